@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
+	"github.com/zorcal/its-a-me-zorcal/internal/termui"
 	"github.com/zorcal/its-a-me-zorcal/pkg/httprouter"
 )
 
@@ -20,16 +21,18 @@ var templatesFS embed.FS
 var staticFS embed.FS
 
 func NewHandler(log *slog.Logger, appVersion string, disableStaticCache bool) (http.Handler, error) {
-	sessMgr := newSessionManager()
-	startSessionCleanupTicker(sessMgr)
-
-	sessAdapter := newSessionAdapter(sessMgr)
-
 	ghFetcher := newCachedGitHubFetcher("Zorcal", 24*time.Hour)
 
 	repos := ghFetcher.FetchRepositories(context.Background(), log)
 	tfs := termfs.New(repos)
 
+	sessMgr := newSessionManager()
+	sessAdapter := newSessionAdapter(sessMgr, tfs)
+	startSessionCleanupTicker(sessAdapter)
+
+	registry := termui.NewRegistry()
+	registry.InstallManPages(tfs)
+
 	static, err := fs.Sub(staticFS, "static")
 	if err != nil {
 		return nil, fmt.Errorf("create sub-filesystem for static files: %w", err)
@@ -44,9 +47,12 @@ func NewHandler(log *slog.Logger, appVersion string, disableStaticCache bool) (h
 
 	r.SetNotFoundHandler(notFoundHandler(), htmlContentTypeMiddleware())
 	r.Handle("/static/", staticHandler(static, appVersion, disableStaticCache))
-	r.Handle("POST /command", commandHandler(sessAdapter, tfs), htmxMiddleware(), htmlContentTypeMiddleware())
+	r.Handle("POST /command", commandHandler(sessAdapter, registry), htmxMiddleware(), htmlContentTypeMiddleware())
 	r.Handle("POST /newline", newlineHandler(sessAdapter), htmxMiddleware(), htmlContentTypeMiddleware())
+	r.Handle("POST /pager", pagerHandler(sessAdapter), htmxMiddleware(), htmlContentTypeMiddleware())
 	r.Handle("GET /history", historyHandler(sessMgr))
+	r.Handle("GET /history/search", historySearchHandler(sessAdapter))
+	r.Handle("GET /complete", completeHandler(sessAdapter, registry))
 	r.Handle("GET /{$}", indexHandler(log, sessAdapter, ghFetcher), htmlContentTypeMiddleware())
 
 	return r, nil
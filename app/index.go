@@ -27,10 +27,11 @@ type IndexData struct {
 	WelcomeBanner template.HTML
 	History       []terminalSessionEntry
 	CurrentPrompt string
+	Pager         pagerTmplData
 }
 
 func indexHandler(log *slog.Logger, sessAdapter *sessionAdapter, ghFetcher *cachedGitHubFetcher) httprouter.Handler {
-	tmpl, err := template.ParseFS(templatesFS, "templates/base.html", "templates/index.html")
+	tmpl, err := template.ParseFS(templatesFS, "templates/base.html", "templates/index.html", "templates/pager.html")
 	if err != nil {
 		return func(w http.ResponseWriter, r *http.Request) error {
 			return fmt.Errorf("parse template fs for index handler: %w", err)
@@ -49,8 +50,12 @@ func indexHandler(log *slog.Logger, sessAdapter *sessionAdapter, ghFetcher *cach
 		data := IndexData{
 			Repos:         ghFetcher.FetchRepositories(r.Context(), log),
 			WelcomeBanner: template.HTML(welcomeBannerHTML),
-			History:       sess.History(),
+			History:       sess.Screen(),
 			CurrentPrompt: termui.GeneratePrompt(sessAdapter.GetCurrentDir(sessionID)),
+			// A pager left open is shown again.
+			Pager: pagerTmplData{
+				View: sessAdapter.updatePager(sessionID, func(*termui.Pager) bool { return false }),
+			},
 		}
 
 		if err := tmpl.ExecuteTemplate(w, "index.html", data); err != nil {
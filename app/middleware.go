@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
 	"html/template"
 	"log/slog"
 	"net/http"
@@ -113,7 +114,7 @@ func panicRecovery(log *slog.Logger) httprouter.Middleware {
 }
 
 func errorMiddleware(log *slog.Logger, sessAdapter *sessionAdapter) httprouter.Middleware {
-	tmpl, err := template.ParseFS(templatesFS, "templates/base.html", "templates/error.html", "templates/command_output.html")
+	tmpl, err := template.ParseFS(templatesFS, "templates/base.html", "templates/error.html", "templates/command_output.html", "templates/pager.html")
 	if err != nil {
 		log.ErrorContext(context.Background(), "Failed to parse template fs for error middleware", "error", err)
 		return func(next httprouter.Handler) httprouter.Handler {
@@ -168,14 +169,14 @@ func errorMiddleware(log *slog.Logger, sessAdapter *sessionAdapter) httprouter.M
 
 			var corrIDHTML string
 			if corrID := tracectx.Get(ctx); corrID != "" {
-				corrIDHTML = fmt.Sprintf(`<div class="error-correlation">Correlation ID: %s</div>`, corrID)
+				corrIDHTML = fmt.Sprintf(`<div class="error-correlation">Correlation ID: %s</div>`, html.EscapeString(corrID))
 			}
 
 			command := getCommand(r)
 			output := template.HTML(fmt.Sprintf(
 				`<div class="error-details">Something went wrong while processing your input.</div><div class="error-code">Error %d: %s</div>%s`,
 				statusCode,
-				errMsg,
+				html.EscapeString(errMsg),
 				corrIDHTML,
 			))
 
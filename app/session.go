@@ -3,12 +3,19 @@ package app
 import (
 	"html/template"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
+	"github.com/zorcal/its-a-me-zorcal/internal/termui"
 	"github.com/zorcal/its-a-me-zorcal/pkg/session"
 )
 
+// sessionFSQuota is the number of bytes each session may write to its
+// filesystem overlay.
+const sessionFSQuota = 1 << 20
+
 type terminalSessionEntry struct {
 	Command   string
 	Output    template.HTML
@@ -31,34 +38,125 @@ func newTerminalSessionEntry(command string, output template.HTML, isError bool)
 	}
 }
 
-func startSessionCleanupTicker(sessionMgr *session.Manager[terminalSessionEntry]) {
+func startSessionCleanupTicker(sessAdapter *sessionAdapter) {
 	go func() {
 		ticker := time.NewTicker(1 * time.Hour)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			sessionMgr.CleanupOldSessions(24 * time.Hour)
+			removed := sessAdapter.mgr.CleanupOldSessions(24 * time.Hour)
+			sessAdapter.removeSessions(removed)
 		}
 	}()
 }
 
 type sessionAdapter struct {
-	mgr    *session.Manager[terminalSessionEntry]
-	dirs   map[string]string
-	dirsMu sync.RWMutex
+	mgr      *session.Manager[terminalSessionEntry]
+	baseFS   *termfs.FS
+	dirs     map[string]string
+	statuses map[string]int
+	envs     map[string]*termui.Vars
+	aliases  map[string]*termui.Vars
+	overlays map[string]*termfs.Overlay
+	pagers   map[string]*termui.Pager
+	mu       sync.RWMutex
 }
 
-func newSessionAdapter(sessionMgr *session.Manager[terminalSessionEntry]) *sessionAdapter {
+func newSessionAdapter(sessionMgr *session.Manager[terminalSessionEntry], tfs *termfs.FS) *sessionAdapter {
 	return &sessionAdapter{
-		mgr:  sessionMgr,
-		dirs: make(map[string]string),
+		mgr:      sessionMgr,
+		baseFS:   tfs,
+		dirs:     make(map[string]string),
+		statuses: make(map[string]int),
+		envs:     make(map[string]*termui.Vars),
+		aliases:  make(map[string]*termui.Vars),
+		overlays: make(map[string]*termfs.Overlay),
+		pagers:   make(map[string]*termui.Pager),
+	}
+}
+
+// FS returns the filesystem of a session: a copy-on-write overlay on top of
+// the shared filesystem, created on first use. Requests without a session get
+// a throwaway overlay, so their writes are never seen by anyone else.
+func (sa *sessionAdapter) FS(sessionID string) *termfs.Overlay {
+	if sessionID == "" {
+		return termfs.NewOverlay(sa.baseFS, sessionFSQuota)
 	}
+
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	overlay, exists := sa.overlays[sessionID]
+	if !exists {
+		overlay = termfs.NewOverlay(sa.baseFS, sessionFSQuota)
+		sa.overlays[sessionID] = overlay
+	}
+	return overlay
+}
+
+// requestSession returns the session of a request, creating it if needed. A
+// request without a session cookie runs in a throwaway session, whose state
+// the returned function discards once the request is done, so that no two
+// such requests share a directory, variables or aliases.
+func (sa *sessionAdapter) requestSession(r *http.Request) (sess *session.Session[terminalSessionEntry], discard func()) {
+	sessionID := getSessionID(r)
+	sess = sa.mgr.GetOrCreateSession(sessionID)
+	if sessionID != "" {
+		return sess, func() {}
+	}
+
+	return sess, func() {
+		sa.mgr.RemoveSession(sess.ID())
+		sa.removeSessions([]string{sess.ID()})
+	}
+}
+
+// removeSessions discards the state kept for the given sessions.
+func (sa *sessionAdapter) removeSessions(sessionIDs []string) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	for _, id := range sessionIDs {
+		delete(sa.dirs, id)
+		delete(sa.statuses, id)
+		delete(sa.envs, id)
+		delete(sa.aliases, id)
+		delete(sa.overlays, id)
+		delete(sa.pagers, id)
+	}
+}
+
+// setPager opens a pager in a session, replacing any open one.
+func (sa *sessionAdapter) setPager(sessionID string, p *termui.Pager) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.pagers[sessionID] = p
+}
+
+// updatePager calls fn with the pager open in a session and returns its view
+// afterwards, or nil if no pager is open. The pager is closed if fn reports
+// that it quit.
+func (sa *sessionAdapter) updatePager(sessionID string, fn func(p *termui.Pager) (quit bool)) *termui.PagerView {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	p, exists := sa.pagers[sessionID]
+	if !exists {
+		return nil
+	}
+	if fn(p) {
+		delete(sa.pagers, sessionID)
+		return nil
+	}
+
+	view := p.View()
+	return &view
 }
 
 // GetCurrentDir implements termui.SessionManager.
 func (sa *sessionAdapter) GetCurrentDir(sessionID string) string {
-	sa.dirsMu.RLock()
-	defer sa.dirsMu.RUnlock()
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
 
 	if dir, exists := sa.dirs[sessionID]; exists {
 		return dir
@@ -68,11 +166,117 @@ func (sa *sessionAdapter) GetCurrentDir(sessionID string) string {
 
 // SetCurrentDir implements termui.SessionManager.
 func (sa *sessionAdapter) SetCurrentDir(sessionID, dir string) {
-	sa.dirsMu.Lock()
-	defer sa.dirsMu.Unlock()
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
 	sa.dirs[sessionID] = dir
 }
 
+// GetLastStatus implements termui.SessionManager.
+func (sa *sessionAdapter) GetLastStatus(sessionID string) int {
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
+	return sa.statuses[sessionID]
+}
+
+// SetLastStatus implements termui.SessionManager.
+func (sa *sessionAdapter) SetLastStatus(sessionID string, status int) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.statuses[sessionID] = status
+}
+
+// GetEnv implements termui.SessionManager.
+func (sa *sessionAdapter) GetEnv(sessionID string) map[string]string {
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
+
+	if vars, exists := sa.envs[sessionID]; exists {
+		return vars.Map()
+	}
+	return termui.DefaultEnv()
+}
+
+// SetEnv implements termui.SessionManager.
+func (sa *sessionAdapter) SetEnv(sessionID, name, value string) error {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	return sa.sessionEnv(sessionID).Set(name, value)
+}
+
+// UnsetEnv implements termui.SessionManager.
+func (sa *sessionAdapter) UnsetEnv(sessionID, name string) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.sessionEnv(sessionID).Unset(name)
+}
+
+// sessionEnv returns the environment of a session, creating it if needed,
+// held within termui.EnvQuota. The caller must hold sa.mu.
+func (sa *sessionAdapter) sessionEnv(sessionID string) *termui.Vars {
+	vars, exists := sa.envs[sessionID]
+	if !exists {
+		vars = termui.NewVars(termui.DefaultEnv(), termui.EnvQuota)
+		sa.envs[sessionID] = vars
+	}
+	return vars
+}
+
+// GetAliases implements termui.SessionManager.
+func (sa *sessionAdapter) GetAliases(sessionID string) map[string]string {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	return sa.sessionAliases(sessionID).Map()
+}
+
+// SetAlias implements termui.SessionManager.
+func (sa *sessionAdapter) SetAlias(sessionID, name, value string) error {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	return sa.sessionAliases(sessionID).Set(name, value)
+}
+
+// UnsetAlias implements termui.SessionManager.
+func (sa *sessionAdapter) UnsetAlias(sessionID, name string) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.sessionAliases(sessionID).Unset(name)
+}
+
+// sessionAliases returns the aliases of a session, seeding them from the
+// ~/.bashrc of the shared filesystem if needed, held within
+// termui.AliasQuota. The caller must hold sa.mu.
+func (sa *sessionAdapter) sessionAliases(sessionID string) *termui.Vars {
+	aliases, exists := sa.aliases[sessionID]
+	if !exists {
+		aliases = termui.NewVars(termui.DefaultAliases(sa.baseFS), termui.AliasQuota)
+		sa.aliases[sessionID] = aliases
+	}
+	return aliases
+}
+
+// GetHistory implements termui.SessionManager.
+func (sa *sessionAdapter) GetHistory(sessionID string) []string {
+	if sessionID == "" {
+		return nil
+	}
+
+	var lines []string
+	for _, entry := range sa.mgr.GetOrCreateSession(sessionID).History() {
+		if cmd := strings.TrimSpace(entry.Command); cmd != "" {
+			lines = append(lines, cmd)
+		}
+	}
+	return lines
+}
+
+// ClearHistory implements termui.SessionManager.
+func (sa *sessionAdapter) ClearHistory(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	sa.mgr.GetOrCreateSession(sessionID).ClearHistory()
+}
+
 func getSessionID(r *http.Request) string {
 	cookie, err := r.Cookie("session_id")
 	if err != nil {
@@ -10,6 +10,13 @@ document.addEventListener("DOMContentLoaded", () => {
 	// Command history navigation
 	let commandHistory = [];
 	let historyIndex = -1;
+	// Cursor of the server history the commands are synced up to, set only
+	// from the answers to /history
+	let historySeq = 0;
+	// The fetch of the history running, and whether another one was asked for
+	// while it ran
+	let historyFetch = null;
+	let historyFetchAgain = false;
 
 	// Text-based cursor management
 	let actualInputValue = ""; // The real command without the cursor
@@ -18,26 +25,69 @@ document.addEventListener("DOMContentLoaded", () => {
 	// Track locally stored newline commands for later server sync
 	let pendingNewlines = 0;
 
-	// Fetch command history from server
-	async function fetchCommandHistory() {
+	// Fetch the commands run since the last fetch from the server. Fetches
+	// run one at a time, each from the cursor the one before left, so that
+	// no command is appended twice.
+	function fetchCommandHistory() {
+		if (historyFetch) {
+			historyFetchAgain = true;
+			return historyFetch;
+		}
+
+		historyFetch = (async () => {
+			try {
+				do {
+					historyFetchAgain = false;
+					await fetchHistorySince();
+				} while (historyFetchAgain);
+			} finally {
+				historyFetch = null;
+			}
+		})();
+		return historyFetch;
+	}
+
+	// Fetch the commands run after historySeq. The server answers 304 Not
+	// Modified, through the browser cache, when nothing changed.
+	async function fetchHistorySince() {
 		try {
-			const response = await fetch("/history");
-			if (response.ok) {
-				const data = await response.json();
-				commandHistory = Array.isArray(data) ? data : [];
-				historyIndex = commandHistory.length; // Start at end of history
-			} else {
-				console.warn("Failed to fetch command history, using empty array");
+			const response = await fetch(`/history?since=${historySeq}`);
+			if (!response.ok) {
+				console.warn("Failed to fetch command history");
+				return;
+			}
+
+			const data = await response.json();
+			if (data.reset) {
 				commandHistory = [];
-				historyIndex = 0;
 			}
+			for (const command of data.commands ?? []) {
+				appendHistory(command);
+			}
+			historySeq = data.seq;
+			historyIndex = commandHistory.length; // Start at end of history
 		} catch (error) {
 			console.error("Failed to fetch command history:", error);
-			commandHistory = []; // Always ensure it's an array
-			historyIndex = 0;
 		}
 	}
 
+	// Add a command to the end of the history, unless it repeats the last one
+	function appendHistory(command) {
+		if (commandHistory[commandHistory.length - 1] !== command) {
+			commandHistory.push(command);
+		}
+	}
+
+	// Record the command that ran, whose entry in the server history is seq,
+	// by fetching it along with any commands other tabs of the session ran
+	// before it. Nothing is fetched if the history is already synced past it.
+	function recordCommand(seq) {
+		if (seq && seq <= historySeq) {
+			return;
+		}
+		fetchCommandHistory();
+	}
+
 	// Navigate command history
 	function navigateHistory(direction) {
 		if (!commandHistory || commandHistory.length === 0) return;
@@ -72,12 +122,187 @@ document.addEventListener("DOMContentLoaded", () => {
 		}
 	}
 
-	// Initial fetch of command history
+	// Initial fetch of command history, and of the commands other tabs of
+	// the session ran while away
 	fetchCommandHistory();
+	window.addEventListener("focus", fetchCommandHistory);
+
+	// Reverse incremental history search, started with Ctrl+R. The server
+	// ranks the matches; Ctrl+R again moves to the next one.
+	let search = null; // { query, matches, index, savedPrompt, savedValue }
+
+	function startSearch() {
+		search = {
+			query: "",
+			matches: [],
+			index: 0,
+			savedPrompt: prompt.textContent,
+			savedValue: actualInputValue,
+		};
+		renderSearch();
+	}
+
+	async function updateSearch(query) {
+		search.query = query;
+		search.index = 0;
+		if (query === "") {
+			search.matches = [];
+			renderSearch();
+			return;
+		}
+
+		try {
+			const response = await fetch(
+				`/history/search?q=${encodeURIComponent(query)}`,
+			);
+			const data = response.ok ? await response.json() : [];
+			// Ignore answers to queries typed over since
+			if (search && search.query === query) {
+				search.matches = Array.isArray(data) ? data : [];
+				renderSearch();
+			}
+		} catch (error) {
+			console.error("Failed to search command history:", error);
+		}
+	}
+
+	// Show the search in the prompt and the match, with the cursor where
+	// the query matched, in the input line
+	function renderSearch() {
+		const match = search.matches[search.index] ?? "";
+		const failed = search.query !== "" && search.matches.length === 0;
+		prompt.textContent = `(${failed ? "failed " : ""}reverse-i-search)\`${search.query}': `;
+
+		const at = Math.max(match.indexOf(search.query), 0);
+		inputText.textContent = match.substring(0, at) + "│" + match.substring(at);
+	}
+
+	// End the search, keeping the match in the input line if accepted, or
+	// restoring the line from before the search
+	function endSearch(accept) {
+		const match = search.matches[search.index];
+		prompt.textContent = search.savedPrompt;
+		actualInputValue =
+			accept && match !== undefined ? match : search.savedValue;
+		search = null;
+
+		input.value = actualInputValue;
+		input.setSelectionRange(actualInputValue.length, actualInputValue.length);
+		updateDisplay();
+	}
+
+	// Handle a key pressed while searching: Enter runs the match, Ctrl+G and
+	// Ctrl+C cancel, Escape, Tab and the arrow keys keep the match to edit it,
+	// and other keys edit the query
+	function handleSearchKey(e) {
+		e.preventDefault();
+
+		if (e.ctrlKey && e.key === "r") {
+			if (search.index < search.matches.length - 1) search.index++;
+			renderSearch();
+		} else if (e.ctrlKey && (e.key === "g" || e.key === "c")) {
+			endSearch(false);
+		} else if (e.key === "Enter") {
+			endSearch(true);
+			if (actualInputValue.trim() !== "") {
+				htmx.trigger("#command-form", "submit");
+			}
+		} else if (
+			e.key === "Escape" ||
+			e.key === "Tab" ||
+			e.key.startsWith("Arrow")
+		) {
+			endSearch(true);
+		} else if (e.key === "Backspace") {
+			updateSearch(search.query.slice(0, -1));
+		} else if (e.key.length === 1 && !e.ctrlKey && !e.metaKey && !e.altKey) {
+			updateSearch(search.query + e.key);
+		}
+	}
+
+	// Complete the word at the cursor. A single candidate is inserted
+	// directly; otherwise the longest common prefix of the candidates is
+	// inserted, or the candidates are listed if there is none to insert.
+	async function completeInput() {
+		const line = actualInputValue;
+		const cursor = input.selectionStart || 0;
+
+		let completion;
+		try {
+			const params = new URLSearchParams({ line, cursor });
+			const response = await fetch(`/complete?${params}`);
+			if (!response.ok) return;
+			completion = await response.json();
+		} catch (error) {
+			console.error("Failed to fetch completions:", error);
+			return;
+		}
+
+		// Ignore the result if the input changed while waiting for it
+		if (actualInputValue !== line) return;
+
+		const candidates = completion.candidates || [];
+		if (candidates.length === 0) return;
+
+		let replacement;
+		if (candidates.length === 1) {
+			replacement = candidates[0].value;
+			if (!replacement.endsWith("/")) replacement += " ";
+		} else {
+			replacement = commonPrefix(candidates.map((c) => c.value));
+		}
+
+		const word = line.substring(completion.start, completion.end);
+		if (replacement.length > word.length) {
+			actualInputValue =
+				line.substring(0, completion.start) +
+				replacement +
+				line.substring(completion.end);
+			const newCursor = completion.start + replacement.length;
+			input.value = actualInputValue;
+			input.setSelectionRange(newCursor, newCursor);
+			updateDisplay();
+			return;
+		}
+
+		listCandidates(line, candidates);
+	}
+
+	function commonPrefix(values) {
+		let prefix = values[0];
+		for (const value of values.slice(1)) {
+			while (!value.startsWith(prefix)) {
+				prefix = prefix.substring(0, prefix.length - 1);
+			}
+		}
+		return prefix;
+	}
+
+	// Show the candidates below the current line, like a shell does
+	function listCandidates(line, candidates) {
+		const entry = document.createElement("div");
+
+		const promptDiv = document.createElement("div");
+		promptDiv.className = "command-prompt";
+		promptDiv.textContent = prompt.textContent + line;
+
+		const outputDiv = document.createElement("div");
+		outputDiv.className = "command-output";
+		const pre = document.createElement("pre");
+		pre.textContent = candidates.map((c) => c.display).join("  ");
+		outputDiv.appendChild(pre);
+
+		entry.appendChild(promptDiv);
+		entry.appendChild(outputDiv);
+		document.getElementById("command-output").appendChild(entry);
+
+		const historyDiv = document.getElementById("command-history");
+		historyDiv.scrollTop = historyDiv.scrollHeight;
+	}
 
 	// Update display with cursor at current position
 	function updateDisplay() {
-		if (suppressDisplayUpdate) return;
+		if (suppressDisplayUpdate || search) return;
 
 		const cursorPos = input.selectionStart || 0;
 		const beforeCursor = actualInputValue.substring(0, cursorPos);
@@ -132,10 +357,13 @@ document.addEventListener("DOMContentLoaded", () => {
 				const historyDiv = document.getElementById("command-history");
 				const currentPrompt = document.getElementById("prompt").textContent;
 				const emptyEntry = document.createElement("div");
-				emptyEntry.innerHTML = `
-				<div class="command-prompt">${currentPrompt}</div>
-				<div class="command-output"></div>
-			`;
+				const promptDiv = document.createElement("div");
+				promptDiv.className = "command-prompt";
+				promptDiv.textContent = currentPrompt;
+				const outputDiv = document.createElement("div");
+				outputDiv.className = "command-output";
+				emptyEntry.appendChild(promptDiv);
+				emptyEntry.appendChild(outputDiv);
 				document.getElementById("command-output").appendChild(emptyEntry);
 
 				// Track this newline for later server sync
@@ -174,11 +402,110 @@ document.addEventListener("DOMContentLoaded", () => {
 		true,
 	); // Use capture phase to run before HTMX
 
+	// Keys sent to the pager, by the key the user pressed
+	const pagerKeys = {
+		" ": " ",
+		f: " ",
+		PageDown: " ",
+		b: "b",
+		PageUp: "b",
+		j: "j",
+		ArrowDown: "j",
+		Enter: "j",
+		k: "k",
+		ArrowUp: "k",
+		g: "g",
+		Home: "g",
+		G: "G",
+		End: "G",
+		n: "n",
+		N: "N",
+		q: "q",
+		Escape: "q",
+	};
+
+	function pagerOpen() {
+		return document.getElementById("pager").classList.contains("active");
+	}
+
+	// Send a key to the pager, which answers with its new screen
+	function sendPagerKey(key, pattern = "") {
+		htmx
+			.ajax("POST", "/pager", {
+				headers: {
+					"Content-Type": "application/x-www-form-urlencoded",
+				},
+				values: { key, pattern, rows: pagerRows() },
+				target: "#pager",
+				swap: "outerHTML",
+			})
+			.then(() => {
+				if (!pagerOpen()) input.focus();
+			});
+	}
+
+	// Read a search pattern in the status line, like less does after /
+	function openPagerSearch() {
+		const status = document.querySelector("#pager .pager-status");
+		const search = document.createElement("input");
+		search.id = "pager-search";
+		search.type = "text";
+		search.autocomplete = "off";
+		status.textContent = "/";
+		status.appendChild(search);
+		search.focus();
+	}
+
+	// While the pager is open it receives every key, before the handlers of
+	// the command line
+	document.addEventListener(
+		"keydown",
+		(e) => {
+			if (!pagerOpen() || e.ctrlKey || e.metaKey || e.altKey) return;
+			e.stopPropagation();
+
+			if (e.target.id === "pager-search") {
+				if (e.key === "Enter") {
+					e.preventDefault();
+					sendPagerKey("/", e.target.value);
+				} else if (e.key === "Escape") {
+					e.preventDefault();
+					sendPagerKey("");
+				}
+				return;
+			}
+
+			e.preventDefault();
+			if (e.key === "/") {
+				openPagerSearch();
+			} else if (pagerKeys[e.key]) {
+				sendPagerKey(pagerKeys[e.key]);
+			}
+		},
+		true,
+	);
+
+	// Redraw the pager for the new number of rows
+	window.addEventListener("resize", () => {
+		if (pagerOpen()) sendPagerKey("");
+	});
+
 	// Handle keyboard shortcuts
 	document.addEventListener("keydown", (e) => {
 		// Only apply special handling when the input field is focused
 		if (document.activeElement !== input) return;
 
+		if (search) {
+			handleSearchKey(e);
+			return;
+		}
+
+		if (e.ctrlKey && e.key === "r") {
+			e.preventDefault();
+			startSearch();
+			return;
+		}
+
 		if (e.ctrlKey && e.key === "l") {
 			e.preventDefault();
 			// Suppress display updates to prevent flicker
@@ -208,9 +535,10 @@ document.addEventListener("DOMContentLoaded", () => {
 			navigateHistory("down");
 		}
 
-		// Prevent default tab behavior (common in terminals for autocomplete)
+		// Tab completes the word at the cursor
 		if (e.key === "Tab") {
 			e.preventDefault();
+			completeInput();
 		}
 	});
 
@@ -219,13 +547,14 @@ document.addEventListener("DOMContentLoaded", () => {
 		prompt.textContent = e.detail.updatePrompt;
 	});
 
-	// Focus input on click anywhere
+	// Focus input on click anywhere, unless searching in the pager
 	document.addEventListener("click", () => {
+		if (document.getElementById("pager-search")) return;
 		input.focus();
 	});
 
 	// HTMX event handlers
-	window.handleCommandSubmit = () => {
+	window.handleCommandSubmit = (event) => {
 		const form = document.getElementById("command-form");
 
 		// Reset everything after submission (only called for successful server requests)
@@ -247,8 +576,10 @@ document.addEventListener("DOMContentLoaded", () => {
 		suppressDisplayUpdate = false;
 		updateDisplay();
 
-		// Refresh command history immediately - the command response has already been processed
-		fetchCommandHistory();
+		// Record the command in the history - the command response has already been processed
+		recordCommand(
+			Number(event.detail.xhr.getResponseHeader("X-History-Seq")),
+		);
 	};
 
 	window.handleCommandError = (event) => {
@@ -302,6 +633,14 @@ document.addEventListener("DOMContentLoaded", () => {
 	}
 });
 
+// Number of rows that fit in the pager, including its status line. Rows are
+// 16px high, as set in index.css.
+function pagerRows() {
+	const pager = document.getElementById("pager");
+	const height = pager.clientHeight || pager.parentElement.clientHeight - 40;
+	return Math.max(2, Math.floor(height / 16));
+}
+
 function checkScreenSize() {
 	const isSmallScreen = window.innerWidth <= 768 || window.innerHeight <= 600;
 	let warning = document.getElementById("screen-size-warning");
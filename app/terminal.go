@@ -1,15 +1,15 @@
 package app
 
 import (
+	"crypto/md5"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"html"
 	"html/template"
 	"net/http"
 	"strconv"
 	"strings"
 
-	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
 	"github.com/zorcal/its-a-me-zorcal/internal/termui"
 	"github.com/zorcal/its-a-me-zorcal/pkg/httprouter"
 	"github.com/zorcal/its-a-me-zorcal/pkg/session"
@@ -21,10 +21,21 @@ type cmdTmplData struct {
 	Error      bool
 	Prompt     string
 	NextPrompt string
+	Pager      *pagerTmplData
 }
 
-func commandHandler(sessAdapter *sessionAdapter, tfs *termfs.FS) httprouter.Handler {
-	tmpl, err := template.ParseFS(templatesFS, "templates/command_output.html")
+type pagerTmplData struct {
+	// View is the screen of the open pager, or nil if none is open.
+	View *termui.PagerView
+	// OOB swaps the pager into the page out of band of the command output.
+	OOB bool
+}
+
+// maxPagerRows bounds the number of rows a client may ask a pager to show.
+const maxPagerRows = 500
+
+func commandHandler(sessAdapter *sessionAdapter, registry *termui.Registry) httprouter.Handler {
+	tmpl, err := template.ParseFS(templatesFS, "templates/command_output.html", "templates/pager.html")
 	if err != nil {
 		return func(w http.ResponseWriter, r *http.Request) error {
 			return fmt.Errorf("parse template fs for command handler: %w", err)
@@ -36,8 +47,9 @@ func commandHandler(sessAdapter *sessionAdapter, tfs *termfs.FS) httprouter.Hand
 			return wrapHTTPError(http.StatusBadRequest, "Bad form data", err)
 		}
 
-		sessionID := getSessionID(r)
-		sess := sessAdapter.mgr.GetOrCreateSession(sessionID)
+		sess, discard := sessAdapter.requestSession(r)
+		defer discard()
+		sessionID := sess.ID()
 
 		// Handle any pending newlines first (sent as a parameter).
 		if newlinesStr := r.FormValue("newlines"); newlinesStr != "" {
@@ -57,304 +69,84 @@ func commandHandler(sessAdapter *sessionAdapter, tfs *termfs.FS) httprouter.Hand
 		currDir := sessAdapter.GetCurrentDir(sessionID)
 		currPrompt := termui.GeneratePrompt(currDir)
 
-		parts := strings.Fields(cmdLine)
-		var cmd string
-		var args []string
-
-		if len(parts) > 0 {
-			cmd = parts[0]
-			args = parts[1:]
+		env := &termui.Env{
+			FS:        sessAdapter.FS(sessionID),
+			Sessions:  sessAdapter,
+			SessionID: sessionID,
 		}
 
-		switch cmd {
-		case "clear":
-			runClearCommand(w, sess)
-			return nil
-		case "help":
-			return runHelpCommand(w, sess, tmpl, cmdLine, currPrompt)
-		case "open":
-			return runOpenCommand(w, sess, tmpl, tfs, sessAdapter, sessionID, cmdLine, args, currPrompt)
-		case "cd":
-			return runCdCommand(w, sess, tmpl, tfs, sessAdapter, sessionID, cmdLine, args, currPrompt)
-		case "ls":
-			return runLsCommand(w, sess, tmpl, tfs, sessAdapter, sessionID, cmdLine, args, currPrompt)
-		case "pwd":
-			return runPwdCommand(w, sess, tmpl, sessAdapter, sessionID, currPrompt)
-		case "cat":
-			return runCatCommand(w, sess, tmpl, tfs, sessAdapter, sessionID, cmdLine, args, currPrompt)
-		default:
-			return runUnknownCommand(w, sess, tmpl, cmd, currPrompt)
+		out, status := registry.Exec(env, cmdLine)
+
+		// Clearing the screen keeps the history: the screen shown on reload
+		// starts after the clear.
+		if out.Clear {
+			w.Header().Set("HX-Retarget", "#command-output")
+			w.Header().Set("HX-Reswap", "innerHTML")
+			if len(out.Chunks()) == 0 {
+				recordCommand(w, sess, out.Line, "", status != 0, currPrompt)
+				sess.ClearScreen()
+				w.Write([]byte(""))
+				return nil
+			}
+			sess.ClearScreen()
 		}
-	}
-}
-
-func runClearCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry]) {
-	sess.ClearHistory()
-	w.Header().Set("HX-Retarget", "#command-output")
-	w.Header().Set("HX-Reswap", "innerHTML")
-	w.Write([]byte(""))
-}
-
-func runHelpCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, cmdLine string, currPrompt string) error {
-	output := `<div class="help">Available commands:
-
-  <strong>ls [options] [path]</strong> - List directory contents
-                        -a, --all: show hidden files (starting with .)
-                        -l, --long: long format (d/c/o):
-                            d-- = directory
-                            -c- = catable
-                            --o = openable
-  <strong>cd [path]</strong>     - Change directory
-  <strong>pwd</strong>           - Print working directory
-  <strong>cat [file]</strong>    - Display file contents
-  <strong>open [file]</strong>   - Open files containing URLs in browser
-  <strong>clear</strong>         - Clear terminal history (or use Ctrl+L)
-  <strong>help</strong>          - Show this help message
-
-Notes:
-  • Use Ctrl+L to clear the terminal
-
-</div>`
-
-	entry := newTerminalSessionEntry(cmdLine, template.HTML(output), false)
-	entry.Prompt = currPrompt
-	sess.AddEntry(entry)
-
-	data := cmdTmplData{
-		Command:    cmdLine,
-		Output:     template.HTML(output),
-		Error:      false,
-		Prompt:     currPrompt,
-		NextPrompt: currPrompt,
-	}
-
-	if err := tmpl.Execute(w, data); err != nil {
-		return fmt.Errorf("exec template: %w", err)
-	}
-
-	return nil
-}
-
-func runOpenCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, tfs *termfs.FS, sessAdapter *sessionAdapter, sessionID, cmdLine string, args []string, currPrompt string) error {
-	var (
-		output  string
-		isError bool
-	)
 
-	result, err := termui.OpenFile(tfs, sessAdapter, sessionID, args)
-	if err != nil {
-		isError = true
-		switch {
-		case errors.Is(err, termui.ErrMissingArgument):
-			output = "open: missing file argument"
-		case errors.Is(err, termui.ErrFileNotFound):
-			output = fmt.Sprintf("open: %s: No such file or directory", result)
-		case errors.Is(err, termui.ErrIsDirectory):
-			output = fmt.Sprintf("open: %s: Is a directory", result)
-		case errors.Is(err, termui.ErrNotOpenable):
-			output = "open: file is not openable"
-		default:
-			output = "open: internal error"
+		if out.OpenURL != "" {
+			w.Header().Set("X-Open-URL", out.OpenURL)
 		}
-	} else {
-		w.Header().Set("X-Open-URL", result)
-		output = fmt.Sprintf("Opening %s in browser...", args[0])
-		isError = false
-	}
-
-	entry := newTerminalSessionEntry(cmdLine, template.HTML(output), isError)
-	entry.Prompt = currPrompt
-	sess.AddEntry(entry)
 
-	data := cmdTmplData{
-		Command:    cmdLine,
-		Output:     template.HTML(output),
-		Error:      isError,
-		Prompt:     currPrompt,
-		NextPrompt: currPrompt,
-	}
-
-	if err := tmpl.Execute(w, data); err != nil {
-		return fmt.Errorf("exec template: %w", err)
-	}
-
-	return nil
-}
-
-func runCdCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, tfs *termfs.FS, sessAdapter *sessionAdapter, sessionID, cmdLine string, args []string, currPrompt string) error {
-	var (
-		output  string
-		isError bool
-	)
+		var pager *pagerTmplData
+		if out.Pager != nil {
+			if rows := pagerRows(r); rows > 0 {
+				out.Pager.SetHeight(rows)
+			}
+			sessAdapter.setPager(sessionID, out.Pager)
 
-	target, err := termui.ChangeDirectory(tfs, sessAdapter, sessionID, args)
-	if err != nil {
-		isError = true
-		switch {
-		case errors.Is(err, termui.ErrFileNotFound):
-			output = fmt.Sprintf("cd: %s: No such file or directory", target)
-		case errors.Is(err, termui.ErrNotDirectory):
-			output = fmt.Sprintf("cd: %s: Not a directory", target)
-		case errors.Is(err, termui.ErrAccessDenied):
-			output = fmt.Sprintf("cd: %s: Permission denied", target)
-		default:
-			output = fmt.Sprintf("cd: %s: internal error", target)
+			view := out.Pager.View()
+			pager = &pagerTmplData{View: &view, OOB: true}
 		}
-	}
-
-	entry := newTerminalSessionEntry(cmdLine, template.HTML(output), isError)
-	entry.Prompt = currPrompt
-	sess.AddEntry(entry)
-
-	data := cmdTmplData{
-		Command:    cmdLine,
-		Output:     template.HTML(output),
-		Error:      isError,
-		Prompt:     currPrompt,
-		NextPrompt: termui.GeneratePrompt(sessAdapter.GetCurrentDir(sessionID)),
-	}
 
-	if err := tmpl.Execute(w, data); err != nil {
-		return fmt.Errorf("exec template: %w", err)
+		nextPrompt := termui.GeneratePrompt(sessAdapter.GetCurrentDir(sessionID))
+		return writeCommandOutput(w, sess, tmpl, cmdLine, out.Line, renderOutput(out), status != 0, currPrompt, nextPrompt, pager)
 	}
-
-	return nil
 }
 
-func runLsCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, tfs *termfs.FS, sessAdapter *sessionAdapter, sessionID, cmdLine string, args []string, currPrompt string) error {
-	var (
-		output  string
-		isError bool
-	)
-
-	result, err := termui.ListDirectoryContents(tfs, sessAdapter, sessionID, args)
-	if err != nil {
-		isError = true
-		switch {
-		case errors.Is(err, termui.ErrFileNotFound):
-			output = fmt.Sprintf("ls: %s: No such file or directory", result)
-		case errors.Is(err, termui.ErrTooManyArguments):
-			output = "ls: too many arguments"
-		case errors.Is(err, termui.ErrAccessDenied):
-			output = "ls: Permission denied"
-		case errors.Is(err, termui.ErrInvalidFlag):
-			output = "ls: invalid flag or option"
-		default:
-			output = "ls: internal error"
+// renderOutput renders the terminal output of a command line as HTML. Only
+// the chunks' HTML is used, in which text written by commands is escaped.
+func renderOutput(out *termui.Output) template.HTML {
+	var b strings.Builder
+	for _, chunk := range out.Chunks() {
+		if chunk.Class == "" {
+			fmt.Fprintf(&b, "<pre>%s</pre>", chunk.HTML)
+			continue
 		}
-	} else {
-		output = fmt.Sprintf("<pre class=\"file-list\">%s</pre>", result)
+		fmt.Fprintf(&b, "<pre class=\"%s\">%s</pre>", html.EscapeString(chunk.Class), chunk.HTML)
 	}
-
-	entry := newTerminalSessionEntry(cmdLine, template.HTML(output), isError)
-	entry.Prompt = currPrompt
-	sess.AddEntry(entry)
-
-	data := cmdTmplData{
-		Command:    cmdLine,
-		Output:     template.HTML(output),
-		Error:      isError,
-		Prompt:     currPrompt,
-		NextPrompt: currPrompt,
-	}
-
-	if err := tmpl.Execute(w, data); err != nil {
-		return fmt.Errorf("exec template: %w", err)
-	}
-
-	return nil
+	return template.HTML(b.String())
 }
 
-func runPwdCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, sessAdapter *sessionAdapter, sessionID, currPrompt string) error {
-	var (
-		output  string
-		isError bool
-	)
-
-	result, err := termui.PrintWorkingDirectory(sessAdapter, sessionID)
-	if err != nil {
-		isError = true
-		output = "pwd: internal error"
-	} else {
-		output = result
-	}
-
-	entry := newTerminalSessionEntry("pwd", template.HTML(output), isError)
-	entry.Prompt = currPrompt
-	sess.AddEntry(entry)
-
-	data := cmdTmplData{
-		Command:    "pwd",
-		Output:     template.HTML(output),
-		Error:      isError,
-		Prompt:     currPrompt,
-		NextPrompt: currPrompt,
-	}
-
-	if err := tmpl.Execute(w, data); err != nil {
-		return fmt.Errorf("exec template: %w", err)
-	}
-
-	return nil
+// recordCommand records the command line that ran in the session history,
+// and sends the sequence number of its entry in the X-History-Seq header.
+func recordCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], cmdLine string, output template.HTML, isError bool, prompt string) {
+	entry := newTerminalSessionEntry(cmdLine, output, isError)
+	entry.Prompt = prompt
+	seq := sess.AddEntry(entry)
+	w.Header().Set("X-History-Seq", strconv.FormatInt(seq, 10))
 }
 
-func runCatCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, tfs *termfs.FS, sessAdapter *sessionAdapter, sessionID, cmdLine string, args []string, currPrompt string) error {
-	var (
-		output  string
-		isError bool
-	)
-
-	result, err := termui.CatFile(tfs, sessAdapter, sessionID, args)
-	if err != nil {
-		isError = true
-		switch {
-		case errors.Is(err, termui.ErrMissingArgument):
-			output = "cat: missing file argument"
-		case errors.Is(err, termui.ErrFileNotFound):
-			output = fmt.Sprintf("cat: %s: No such file or directory", result)
-		case errors.Is(err, termui.ErrIsDirectory):
-			output = fmt.Sprintf("cat: %s: Is a directory", result)
-		case errors.Is(err, termui.ErrAccessDenied):
-			output = fmt.Sprintf("cat: %s: Permission denied", result)
-		default:
-			output = "cat: internal error"
-		}
-	} else {
-		output = fmt.Sprintf("<pre class=\"file-content\">%s</pre>", result)
-	}
-
-	entry := newTerminalSessionEntry(cmdLine, template.HTML(output), isError)
-	entry.Prompt = currPrompt
-	sess.AddEntry(entry)
+// writeCommandOutput records the command line that ran, histLine, in the
+// session history and renders the command line as typed, cmdLine, to w,
+// along with the pager it opened, if any.
+func writeCommandOutput(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, cmdLine, histLine string, output template.HTML, isError bool, currPrompt, nextPrompt string, pager *pagerTmplData) error {
+	recordCommand(w, sess, histLine, output, isError, currPrompt)
 
 	data := cmdTmplData{
 		Command:    cmdLine,
-		Output:     template.HTML(output),
+		Output:     output,
 		Error:      isError,
 		Prompt:     currPrompt,
-		NextPrompt: currPrompt,
-	}
-
-	if err := tmpl.Execute(w, data); err != nil {
-		return fmt.Errorf("exec template: %w", err)
-	}
-
-	return nil
-}
-
-func runUnknownCommand(w http.ResponseWriter, sess *session.Session[terminalSessionEntry], tmpl *template.Template, cmd, currPrompt string) error {
-	output := fmt.Sprintf("shell: %s: command not found...", cmd)
-
-	entry := newTerminalSessionEntry(cmd, template.HTML(output), true)
-	entry.Prompt = currPrompt
-	sess.AddEntry(entry)
-
-	data := cmdTmplData{
-		Command:    cmd,
-		Output:     template.HTML(output),
-		Error:      true,
-		Prompt:     currPrompt,
-		NextPrompt: currPrompt,
+		NextPrompt: nextPrompt,
+		Pager:      pager,
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
@@ -377,8 +169,9 @@ func newlineHandler(sessAdapter *sessionAdapter) httprouter.Handler {
 			return wrapHTTPError(http.StatusBadRequest, "Bad form data", err)
 		}
 
-		sessionID := getSessionID(r)
-		sess := sessAdapter.mgr.GetOrCreateSession(sessionID)
+		sess, discard := sessAdapter.requestSession(r)
+		defer discard()
+		sessionID := sess.ID()
 
 		currDir := sessAdapter.GetCurrentDir(sessionID)
 		currPrompt := termui.GeneratePrompt(currDir)
@@ -407,26 +200,198 @@ func newlineHandler(sessAdapter *sessionAdapter) httprouter.Handler {
 	}
 }
 
+// pagerHandler sends a key the user pressed to the pager open in the session
+// and renders its screen, or an empty pager once it quits. The key "/"
+// searches for the pattern form value.
+func pagerHandler(sessAdapter *sessionAdapter) httprouter.Handler {
+	tmpl, err := template.ParseFS(templatesFS, "templates/pager.html")
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			return fmt.Errorf("parse template fs for pager handler: %w", err)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return wrapHTTPError(http.StatusBadRequest, "Bad form data", err)
+		}
+
+		rows := pagerRows(r)
+		key := r.FormValue("key")
+
+		view := sessAdapter.updatePager(getSessionID(r), func(p *termui.Pager) bool {
+			if rows > 0 {
+				p.SetHeight(rows)
+			}
+			if key == "/" {
+				p.Search(r.FormValue("pattern"))
+				return false
+			}
+			return p.Key(key)
+		})
+
+		if err := tmpl.ExecuteTemplate(w, "pager", pagerTmplData{View: view}); err != nil {
+			return fmt.Errorf("exec template: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// pagerRows returns the number of rows of the client's pager sent as the rows
+// form value, or 0 if it is missing or invalid.
+func pagerRows(r *http.Request) int {
+	rows, err := strconv.Atoi(r.FormValue("rows"))
+	if err != nil || rows < 0 {
+		return 0
+	}
+	return min(rows, maxPagerRows)
+}
+
+// historyData is the command history of a session, or the commands added
+// to it after a cursor.
+type historyData struct {
+	// Seq is the cursor to ask for the commands run next with.
+	Seq int64 `json:"seq"`
+	// Reset reports that Commands replace the client's commands rather than
+	// follow on from them.
+	Reset    bool     `json:"reset"`
+	Commands []string `json:"commands"`
+}
+
+// historyHandler returns the command lines run in the session as JSON, for
+// the arrow keys of the client, without repeating a line run twice in a row.
+// With the since query parameter, a cursor from an earlier response or the
+// X-History-Seq header of a command, only the lines run after it are
+// returned. Responses carry an ETag, so that unchanged ones are answered
+// with 304 Not Modified.
 func historyHandler(sessMgr *session.Manager[terminalSessionEntry]) httprouter.Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		var since int64
+		if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+			parsed, err := strconv.ParseInt(sinceStr, 10, 64)
+			if err != nil || parsed < 0 {
+				return wrapHTTPError(http.StatusBadRequest, "Bad since", err)
+			}
+			since = parsed
+		}
+
 		sessionID := getSessionID(r)
 		sess := sessMgr.GetOrCreateSession(sessionID)
 
-		history := sess.History()
+		entries, seq, reset := sess.HistorySince(since)
 
-		var commands []string
-		for _, entry := range history {
+		data := historyData{Seq: seq, Reset: reset, Commands: []string{}}
+		for _, entry := range entries {
 			cmd := strings.TrimSpace(entry.Command)
-			if cmd != "" && (len(commands) == 0 || commands[len(commands)-1] != cmd) {
-				commands = append(commands, cmd)
+			if cmd != "" && (len(data.Commands) == 0 || data.Commands[len(data.Commands)-1] != cmd) {
+				data.Commands = append(data.Commands, cmd)
 			}
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(commands); err != nil {
+		body, err := json.Marshal(data)
+		if err != nil {
 			return fmt.Errorf("json encode command history: %w", err)
 		}
 
+		etag := fmt.Sprintf(`"%x"`, md5.Sum(body))
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if match := r.Header.Get("If-None-Match"); match == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(body); err != nil {
+			return fmt.Errorf("write command history: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// maxHistorySearchResults bounds the number of matches a history search
+// returns.
+const maxHistorySearchResults = 50
+
+// historySearchHandler searches the command lines in the session history
+// for the q query parameter, for the reverse incremental search of the
+// client, and returns the matches as JSON, best first.
+func historySearchHandler(sessAdapter *sessionAdapter) httprouter.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		history := sessAdapter.GetHistory(getSessionID(r))
+
+		matches := termui.SearchHistory(history, r.URL.Query().Get("q"))
+		if len(matches) > maxHistorySearchResults {
+			matches = matches[:maxHistorySearchResults]
+		}
+		if matches == nil {
+			matches = []string{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(matches); err != nil {
+			return fmt.Errorf("json encode history search: %w", err)
+		}
+
+		return nil
+	}
+}
+
+type completionData struct {
+	Start      int             `json:"start"`
+	End        int             `json:"end"`
+	Candidates []candidateData `json:"candidates"`
+}
+
+type candidateData struct {
+	Value   string `json:"value"`
+	Display string `json:"display"`
+}
+
+func completeHandler(sessAdapter *sessionAdapter, registry *termui.Registry) httprouter.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		line := r.URL.Query().Get("line")
+
+		cursor := len([]rune(line))
+		if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+			parsed, err := strconv.Atoi(cursorStr)
+			if err != nil || parsed < 0 {
+				return wrapHTTPError(http.StatusBadRequest, "Bad cursor", err)
+			}
+			cursor = parsed
+		}
+
+		// Only sessions the manager knows about get state of their own, so an
+		// unknown cookie can't make the adapter keep state that is never
+		// cleaned up.
+		sessionID := getSessionID(r)
+		if _, exists := sessAdapter.mgr.GetSession(sessionID); !exists {
+			sessionID = ""
+		}
+		env := &termui.Env{
+			FS:        sessAdapter.FS(sessionID),
+			Sessions:  sessAdapter,
+			SessionID: sessionID,
+		}
+
+		comp := registry.Complete(env, line, cursor)
+
+		data := completionData{
+			Start:      comp.Start,
+			End:        comp.End,
+			Candidates: []candidateData{},
+		}
+		for _, c := range comp.Candidates {
+			data.Candidates = append(data.Candidates, candidateData{Value: c.Value, Display: c.Display})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			return fmt.Errorf("json encode completion: %w", err)
+		}
+
 		return nil
 	}
 }
@@ -57,22 +57,8 @@ func (of *openFile) ReadDir(n int) ([]fs.DirEntry, error) {
 	}
 
 	var entries []fs.DirEntry
-
-	// Find all files that are children of this directory.
-	for filePath, file := range of.fs.files {
-		if of.path == "" {
-			// Root directory - include files directly in root.
-			if !strings.Contains(filePath, "/") && filePath != "" {
-				entries = append(entries, &dirEntry{file: file})
-			}
-		} else {
-			// Check if this file is a direct child of the current directory.
-			if after, ok := strings.CutPrefix(filePath, of.path+"/"); ok {
-				if !strings.Contains(after, "/") {
-					entries = append(entries, &dirEntry{file: file})
-				}
-			}
-		}
+	for _, file := range directChildren(of.fs.files, of.path) {
+		entries = append(entries, &dirEntry{file: file})
 	}
 
 	sort.Slice(entries, func(i, j int) bool {
@@ -86,6 +72,28 @@ func (of *openFile) ReadDir(n int) ([]fs.DirEntry, error) {
 	return entries, nil
 }
 
+// directChildren returns the files in files that are direct children of the
+// directory dir, keyed by name.
+func directChildren(files map[string]*File, dir string) map[string]*File {
+	children := make(map[string]*File)
+	for filePath, file := range files {
+		if dir == "" {
+			// Root directory - include files directly in root.
+			if !strings.Contains(filePath, "/") && filePath != "" {
+				children[file.name] = file
+			}
+		} else {
+			// Check if this file is a direct child of the current directory.
+			if after, ok := strings.CutPrefix(filePath, dir+"/"); ok {
+				if !strings.Contains(after, "/") {
+					children[file.name] = file
+				}
+			}
+		}
+	}
+	return children
+}
+
 // dirEntry implements fs.DirEntry.
 type dirEntry struct {
 	file *File
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/fs"
 	"path"
+	"sort"
 	"time"
 
 	"github.com/zorcal/its-a-me-zorcal/pkg/github"
@@ -53,7 +54,8 @@ You can navigate the filesystem, view files, and execute commands just like
 in a real terminal.
 
 Try exploring with 'ls' and 'cd projects' to see my work!
-Run 'help' for a full list of available commands.
+Take the guided tour with 'tour.sh', and run 'help' for a full list of
+available commands.
 
 Happy exploring!
 
@@ -61,6 +63,72 @@ Happy exploring!
 
 	fs.AddFile("home/guest/welcome.txt", []byte(welcomeMessage))
 
+	bashrc := `# ~/.bashrc: read by the shell when a session starts.
+
+# Aliases. List them with 'alias' and define your own with
+# alias name='command'.
+alias ll='ls -l'
+alias la='ls -a'
+alias l='ls -F'
+alias ..='cd ..'
+`
+
+	fs.AddFile("home/guest/.bashrc", []byte(bashrc))
+
+	fs.AddDir("usr")
+	fs.AddDir("usr/local")
+	fs.AddDir("usr/local/bin")
+
+	tour := `#!/bin/sh
+# A guided tour of the terminal. Run it with 'tour.sh', and read it with
+# 'cat /usr/local/bin/tour.sh' to see how it works.
+
+# section prints a heading.
+section() {
+	echo
+	echo "== $1 =="
+}
+
+# show prints a command line, then runs it.
+show() {
+	echo "\$ $*"
+	"$@"
+}
+
+echo "Welcome to the tour of Zorcal's terminal!"
+
+section "Where am I?"
+show pwd
+show ls ~
+
+section "Projects"
+first=
+for file in /home/zorcal/projects/*.md; do
+	if [ -f "$file" ]; then
+		echo "  $file"
+		if [ -z "$first" ]; then
+			first=$file
+		fi
+	fi
+done
+
+if [ -n "$first" ]; then
+	section "A closer look"
+	show head -n 5 "$first"
+else
+	echo "No projects yet, come back soon."
+fi
+
+section "Try it yourself"
+echo "  cd /home/zorcal/projects && ls -l     move around"
+echo "  grep -ri go /home/zorcal/projects     search the projects"
+echo "  ls | wc -l                            connect commands with |"
+echo "  for f in *.md; do echo \$f; done       loop over files"
+echo "  help, man sh                          read the manual"
+`
+
+	fs.AddFile("usr/local/bin/tour.sh", []byte(tour))
+
 	// Easter egg.
 	secretMessage := `🎉 Congratulations! You found the secret file! 🎉
 
@@ -83,10 +151,7 @@ func (f *FS) Open(name string) (fs.File, error) {
 		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
 	}
 
-	name = path.Clean(name)
-	if name == "." {
-		name = ""
-	}
+	name = cleanPath(name)
 
 	file, exists := f.files[name]
 	if !exists {
@@ -96,12 +161,16 @@ func (f *FS) Open(name string) (fs.File, error) {
 	return &openFile{file: file, fs: f, path: name}, nil
 }
 
+// Glob implements fs.GlobFS. Since the filesystem is a flat map of paths and
+// * never matches a separator, every path is matched against the whole
+// pattern in a single pass instead of walking the directories.
+func (f *FS) Glob(pattern string) ([]string, error) {
+	return globFiles(pattern, f.files)
+}
+
 // AddDir creates a new directory in the filesystem.
 func (f *FS) AddDir(name string) {
-	name = path.Clean(name)
-	if name == "." {
-		name = ""
-	}
+	name = cleanPath(name)
 
 	f.files[name] = &File{
 		name:     path.Base(name),
@@ -130,3 +199,38 @@ func (f *FS) AddFile(name string, content []byte) {
 		parent.children[path.Base(name)] = f.files[name]
 	}
 }
+
+// cleanPath cleans a slash-separated path, representing the root directory
+// as the empty string.
+func cleanPath(name string) string {
+	name = path.Clean(name)
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+// globFiles returns the sorted paths in the given file maps matching pattern.
+func globFiles(pattern string, fileMaps ...map[string]*File) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var matches []string
+	for _, files := range fileMaps {
+		for name := range files {
+			if name == "" || seen[name] {
+				continue
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				seen[name] = true
+				matches = append(matches, name)
+			}
+		}
+	}
+
+	sort.Strings(matches)
+
+	return matches, nil
+}
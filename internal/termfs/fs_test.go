@@ -4,7 +4,9 @@ import (
 	"errors"
 	"io"
 	"io/fs"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -164,7 +166,7 @@ func TestFS_readDir(t *testing.T) {
 		{
 			name:      "root directory",
 			path:      ".",
-			wantFiles: []string{"home"},
+			wantFiles: []string{"home", "usr"},
 		},
 		{
 			name:      "home directory",
@@ -350,11 +352,16 @@ func TestFS_walkDir(t *testing.T) {
 		".",
 		"home",
 		"home/guest",
+		"home/guest/.bashrc",
 		"home/guest/welcome.txt",
 		"home/zorcal",
 		"home/zorcal/.secret.txt",
 		"home/zorcal/projects",
 		"home/zorcal/projects/repo1.md",
+		"usr",
+		"usr/local",
+		"usr/local/bin",
+		"usr/local/bin/tour.sh",
 	}
 
 	if got, want := len(paths), len(wantPaths); got != want {
@@ -431,3 +438,37 @@ func TestFileInfo(t *testing.T) {
 		}
 	})
 }
+
+func TestFS_glob(t *testing.T) {
+	tfs := New(testRepos())
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"home/zorcal/projects/*.md", []string{"home/zorcal/projects/another-repo.md", "home/zorcal/projects/test-repo.md"}},
+		{"home/zorcal/projects/t?st-*", []string{"home/zorcal/projects/test-repo.md"}},
+		{"home/[gz]*", []string{"home/guest", "home/zorcal"}},
+		{"home/*/welcome.txt", []string{"home/guest/welcome.txt"}},
+		{"*", []string{"home", "usr"}},
+		{"home/guest", []string{"home/guest"}},
+		{"home/nothing*", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := fs.Glob(tfs, tt.pattern)
+			if err != nil {
+				t.Fatalf("Glob(%q) failed: %v", tt.pattern, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Glob(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("bad pattern", func(t *testing.T) {
+		if _, err := tfs.Glob("home/[z"); !errors.Is(err, path.ErrBadPattern) {
+			t.Errorf("Glob() error = %v, want %v", err, path.ErrBadPattern)
+		}
+	})
+}
@@ -0,0 +1,456 @@
+package termfs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Overlay errors.
+var (
+	ErrIsDir         = errors.New("is a directory")
+	ErrNotDir        = errors.New("not a directory")
+	ErrNotEmpty      = errors.New("directory not empty")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// Overlay is a copy-on-write filesystem layered on top of a base FS. Reads
+// fall through to the base filesystem for paths that have not been written
+// to the overlay, and writes are stored in the overlay only, so one base
+// filesystem can be shared by many overlays. The total size of the entries
+// stored in the overlay is limited by a quota.
+//
+// Entries of the base filesystem cannot be removed or renamed; attempting to
+// do so fails with fs.ErrPermission.
+type Overlay struct {
+	base  *FS
+	files map[string]*File
+	quota int64
+	used  int64
+	mu    sync.RWMutex
+}
+
+// NewOverlay creates an empty overlay on top of base that stores at most
+// quota bytes. Every entry is charged the length of its path plus the length
+// of its content.
+func NewOverlay(base *FS, quota int64) *Overlay {
+	return &Overlay{
+		base:  base,
+		files: make(map[string]*File),
+		quota: quota,
+	}
+}
+
+// Open implements fs.FS.
+func (o *Overlay) Open(name string) (fs.File, error) {
+	if name != "." && !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	name = cleanPath(name)
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	file, exists := o.lookup(name)
+	if !exists {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if file.isDir {
+		return &overlayDir{file: file, path: name, entries: o.readDir(name)}, nil
+	}
+
+	return &openFile{file: file, path: name}, nil
+}
+
+// Glob implements fs.GlobFS.
+func (o *Overlay) Glob(pattern string) ([]string, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return globFiles(pattern, o.base.files, o.files)
+}
+
+// WriteFile writes data to the named file, creating it if necessary and
+// replacing its previous content. Files of the base filesystem are copied
+// into the overlay rather than modified.
+func (o *Overlay) WriteFile(name string, data []byte) error {
+	return o.writeFile("write", name, data, false)
+}
+
+// AppendFile appends data to the named file, creating it if necessary. Files
+// of the base filesystem are copied into the overlay rather than modified.
+func (o *Overlay) AppendFile(name string, data []byte) error {
+	return o.writeFile("append", name, data, true)
+}
+
+// Mkdir creates a new directory. The parent directory must exist.
+func (o *Overlay) Mkdir(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, exists := o.lookup(name); exists {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+
+	if err := o.checkParent(name); err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+
+	if err := o.store(name, newDir(name)); err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+
+	return nil
+}
+
+// MkdirAll creates a directory along with any missing parents. It does
+// nothing if the directory already exists.
+func (o *Overlay) MkdirAll(name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	name = cleanPath(name)
+
+	var dir string
+	for elem := range strings.SplitSeq(name, "/") {
+		if elem == "" {
+			continue
+		}
+		dir = path.Join(dir, elem)
+
+		if file, exists := o.lookup(dir); exists {
+			if !file.isDir {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: ErrNotDir}
+			}
+			continue
+		}
+
+		if err := o.store(dir, newDir(dir)); err != nil {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Touch sets the modification time of the named file or directory to the
+// current time, creating an empty file if it does not exist.
+func (o *Overlay) Touch(name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "touch", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	name = cleanPath(name)
+
+	file := &File{name: path.Base(name)}
+	if existing, exists := o.lookup(name); exists {
+		*file = *existing
+	} else if err := o.checkParent(name); err != nil {
+		return &fs.PathError{Op: "touch", Path: name, Err: err}
+	}
+	file.modTime = time.Now()
+
+	if err := o.store(name, file); err != nil {
+		return &fs.PathError{Op: "touch", Path: name, Err: err}
+	}
+
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (o *Overlay) Remove(name string) error {
+	return o.remove("remove", name, false)
+}
+
+// RemoveAll removes the named file or directory along with everything it
+// contains.
+func (o *Overlay) RemoveAll(name string) error {
+	return o.remove("removeall", name, true)
+}
+
+func (o *Overlay) remove(op, name string, all bool) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	name = cleanPath(name)
+
+	if _, exists := o.base.files[name]; exists {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+
+	file, exists := o.files[name]
+	if !exists {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	descendants := o.descendants(name)
+	if file.isDir && len(descendants) > 0 && !all {
+		return &fs.PathError{Op: op, Path: name, Err: ErrNotEmpty}
+	}
+
+	for _, p := range append(descendants, name) {
+		o.used -= entrySize(p, o.files[p])
+		delete(o.files, p)
+	}
+
+	return nil
+}
+
+// Rename renames oldname to newname, moving everything a directory contains
+// along with it. If newname is an existing file it is replaced; an existing
+// directory is never replaced.
+func (o *Overlay) Rename(oldname, newname string) error {
+	if !fs.ValidPath(oldname) || !fs.ValidPath(newname) || oldname == "." || newname == "." {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	oldname, newname = cleanPath(oldname), cleanPath(newname)
+
+	renameErr := func(err error) error {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+
+	if _, exists := o.base.files[oldname]; exists {
+		return renameErr(fs.ErrPermission)
+	}
+
+	file, exists := o.files[oldname]
+	if !exists {
+		return renameErr(fs.ErrNotExist)
+	}
+
+	if oldname == newname {
+		return nil
+	}
+	if strings.HasPrefix(newname, oldname+"/") {
+		return renameErr(fs.ErrInvalid)
+	}
+
+	if err := o.checkParent(newname); err != nil {
+		return renameErr(err)
+	}
+
+	used := o.used
+	if existing, exists := o.lookup(newname); exists {
+		if existing.isDir || file.isDir {
+			return renameErr(fs.ErrExist)
+		}
+		if prev, exists := o.files[newname]; exists {
+			used -= entrySize(newname, prev)
+		}
+	}
+
+	renamed := make(map[string]*File)
+	for _, p := range append(o.descendants(oldname), oldname) {
+		newPath := newname + strings.TrimPrefix(p, oldname)
+		f := o.files[p]
+		if p == oldname {
+			moved := *f
+			moved.name = path.Base(newname)
+			f = &moved
+		}
+		renamed[newPath] = f
+		used += entrySize(newPath, f) - entrySize(p, o.files[p])
+	}
+
+	if used > o.quota {
+		return renameErr(ErrQuotaExceeded)
+	}
+
+	for _, p := range append(o.descendants(oldname), oldname) {
+		delete(o.files, p)
+	}
+	for p, f := range renamed {
+		o.files[p] = f
+	}
+	o.used = used
+
+	return nil
+}
+
+// Used returns the number of bytes of the quota in use.
+func (o *Overlay) Used() int64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.used
+}
+
+func (o *Overlay) writeFile(op, name string, data []byte, appendData bool) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.checkParent(name); err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	var content []byte
+	if existing, exists := o.lookup(name); exists {
+		if existing.isDir {
+			return &fs.PathError{Op: op, Path: name, Err: ErrIsDir}
+		}
+		if appendData {
+			content = append(content, existing.content...)
+		}
+	}
+	content = append(content, data...)
+
+	file := &File{
+		name:    path.Base(name),
+		content: content,
+		modTime: time.Now(),
+	}
+
+	if err := o.store(name, file); err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	return nil
+}
+
+// lookup returns the file at the cleaned path name, preferring the overlay
+// over the base filesystem. The caller must hold o.mu.
+func (o *Overlay) lookup(name string) (*File, bool) {
+	if file, exists := o.files[name]; exists {
+		return file, true
+	}
+	file, exists := o.base.files[name]
+	return file, exists
+}
+
+// checkParent verifies that the parent of name exists and is a directory.
+// The caller must hold o.mu.
+func (o *Overlay) checkParent(name string) error {
+	parent, exists := o.lookup(cleanPath(path.Dir(name)))
+	if !exists {
+		return fs.ErrNotExist
+	}
+	if !parent.isDir {
+		return ErrNotDir
+	}
+	return nil
+}
+
+// store puts file at name in the overlay, replacing any previous overlay
+// entry, if the quota allows it. The caller must hold o.mu.
+func (o *Overlay) store(name string, file *File) error {
+	used := o.used + entrySize(name, file)
+	if prev, exists := o.files[name]; exists {
+		used -= entrySize(name, prev)
+	}
+
+	if used > o.quota {
+		return ErrQuotaExceeded
+	}
+
+	o.files[name] = file
+	o.used = used
+
+	return nil
+}
+
+// readDir returns the entries of the directory at the cleaned path name,
+// with overlay entries shadowing base entries of the same name. The caller
+// must hold o.mu.
+func (o *Overlay) readDir(name string) []fs.DirEntry {
+	children := directChildren(o.base.files, name)
+	for childName, file := range directChildren(o.files, name) {
+		children[childName] = file
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, file := range children {
+		entries = append(entries, &dirEntry{file: file})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries
+}
+
+// descendants returns the paths of the overlay entries below the directory
+// at the cleaned path name. The caller must hold o.mu.
+func (o *Overlay) descendants(name string) []string {
+	var paths []string
+	for p := range o.files {
+		if name == "" || strings.HasPrefix(p, name+"/") {
+			if p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
+
+func newDir(name string) *File {
+	return &File{
+		name:     path.Base(name),
+		isDir:    true,
+		modTime:  time.Now(),
+		children: make(map[string]*File),
+	}
+}
+
+func entrySize(name string, file *File) int64 {
+	return int64(len(name) + len(file.content))
+}
+
+// overlayDir implements fs.File and fs.ReadDirFile for directories of an
+// Overlay. Its entries are read when the directory is opened.
+type overlayDir struct {
+	file    *File
+	path    string
+	entries []fs.DirEntry
+}
+
+// Stat implements fs.File.
+func (d *overlayDir) Stat() (fs.FileInfo, error) {
+	return &FileInfo{file: d.file}, nil
+}
+
+// Read implements fs.File.
+func (d *overlayDir) Read(b []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fs.ErrInvalid}
+}
+
+// Close implements fs.File.
+func (d *overlayDir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := d.entries
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries, nil
+}
@@ -0,0 +1,355 @@
+package termfs
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/github"
+)
+
+func TestOverlay_writeFile(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 1024)
+
+	if err := o.WriteFile("home/guest/notes.txt", []byte("hello\n")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	got, err := fs.ReadFile(o, "home/guest/notes.txt")
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if want := "hello\n"; string(got) != want {
+		t.Errorf("ReadFile() = %q, want %q", got, want)
+	}
+
+	if _, err := fs.Stat(base, "home/guest/notes.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(base) error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if err := o.WriteFile("home/guest/notes.txt", []byte("bye\n")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	got, err = fs.ReadFile(o, "home/guest/notes.txt")
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if want := "bye\n"; string(got) != want {
+		t.Errorf("ReadFile() after overwrite = %q, want %q", got, want)
+	}
+}
+
+func TestOverlay_appendFile(t *testing.T) {
+	base := New([]github.Repository{})
+	base.AddFile("home/guest/log.txt", []byte("one\n"))
+	o := NewOverlay(base, 1024)
+
+	if err := o.AppendFile("home/guest/log.txt", []byte("two\n")); err != nil {
+		t.Fatalf("AppendFile() failed: %v", err)
+	}
+
+	got, err := fs.ReadFile(o, "home/guest/log.txt")
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if want := "one\ntwo\n"; string(got) != want {
+		t.Errorf("ReadFile() = %q, want %q", got, want)
+	}
+
+	got, err = fs.ReadFile(base, "home/guest/log.txt")
+	if err != nil {
+		t.Fatalf("ReadFile(base) failed: %v", err)
+	}
+	if want := "one\n"; string(got) != want {
+		t.Errorf("ReadFile(base) = %q, want %q", got, want)
+	}
+}
+
+func TestOverlay_isolation(t *testing.T) {
+	base := New([]github.Repository{})
+	o1 := NewOverlay(base, 1024)
+	o2 := NewOverlay(base, 1024)
+
+	if err := o1.WriteFile("home/guest/mine.txt", []byte("x")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	if _, err := fs.Stat(o2, "home/guest/mine.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat() in other overlay error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestOverlay_writeErrors(t *testing.T) {
+	base := New([]github.Repository{})
+	base.AddFile("home/guest/file.txt", []byte("content"))
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{"missing parent", "home/nonexistent/file.txt", fs.ErrNotExist},
+		{"parent is file", "home/guest/file.txt/child", ErrNotDir},
+		{"target is directory", "home/guest", ErrIsDir},
+		{"root", ".", fs.ErrInvalid},
+		{"invalid path", "../outside", fs.ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOverlay(base, 1024)
+
+			if err := o.WriteFile(tt.path, []byte("data")); !errors.Is(err, tt.wantErr) {
+				t.Errorf("WriteFile(%q) error = %v, want %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOverlay_quota(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 32)
+
+	name := "home/guest/a.txt" // 16 bytes
+	if err := o.WriteFile(name, []byte("0123456789")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if got, want := o.Used(), int64(26); got != want {
+		t.Errorf("Used() = %d, want %d", got, want)
+	}
+
+	if err := o.AppendFile(name, []byte("0123456789")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("AppendFile() error = %v, want %v", err, ErrQuotaExceeded)
+	}
+
+	got, err := fs.ReadFile(o, name)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if want := "0123456789"; string(got) != want {
+		t.Errorf("ReadFile() after failed append = %q, want %q", got, want)
+	}
+
+	// Overwriting releases the space of the previous content.
+	if err := o.WriteFile(name, []byte("small")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if got, want := o.Used(), int64(21); got != want {
+		t.Errorf("Used() = %d, want %d", got, want)
+	}
+}
+
+func TestOverlay_readDir(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 1024)
+
+	if err := o.WriteFile("home/guest/notes.txt", []byte("notes")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := o.AppendFile("home/guest/welcome.txt", []byte("more")); err != nil {
+		t.Fatalf("AppendFile() failed: %v", err)
+	}
+
+	entries, err := fs.ReadDir(o, "home/guest")
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{".bashrc", "notes.txt", "welcome.txt"}; !slices.Equal(names, want) {
+		t.Errorf("ReadDir() names = %v, want %v", names, want)
+	}
+
+	baseEntries, err := fs.ReadDir(base, "home/guest")
+	if err != nil {
+		t.Fatalf("ReadDir(base) failed: %v", err)
+	}
+	if got, want := len(baseEntries), 2; got != want {
+		t.Errorf("len(ReadDir(base)) = %d, want %d", got, want)
+	}
+}
+
+func TestOverlay_mkdir(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 1024)
+
+	if err := o.Mkdir("home/guest/docs"); err != nil {
+		t.Fatalf("Mkdir() failed: %v", err)
+	}
+
+	info, err := fs.Stat(o, "home/guest/docs")
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if !info.IsDir() {
+		t.Error("IsDir() = false, want true")
+	}
+
+	if err := o.Mkdir("home/guest/docs"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Mkdir() of existing directory error = %v, want %v", err, fs.ErrExist)
+	}
+	if err := o.Mkdir("home/guest/a/b"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Mkdir() without parent error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if err := o.MkdirAll("home/guest/a/b/c"); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := o.MkdirAll("home/guest/a/b"); err != nil {
+		t.Errorf("MkdirAll() of existing directory failed: %v", err)
+	}
+	if err := o.WriteFile("home/guest/a/file.txt", nil); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := o.MkdirAll("home/guest/a/file.txt/d"); !errors.Is(err, ErrNotDir) {
+		t.Errorf("MkdirAll() through file error = %v, want %v", err, ErrNotDir)
+	}
+}
+
+func TestOverlay_touch(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 1024)
+
+	if err := o.Touch("home/guest/empty.txt"); err != nil {
+		t.Fatalf("Touch() failed: %v", err)
+	}
+
+	info, err := fs.Stat(o, "home/guest/empty.txt")
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if got := info.Size(); got != 0 {
+		t.Errorf("Size() = %d, want 0", got)
+	}
+
+	before, err := fs.Stat(base, "home/guest/welcome.txt")
+	if err != nil {
+		t.Fatalf("Stat(base) failed: %v", err)
+	}
+	if err := o.Touch("home/guest/welcome.txt"); err != nil {
+		t.Fatalf("Touch() failed: %v", err)
+	}
+	after, err := fs.Stat(o, "home/guest/welcome.txt")
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if got, want := after.Size(), before.Size(); got != want {
+		t.Errorf("Size() after touch = %d, want %d", got, want)
+	}
+	if after.ModTime().Before(before.ModTime()) {
+		t.Errorf("ModTime() after touch = %v, want not before %v", after.ModTime(), before.ModTime())
+	}
+}
+
+func TestOverlay_remove(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 1024)
+
+	if err := o.MkdirAll("home/guest/a/b"); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := o.WriteFile("home/guest/a/b/file.txt", []byte("data")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	if err := o.Remove("home/guest/a"); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("Remove() of non-empty directory error = %v, want %v", err, ErrNotEmpty)
+	}
+	if err := o.Remove("home/guest/welcome.txt"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Remove() of base file error = %v, want %v", err, fs.ErrPermission)
+	}
+	if err := o.RemoveAll("home/guest"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("RemoveAll() of base directory error = %v, want %v", err, fs.ErrPermission)
+	}
+	if err := o.Remove("home/guest/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove() of missing file error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if err := o.RemoveAll("home/guest/a"); err != nil {
+		t.Fatalf("RemoveAll() failed: %v", err)
+	}
+	if _, err := fs.Stat(o, "home/guest/a/b/file.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat() after RemoveAll() error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if got := o.Used(); got != 0 {
+		t.Errorf("Used() after RemoveAll() = %d, want 0", got)
+	}
+}
+
+func TestOverlay_rename(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 1024)
+
+	if err := o.MkdirAll("home/guest/src/sub"); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := o.WriteFile("home/guest/src/sub/file.txt", []byte("data")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	if err := o.Rename("home/guest/src", "home/guest/dst"); err != nil {
+		t.Fatalf("Rename() failed: %v", err)
+	}
+
+	got, err := fs.ReadFile(o, "home/guest/dst/sub/file.txt")
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if want := "data"; string(got) != want {
+		t.Errorf("ReadFile() = %q, want %q", got, want)
+	}
+	info, err := fs.Stat(o, "home/guest/dst")
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if got, want := info.Name(), "dst"; got != want {
+		t.Errorf("Name() = %q, want %q", got, want)
+	}
+	if _, err := fs.Stat(o, "home/guest/src"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat() of old name error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	tests := []struct {
+		name     string
+		old, new string
+		wantErr  error
+	}{
+		{"base file", "home/guest/welcome.txt", "home/guest/moved.txt", fs.ErrPermission},
+		{"missing file", "home/guest/missing", "home/guest/moved", fs.ErrNotExist},
+		{"into itself", "home/guest/dst", "home/guest/dst/sub/dst", fs.ErrInvalid},
+		{"onto directory", "home/guest/dst/sub/file.txt", "home/guest/dst", fs.ErrExist},
+		{"missing parent", "home/guest/dst", "home/missing/dst", fs.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := o.Rename(tt.old, tt.new); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Rename(%q, %q) error = %v, want %v", tt.old, tt.new, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOverlay_glob(t *testing.T) {
+	base := New([]github.Repository{})
+	o := NewOverlay(base, 1024)
+
+	if err := o.WriteFile("home/guest/notes.txt", []byte("notes")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := o.AppendFile("home/guest/welcome.txt", []byte("more")); err != nil {
+		t.Fatalf("AppendFile() failed: %v", err)
+	}
+
+	got, err := fs.Glob(o, "home/guest/*.txt")
+	if err != nil {
+		t.Fatalf("Glob() failed: %v", err)
+	}
+	if want := []string{"home/guest/notes.txt", "home/guest/welcome.txt"}; !slices.Equal(got, want) {
+		t.Errorf("Glob() = %q, want %q", got, want)
+	}
+}
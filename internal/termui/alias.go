@@ -0,0 +1,269 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// bashrcPath is the startup file of the shell, whose alias commands define
+// the aliases sessions start with.
+const bashrcPath = "home/guest/.bashrc"
+
+// AliasQuota is the number of bytes the aliases of a session may take up,
+// counted as in their name=value form.
+const AliasQuota = 32 << 10
+
+const (
+	// maxAliasDepth is the number of aliases that may be expanded within
+	// each other.
+	maxAliasDepth = 16
+	// maxAliasExpansion is the number of bytes of alias values a command
+	// line may expand to. Aliases expanding to several others grow
+	// exponentially with their depth, and are expanded before the line is
+	// run, out of reach of the step limit.
+	maxAliasExpansion = 64 << 10
+)
+
+// DefaultAliases returns the aliases of a new session, defined by the alias
+// commands in ~/.bashrc in fsys. Other commands are ignored, and a missing
+// file defines no aliases.
+func DefaultAliases(fsys fs.FS) map[string]string {
+	aliases := make(map[string]string)
+
+	content, err := fs.ReadFile(fsys, bashrcPath)
+	if err != nil {
+		return aliases
+	}
+
+	for _, line := range splitLines(string(content)) {
+		list, err := Parse(line)
+		if err != nil {
+			continue
+		}
+		for _, item := range list {
+			for _, cmd := range item.Pipeline {
+				if len(cmd.Args) == 0 || cmd.Args[0].String() != "alias" {
+					continue
+				}
+				for _, arg := range cmd.Args[1:] {
+					name, value, ok := strings.Cut(arg.String(), "=")
+					if ok && isValidAliasName(name) {
+						aliases[name] = value
+					}
+				}
+			}
+		}
+	}
+
+	return aliases
+}
+
+// expandAliases replaces the first word of each simple command of line with
+// its value if it is the name of an alias, like bash does before parsing a
+// command line. Commands follow |, ;, &&, ||, newlines and the reserved words
+// such as then and do that start the body of a compound command. The value
+// is expanded in turn, except for the aliases being expanded already, and if
+// it ends with a blank, the word that follows is also checked for an alias.
+// Quoting any part of a word prevents its expansion. A line that cannot be
+// lexed is returned as it is, for Parse to report the error.
+// Possible errors: ErrAliasTooDeep, ErrAliasTooLong.
+func expandAliases(line string, aliases map[string]string) (string, error) {
+	var size int
+	return expandAliasesExcept(line, aliases, nil, &size)
+}
+
+// expandAliasesExcept expands the aliases of line like expandAliases, except
+// for the aliases in expanding. size is the number of bytes of alias values
+// expanded so far, and is updated with those of line.
+func expandAliasesExcept(line string, aliases map[string]string, expanding map[string]bool, size *int) (string, error) {
+	if len(aliases) == 0 {
+		return line, nil
+	}
+
+	tokens, err := lex(line)
+	if err != nil {
+		return line, nil
+	}
+
+	var (
+		b     strings.Builder
+		runes = []rune(line)
+		last  int
+		// command reports whether the next word is in command position.
+		command = true
+	)
+	for i, tok := range tokens {
+		switch {
+		case tok.kind == tokenPipe || tok.kind == tokenControl || tok.kind == tokenNewline:
+			command = true
+			continue
+		case tok.kind == tokenRedirect:
+			continue
+		case i > 0 && tokens[i-1].kind == tokenRedirect:
+			// The target of a redirection, which may precede the command.
+			continue
+		case !command:
+			continue
+		}
+		name, ok := unquotedWord(tok.word)
+		// The reserved words starting a compound command or a list in it are
+		// followed by a command.
+		command = ok && slices.Contains([]string{"if", "then", "elif", "else", "while", "until", "do", "{"}, name)
+
+		value, isAlias := aliases[name]
+		if !ok || !isAlias || expanding[name] {
+			continue
+		}
+
+		if len(expanding) >= maxAliasDepth {
+			return "", ErrAliasTooDeep
+		}
+		if *size += len(value); *size > maxAliasExpansion {
+			return "", ErrAliasTooLong
+		}
+
+		inner := map[string]bool{name: true}
+		maps.Copy(inner, expanding)
+
+		expanded, err := expandAliasesExcept(value, aliases, inner, size)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(string(runes[last:tok.start]))
+		b.WriteString(expanded)
+		last = tok.end
+
+		command = strings.HasSuffix(value, " ") || strings.HasSuffix(value, "\t")
+	}
+	b.WriteString(string(runes[last:]))
+
+	return b.String(), nil
+}
+
+// unquotedWord returns the text of w and reports whether no part of it is
+// quoted.
+func unquotedWord(w Word) (string, bool) {
+	for _, part := range w {
+		if part.Quote != 0 {
+			return "", false
+		}
+	}
+	return w.String(), true
+}
+
+// isValidAliasName reports whether name can be defined as an alias: a
+// non-empty word without quotes, expansions, operators or slashes.
+func isValidAliasName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t\n'\"\\$`|&;<>()=/")
+}
+
+type aliasOptions struct {
+	print bool
+}
+
+func newAliasFlagSet(opts *aliasOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.print, "print", 'p', false, "print every alias")
+	return flagSet
+}
+
+// Alias defines an alias of the session for each argument of the form
+// name=value, and writes the definition of each argument without a value.
+// Without arguments, or with -p, every alias is written, sorted by name, in
+// a form that can be reused as input. Aliases that do not fit in the
+// AliasQuota of the session are not defined. Errors are returned joined,
+// each as *ArgError carrying the argument that caused it.
+// Possible errors: ErrAliasNotFound, ErrInvalidAliasName, ErrQuotaExceeded,
+// ErrInvalidFlag.
+func Alias(env *Env, args []string) error {
+	var opts aliasOptions
+	flagSet := newAliasFlagSet(&opts)
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	args = flagSet.Args()
+	aliases := env.Sessions.GetAliases(env.SessionID)
+	if len(args) == 0 || opts.print {
+		for _, name := range slices.Sorted(maps.Keys(aliases)) {
+			fmt.Fprintf(env.Stdout, "alias %s=%s\n", name, quoteValue(aliases[name]))
+		}
+	}
+
+	var errs []error
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue {
+			value, exists := aliases[name]
+			if !exists {
+				errs = append(errs, &ArgError{Arg: name, Err: ErrAliasNotFound})
+				continue
+			}
+			fmt.Fprintf(env.Stdout, "alias %s=%s\n", name, quoteValue(value))
+			continue
+		}
+
+		if !isValidAliasName(name) {
+			errs = append(errs, &ArgError{Arg: name, Err: ErrInvalidAliasName})
+			continue
+		}
+		if err := env.Sessions.SetAlias(env.SessionID, name, value); err != nil {
+			errs = append(errs, &ArgError{Arg: name, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type unaliasOptions struct {
+	all bool
+}
+
+func newUnaliasFlagSet(opts *unaliasOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.all, "all", 'a', false, "remove every alias")
+	return flagSet
+}
+
+// Unalias removes the aliases of the session given as arguments, or every
+// alias with -a. Errors are returned joined, each as *ArgError carrying the
+// name that is not an alias.
+// Possible errors: ErrMissingArgument, ErrAliasNotFound, ErrInvalidFlag.
+func Unalias(env *Env, args []string) error {
+	var opts unaliasOptions
+	flagSet := newUnaliasFlagSet(&opts)
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	aliases := env.Sessions.GetAliases(env.SessionID)
+	if opts.all {
+		for name := range aliases {
+			env.Sessions.UnsetAlias(env.SessionID, name)
+		}
+		return nil
+	}
+
+	args = flagSet.Args()
+	if len(args) == 0 {
+		return ErrMissingArgument
+	}
+
+	var errs []error
+	for _, name := range args {
+		if _, exists := aliases[name]; !exists {
+			errs = append(errs, &ArgError{Arg: name, Err: ErrAliasNotFound})
+			continue
+		}
+		env.Sessions.UnsetAlias(env.SessionID, name)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,196 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestExpandAliases(t *testing.T) {
+	aliases := map[string]string{
+		"ll":   "ls -l",
+		"ls":   "ls -F",
+		"sudo": "sudo ",
+		"up":   "cd ..; ls",
+		"a":    "b",
+		"b":    "a x",
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "no aliases", line: "cat notes.txt", want: "cat notes.txt"},
+		{name: "first word", line: "ll projects", want: "ls -F -l projects"},
+		{name: "not first word", line: "echo ll", want: "echo ll"},
+		{name: "each command", line: "ll | ll;ll && ll", want: "ls -F -l | ls -F -l;ls -F -l && ls -F -l"},
+		{name: "after redirection", line: "> out.txt ll", want: "> out.txt ls -F -l"},
+		{name: "list value", line: "up && pwd", want: "cd ..; ls -F && pwd"},
+		{name: "quoted", line: `'ll' \ll l"l"`, want: `'ll' \ll l"l"`},
+		{name: "trailing blank", line: "sudo ll", want: "sudo  ls -F -l"},
+		{name: "recursive", line: "a", want: "a x"},
+		{name: "unterminated quote", line: "ll 'a", want: "ll 'a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandAliases(tt.line, aliases)
+			if err != nil {
+				t.Fatalf("expandAliases(%q) error = %v", tt.line, err)
+			}
+			if got != tt.want {
+				t.Errorf("expandAliases(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandAliases_limits(t *testing.T) {
+	// Each alias expands to 10 copies of the one before it.
+	nested := map[string]string{"a0": "echo"}
+	for i := 1; i <= 8; i++ {
+		prev := fmt.Sprintf("a%d", i-1)
+		nested[fmt.Sprintf("a%d", i)] = strings.Repeat(prev+";", 9) + prev
+	}
+
+	// Each alias expands to the one after it.
+	chain := map[string]string{}
+	for i := range maxAliasDepth + 1 {
+		chain[fmt.Sprintf("c%d", i)] = fmt.Sprintf("c%d", i+1)
+	}
+
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		line    string
+		wantErr error
+	}{
+		{name: "nested", aliases: nested, line: "a8", wantErr: ErrAliasTooLong},
+		{name: "nested within limit", aliases: nested, line: "a2"},
+		{name: "too deep", aliases: chain, line: "c0", wantErr: ErrAliasTooDeep},
+		{name: "deep within limit", aliases: chain, line: "c1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := expandAliases(tt.line, tt.aliases); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expandAliases(%q) error = %v, want %v", tt.line, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultAliases(t *testing.T) {
+	tfs, _ := setupTest()
+	if err := tfs.WriteFile(bashrcPath, []byte("# aliases\nalias ll='ls -l' la=\"ls -a\"\nexport X=1\nalias bad/name=x\nalias 'oops\n")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	want := map[string]string{"ll": "ls -l", "la": "ls -a"}
+	if got := DefaultAliases(tfs); !maps.Equal(got, want) {
+		t.Errorf("DefaultAliases() = %v, want %v", got, want)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "run",
+			line:       "cd /home/zorcal; ll",
+			wantOutput: ".:\nprojects/\n\n./projects:\napp.js  test-repo.md\n",
+		},
+		{
+			name:       "list",
+			line:       "alias ls='ls -a'; alias",
+			wantOutput: "alias ll='ls -R'\nalias ls='ls -a'\n",
+		},
+		{
+			name:       "print",
+			line:       "alias ll nope",
+			wantOutput: "alias ll='ls -R'\nalias: nope: not found\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "invalid name",
+			line:       "alias a/b=ls",
+			wantOutput: "alias: a/b: invalid alias name\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "defined for later lines",
+			line:       "alias x=pwd; x",
+			wantOutput: "shell: x: command not found...\n",
+			wantStatus: 127,
+		},
+		{
+			name:       "unalias",
+			line:       "unalias ll; alias",
+			wantOutput: "",
+		},
+		{
+			name:       "unalias all",
+			line:       "alias a=b; unalias -a; alias",
+			wantOutput: "",
+		},
+		{
+			name:       "unalias missing",
+			line:       "unalias nope",
+			wantOutput: "unalias: nope: not found\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "unalias without names",
+			line:       "unalias",
+			wantOutput: "unalias: missing file argument\n",
+			wantStatus: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetAlias(sessionID, "ll", "ls -R")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestAlias_quota(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+
+	// x holds 10KiB, so that only a few aliases of it fit in the quota.
+	line := "x=0123456789; for i in 1 2 3 4 5 6 7 8 9 10; do x=$x$x; done; " +
+		"for a in 0 1 2 3 4 5 6 7 8 9; do for b in 0 1 2 3 4 5 6 7 8 9; do alias w$a$b=$x; done; done"
+
+	out, status := NewRegistry().Exec(env, line)
+	if status != 1 {
+		t.Errorf("Exec(env, %q) status = %d, want 1", line, status)
+	}
+	if got, want := out.String(), "alias: w03: Disk quota exceeded\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("Exec(env, %q) output = %.200q, want it to start with %q", line, got, want)
+	}
+
+	var size int
+	for name, value := range sessMgr.GetAliases(sessionID) {
+		size += len(name) + 1 + len(value)
+	}
+	if size > AliasQuota {
+		t.Errorf("after Exec(env, %q) the aliases take up %d bytes, want at most %d", line, size, AliasQuota)
+	}
+}
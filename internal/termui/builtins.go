@@ -0,0 +1,483 @@
+package termui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// registerBuiltins registers the built-in terminal commands in r.
+func registerBuiltins(r *Registry) {
+	r.Register(&Command{
+		Name:    "ls",
+		Usage:   "ls [options] [path...]",
+		Summary: "List directory contents",
+		Description: "List the contents of each directory given, or of the current directory if none is given. Files given as arguments are listed first, followed by each directory under a header naming it if there is more than one.\n\n" +
+			"Directories are marked with a trailing /. Entries are sorted by name unless -t or -S sorts them by time or size. Hidden files, whose names start with a dot, are only listed with -a.",
+		Flags: func() *posixflag.FlagSet {
+			return newLsFlagSet(new(lsOptions))
+		},
+		Class: "file-list",
+		Run:   ListDirectoryContents,
+	})
+
+	r.Register(&Command{
+		Name:    "cd",
+		Usage:   "cd [path | -]",
+		Summary: "Change directory",
+		Description: "Change the current directory to path, or to $HOME if no path is given. The argument - changes to the previous directory, $OLDPWD, and prints it.\n\n" +
+			"PWD and OLDPWD are updated when the directory changes.",
+		Run: ChangeDirectory,
+	})
+
+	r.Register(&Command{
+		Name:        "tree",
+		Usage:       "tree [options] [path]",
+		Summary:     "Show the directory hierarchy",
+		Description: "Show the hierarchy of files under path, or under the current directory if no path is given, drawn with box-drawing characters and followed by a count of the directories and files shown.",
+		Flags: func() *posixflag.FlagSet {
+			return newTreeFlagSet(new(treeOptions))
+		},
+		Run: Tree,
+	})
+
+	r.Register(&Command{
+		Name:        "pwd",
+		Usage:       "pwd",
+		Summary:     "Print working directory",
+		Description: "Print the absolute path of the current directory.",
+		Run:         PrintWorkingDirectory,
+	})
+
+	r.Register(&Command{
+		Name:    "cat",
+		Usage:   "cat [-rn] [file...]",
+		Summary: "Display file contents, or standard input",
+		Description: "Print each file given, in order, or standard input if no file is given or a file is -.\n\n" +
+			"In the terminal, markdown files are rendered, with bold headings, bullets and links that open in a new tab, and Go, JavaScript, JSON, YAML and shell sources are highlighted. Output that is piped or redirected is always printed as it is.",
+		Flags: func() *posixflag.FlagSet {
+			return newCatFlagSet(new(catOptions))
+		},
+		Class: "file-content",
+		Run:   CatFile,
+	})
+
+	r.Register(&Command{
+		Name:    "less",
+		Usage:   "less [file]",
+		Summary: "View a file or standard input one screen at a time",
+		Description: "Show a file, or standard input if no file is given, one screen at a time. Press space or b to move a screen forward or back, j or k to move a line, g or G to go to the start or end, / to search for a regular expression, n or N to repeat the search forwards or backwards, and q to quit.\n\n" +
+			"Output that is piped or redirected is printed as it is. more does the same.",
+		Aliases: []string{"more"},
+		Class:   "file-content",
+		Run:     Less,
+	})
+
+	r.Register(&Command{
+		Name:        "mkdir",
+		Usage:       "mkdir [-p] directory...",
+		Summary:     "Create directories",
+		Description: "Create each directory given. It is an error if a directory exists or its parent does not, unless -p is given.",
+		Flags: func() *posixflag.FlagSet {
+			return newMkdirFlagSet(new(mkdirOptions))
+		},
+		Run: MakeDirectory,
+	})
+
+	r.Register(&Command{
+		Name:        "touch",
+		Usage:       "touch file...",
+		Summary:     "Create empty files or update modification times",
+		Description: "Update the modification time of each file given, creating empty files for those that do not exist.",
+		Run:         TouchFile,
+	})
+
+	r.Register(&Command{
+		Name:    "rm",
+		Usage:   "rm [-rf] file...",
+		Summary: "Remove files or directories",
+		Description: "Remove each file given. Directories are only removed with -r, along with everything they contain.\n\n" +
+			"Only files you created can be removed; the files everyone shares are read-only.",
+		Flags: func() *posixflag.FlagSet {
+			return newRmFlagSet(new(rmOptions))
+		},
+		Run: RemoveFile,
+	})
+
+	r.Register(&Command{
+		Name:    "mv",
+		Usage:   "mv [-f] source... destination",
+		Summary: "Move or rename files",
+		Description: "Rename source to destination, or move each source into the destination directory. Existing files are replaced without asking, with or without -f.\n\n" +
+			"Only files you created can be moved; the files everyone shares are read-only.",
+		Flags: func() *posixflag.FlagSet {
+			return newMvFlagSet(new(mvOptions))
+		},
+		Run: MoveFile,
+	})
+
+	r.Register(&Command{
+		Name:        "cp",
+		Usage:       "cp [-r] source... destination",
+		Summary:     "Copy files or directories",
+		Description: "Copy source to destination, or copy each source into the destination directory. Directories are only copied with -r, along with everything they contain.",
+		Flags: func() *posixflag.FlagSet {
+			return newCpFlagSet(new(cpOptions))
+		},
+		Run: CopyFile,
+	})
+
+	r.Register(&Command{
+		Name:    "grep",
+		Usage:   "grep [options] pattern [file...]",
+		Summary: "Search files for lines matching a regular expression",
+		Description: "Print the lines of each file given, or of standard input if no file is given, that match the regular expression pattern. Matches are highlighted in the terminal.\n\n" +
+			"With -r, directories are searched recursively, the current directory if no file is given. Lines are prefixed with the name of their file when more than one file is searched. The exit status is 1 if no line is selected.",
+		Flags: func() *posixflag.FlagSet {
+			return newGrepFlagSet(new(grepOptions))
+		},
+		Run: Grep,
+	})
+
+	r.Register(&Command{
+		Name:    "find",
+		Usage:   "find [path...] [expression]",
+		Summary: "Search for files by name, type, size and modification time",
+		Description: "Print the path of every file under each path given, or under the current directory if none is given, that matches the expression. Directories are searched recursively and the paths given are included.\n\n" +
+			"The expression combines the tests -name pattern, -type f or -type d, -size [+-]N[ckMG], -mtime [+-]N and -newer file with -not or !, -and or -a, -or or -o, and parentheses. Tests next to each other must both match. An empty expression matches every file.",
+		Run: Find,
+	})
+
+	r.Register(&Command{
+		Name:        "head",
+		Usage:       "head [-n lines] [file...]",
+		Summary:     "Print the first lines of files",
+		Description: "Print the first lines of each file given, or of standard input if no file is given. Each file is preceded by a header naming it when more than one file is given.",
+		Flags: func() *posixflag.FlagSet {
+			return newHeadTailFlagSet(new(headTailOptions))
+		},
+		Run: Head,
+	})
+
+	r.Register(&Command{
+		Name:        "tail",
+		Usage:       "tail [-n [+]lines] [file...]",
+		Summary:     "Print the last lines of files",
+		Description: "Print the last lines of each file given, or of standard input if no file is given. With -n +lines, print every line from that line on instead, e.g. -n +2 to skip a header. Each file is preceded by a header naming it when more than one file is given.",
+		Flags: func() *posixflag.FlagSet {
+			return newHeadTailFlagSet(new(headTailOptions))
+		},
+		Run: Tail,
+	})
+
+	r.Register(&Command{
+		Name:        "wc",
+		Usage:       "wc [-lwc] [file...]",
+		Summary:     "Count lines, words and bytes",
+		Description: "Print the number of lines, words and bytes of each file given, or of standard input if no file is given, followed by a total when more than one file is given. With -l, -w or -c only the chosen counts are printed.",
+		Flags: func() *posixflag.FlagSet {
+			return newWcFlagSet(new(wcOptions))
+		},
+		Run: WordCount,
+	})
+
+	r.Register(&Command{
+		Name:        "sort",
+		Usage:       "sort [-rnu] [file...]",
+		Summary:     "Sort lines",
+		Description: "Print the lines of the files given, or of standard input if no file is given, sorted.",
+		Flags: func() *posixflag.FlagSet {
+			return newSortFlagSet(new(sortOptions))
+		},
+		Run: Sort,
+	})
+
+	r.Register(&Command{
+		Name:        "uniq",
+		Usage:       "uniq [-c] [file]",
+		Summary:     "Collapse adjacent repeated lines",
+		Description: "Print the lines of a file, or of standard input if no file is given, with adjacent repeated lines collapsed into one. Sort the lines first to collapse all repeated lines.",
+		Flags: func() *posixflag.FlagSet {
+			return newUniqFlagSet(new(uniqOptions))
+		},
+		Run: Uniq,
+	})
+
+	r.Register(&Command{
+		Name:        "cut",
+		Usage:       "cut -f list [-d delim] [file...]",
+		Summary:     "Print selected fields of lines",
+		Description: "Print the fields of each line of the files given, or of standard input if no file is given, selected by -f. Fields are separated by tabs, or by the character given with -d. Lines without a delimiter are printed as they are.",
+		Flags: func() *posixflag.FlagSet {
+			return newCutFlagSet(new(cutOptions))
+		},
+		Run: Cut,
+	})
+
+	r.Register(&Command{
+		Name:        "open",
+		Usage:       "open [file]",
+		Summary:     "Open files containing URLs in browser",
+		Description: "Open the URL of a file in a new browser tab. The URL is the one the file mentions, e.g. the repository of a project in /home/zorcal/projects.",
+		Run:         OpenFile,
+	})
+
+	r.Register(&Command{
+		Name:        "clear",
+		Usage:       "clear",
+		Summary:     "Clear the terminal screen (or use Ctrl+L)",
+		Description: "Clear the terminal screen. Ctrl+L does the same. The command history is kept; history -c clears it.",
+		Run: func(env *Env, args []string) error {
+			env.Output.clearScreen()
+			return nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:        "echo",
+		Usage:       "echo [-n] [string...]",
+		Summary:     "Display a line of text",
+		Description: "Print the strings given, separated by spaces and followed by a newline. A leading -n suppresses the newline.",
+		Flags: func() *posixflag.FlagSet {
+			flagSet := posixflag.NewFlagSet()
+			flagSet.BoolVar(new(bool), "no-newline", 'n', false, "do not output the trailing newline")
+			return flagSet
+		},
+		Run: Echo,
+	})
+
+	r.Register(&Command{
+		Name:    "export",
+		Usage:   "export [name=value...]",
+		Summary: "Set environment variables, or list them",
+		Description: "Set each environment variable given as name=value. A name without a value leaves the variable unchanged. Without arguments, print every variable in a form that can be run again.\n\n" +
+			"Variables are expanded in command lines with $NAME or ${NAME}. The variables of a session may take up 32 KiB in all.",
+		Run: Export,
+	})
+
+	r.Register(&Command{
+		Name:        "unset",
+		Usage:       "unset [name...]",
+		Summary:     "Remove environment variables",
+		Description: "Remove each environment variable given.",
+		Run:         Unset,
+	})
+
+	r.Register(&Command{
+		Name:    "alias",
+		Usage:   "alias [-p] [name[=value]...]",
+		Summary: "Define aliases, or list them",
+		Description: "Define an alias for each name=value given: a command line starting with name runs value instead, followed by the rest of the line. A name without a value prints its alias. Without arguments, or with -p, print every alias in a form that can be run again.\n\n" +
+			"Sessions start with the aliases defined in ~/.bashrc, e.g. ll for ls -l. Quote the command name, e.g. \\ll or 'll', to run it without its alias. The aliases of a session may take up 32 KiB in all.",
+		Flags: func() *posixflag.FlagSet {
+			return newAliasFlagSet(new(aliasOptions))
+		},
+		Run: Alias,
+	})
+
+	r.Register(&Command{
+		Name:        "unalias",
+		Usage:       "unalias [-a] name...",
+		Summary:     "Remove aliases",
+		Description: "Remove the alias of each name given, or every alias with -a.",
+		Flags: func() *posixflag.FlagSet {
+			return newUnaliasFlagSet(new(unaliasOptions))
+		},
+		Run: Unalias,
+	})
+
+	r.Register(&Command{
+		Name:        "env",
+		Usage:       "env",
+		Summary:     "Print environment variables",
+		Description: "Print the environment variables, one name=value per line, sorted by name.",
+		Run:         PrintEnv,
+	})
+
+	r.Register(&Command{
+		Name:        "true",
+		Usage:       "true",
+		Summary:     "Do nothing, successfully",
+		Description: "Exit with status 0.",
+		Aliases:     []string{":"},
+		Run: func(env *Env, args []string) error {
+			return nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:        "false",
+		Usage:       "false",
+		Summary:     "Do nothing, unsuccessfully",
+		Description: "Exit with status 1.",
+		Run: func(env *Env, args []string) error {
+			return ExitStatus(1)
+		},
+	})
+
+	r.Register(&Command{
+		Name:    "test",
+		Usage:   "test expression",
+		Summary: "Check files and compare values",
+		Description: "Exit with status 0 if expression is true, or 1 if it is false. -n s and -z s check whether the string s is empty, -e, -f, -d and -s check whether a file exists, is a regular file, is a directory or is not empty, = and != compare strings, and -eq, -ne, -lt, -le, -gt and -ge compare integers. ! negates an expression, and a lone string is true if it is not empty.\n\n" +
+			"test is mostly used as the condition of if and while, in its [ expression ] form, e.g. if [ -d projects ]; then cd projects; fi.",
+		Run: Test,
+	})
+
+	r.Register(&Command{
+		Name:        "[",
+		Usage:       "[ expression ]",
+		Summary:     "Check files and compare values, like test",
+		Description: "Evaluate expression like test. The last argument must be ].",
+		Run:         Bracket,
+	})
+
+	r.Register(&Command{
+		Name:    "sh",
+		Usage:   "sh [file [argument...]]",
+		Summary: "Run a shell script",
+		Description: "Run the commands in file, or read from standard input if no file is given, with the arguments as the positional parameters $1, $2 and so on. A script whose file is in $PATH can also be run by name, and any other as ./file. The variables a script sets and the directory it changes to do not outlast it; run it with source to keep them.\n\n" +
+			"Besides command lines, scripts may use if ...; then ...; elif ...; then ...; else ...; fi, for name in word...; do ...; done, while ...; do ...; done, until ...; do ...; done and functions, defined as name() { ...; } and called like commands for the rest of the command line. Words starting with # start comments, name=value sets a variable, and $# and $@ expand to the number and list of positional parameters. Conditions are commands, usually test.\n\n" +
+			"To keep a script from running forever, a command line stops with an error after 10000 commands and loop iterations. Try the guided tour, tour.sh.",
+		Run: Sh,
+	})
+
+	r.Register(&Command{
+		Name:        "source",
+		Usage:       "source file [argument...]",
+		Summary:     "Run a shell script in the current shell",
+		Description: "Run the commands in file like sh, but in the current shell: the variables and aliases the script defines, and the directory it changes to, stay in effect after it. The functions it defines can be called for the rest of the command line, e.g. source lib.sh; greet, but not on later ones. . does the same.",
+		Aliases:     []string{"."},
+		Run:         Source,
+	})
+
+	r.Register(&Command{
+		Name:        "return",
+		Usage:       "return [status]",
+		Summary:     "Return from a function or a sourced script",
+		Description: "Leave the function or script running, with the exit status given, or else that of the last command.",
+		Run:         Return,
+	})
+
+	r.Register(&Command{
+		Name:        "exit",
+		Usage:       "exit [status]",
+		Summary:     "Exit a script",
+		Description: "Leave the script running with the exit status given, or else that of the last command. At the prompt, exit stops the rest of the command line.",
+		Run:         Exit,
+	})
+
+	r.Register(&Command{
+		Name:        "shift",
+		Usage:       "shift [n]",
+		Summary:     "Shift the positional parameters",
+		Description: "Remove the first n positional parameters, or the first one, so that $1 is the one after them. The parameters are left unchanged, with status 1, if there are fewer than n.",
+		Run:         Shift,
+	})
+
+	r.Register(&Command{
+		Name:        "break",
+		Usage:       "break",
+		Summary:     "Leave a loop",
+		Description: "Leave the innermost for, while or until loop running.",
+		Run:         Break,
+	})
+
+	r.Register(&Command{
+		Name:        "continue",
+		Usage:       "continue",
+		Summary:     "Go on with the next iteration of a loop",
+		Description: "Skip the rest of the body of the innermost for, while or until loop running, and go on with its next iteration.",
+		Run:         Continue,
+	})
+
+	r.Register(&Command{
+		Name:    "history",
+		Usage:   "history [-c] [N]",
+		Summary: "List the commands run, or clear them",
+		Description: "List the command lines run in this session, numbered from the oldest, or only the last N if N is given. With -c, the history is cleared instead; clearing the screen keeps it.\n\n" +
+			"Command lines may refer to earlier ones: !! is replaced by the previous command line, !n by command line n, !-n by the command line n lines back and !prefix by the last command line starting with prefix. A line ^old^new runs the previous command line with old replaced by new. The expanded line is printed before it runs.\n\n" +
+			"Press Ctrl+R to search the history as you type. Press Ctrl+R again for the next match, Enter to run it, Escape to edit it and Ctrl+G to cancel.",
+		Flags: func() *posixflag.FlagSet {
+			return newHistoryFlagSet(new(historyOptions))
+		},
+		Run: History,
+	})
+
+	r.Register(&Command{
+		Name:        "help",
+		Usage:       "help",
+		Summary:     "Show this help message",
+		Description: "Show a summary of the available commands and their options, followed by tips on using the terminal. Read the manual page of a command with man for the details.",
+		Class:       "help",
+		Run: func(env *Env, args []string) error {
+			return writeStyled(env.Stdout, helpText(r))
+		},
+	})
+
+	r.Register(&Command{
+		Name:    "man",
+		Usage:   "man [-k] command...",
+		Summary: "Show the manual page of a command",
+		Description: "Show the manual page of each command given. The pages are stored under /usr/share/man/man1.\n\n" +
+			"With -k, the arguments are keywords instead, and man lists the name and summary of every page whose text contains any of them, ignoring case.",
+		Flags: func() *posixflag.FlagSet {
+			return newManFlagSet(new(manOptions))
+		},
+		Class: "help",
+		Run:   Man,
+	})
+}
+
+// helpText renders the help message from the commands registered in r,
+// with the command usages in bold.
+func helpText(r *Registry) *styledText {
+	var width int
+	for _, cmd := range r.Commands() {
+		width = max(width, len(cmd.Usage))
+	}
+
+	b := new(styledText)
+	b.WriteString("Available commands:\n\n")
+	for _, cmd := range r.Commands() {
+		padding := strings.Repeat(" ", width-len(cmd.Usage))
+		b.WriteString("  ")
+		b.Element("strong", "", cmd.Usage)
+		fmt.Fprintf(b, "%s - %s\n", padding, cmd.Summary)
+
+		if cmd.Flags == nil {
+			continue
+		}
+
+		indent := strings.Repeat(" ", width+5)
+		cmd.Flags().VisitAll(func(f *posixflag.Flag) {
+			if f.Short != 0 {
+				fmt.Fprintf(b, "%s-%c, --%s: %s\n", indent, f.Short, f.Name, f.Usage)
+			} else {
+				fmt.Fprintf(b, "%s--%s: %s\n", indent, f.Name, f.Usage)
+			}
+		})
+	}
+	b.WriteString("\nNotes:\n")
+	b.WriteString("  • Use Ctrl+L to clear the terminal\n")
+	b.WriteString("  • Run earlier commands again with !!, !n and !prefix, e.g. !ls; list them with history\n")
+	b.WriteString("  • Press Ctrl+R to search the commands you ran; press it again for older matches\n")
+	b.WriteString("  • Press Tab to complete commands, flags and paths\n")
+	b.WriteString("  • Connect commands with | to pipe output, e.g. ls | cat\n")
+	b.WriteString("  • Chain commands with ;, && and ||, e.g. cd projects && ls\n")
+	b.WriteString("  • Write output to a file with > or append with >>, e.g. ls > files.txt\n")
+	b.WriteString("  • Files you write are only visible to you\n")
+	b.WriteString("  • Run shell scripts with sh or ./script, e.g. sh script.sh; take the guided tour with tour.sh\n")
+	b.WriteString("  • Use if, for and while at the prompt, e.g. for f in *.md; do echo $f; done\n")
+	b.WriteString("  • Define shortcuts with alias, e.g. alias ll='ls -l'; list them with alias\n")
+	b.WriteString("  • $? holds the exit status of the last command\n")
+	b.WriteString("  • Use $NAME or ${NAME} to expand environment variables, e.g. echo $HOME\n")
+	b.WriteString("  • Match file names with *, ? and [...], e.g. cat projects/*.md\n")
+	b.WriteString("  • Search the projects with grep, e.g. grep -ri golang /home/zorcal/projects\n")
+	b.WriteString("  • Find files with find, e.g. find / -name '*.md' -not -name '.*'\n")
+	b.WriteString("  • Page through long files with less, e.g. ls -lR / | less; press q to quit\n")
+	b.WriteString("  • Read the manual page of a command with man, e.g. man ls, or search them with man -k\n")
+
+	return b
+}
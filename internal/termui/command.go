@@ -0,0 +1,197 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// ErrCommandNotFound is returned when running a command that is not registered.
+var ErrCommandNotFound = errors.New("command not found")
+
+// ExitStatus is returned by commands that exit with a non-zero status
+// without reporting an error message, e.g. false.
+type ExitStatus int
+
+func (s ExitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// Command describes a terminal command.
+type Command struct {
+	// Name is the name the command is invoked by.
+	Name string
+	// Aliases are alternative names the command can be invoked by.
+	Aliases []string
+	// Usage is a one-line synopsis, e.g. "ls [options] [path]".
+	Usage string
+	// Summary is a short description of what the command does.
+	Summary string
+	// Description is a longer explanation of the command, shown in its
+	// manual page. Paragraphs are separated by blank lines.
+	Description string
+	// Flags returns a new flag set declaring the command's flags. It is used
+	// for introspection, e.g. by help, and may be nil.
+	Flags func() *posixflag.FlagSet
+	// Class is the CSS class of the output the command writes to the terminal.
+	Class string
+	// Run executes the command with the given arguments.
+	Run func(env *Env, args []string) error
+}
+
+// Env is the environment a command runs in.
+type Env struct {
+	// FS is the filesystem of the session. Writes are only visible to the
+	// session.
+	FS        *termfs.Overlay
+	Sessions  SessionManager
+	SessionID string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Output is the terminal output of the command line the command is part
+	// of. Commands use it to make requests to the client terminal.
+	Output *Output
+
+	// shell is the shell running the command, for the commands that change
+	// how it goes on, such as return. Exec sets it.
+	shell *shell
+}
+
+// ArgError records the argument that caused a command to fail, allowing
+// contextual error messages such as "cat: foo.txt: No such file or directory".
+type ArgError struct {
+	Arg string
+	Err error
+}
+
+func (e *ArgError) Error() string {
+	return e.Arg + ": " + e.Err.Error()
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+// Registry is a table of commands, looked up by name or alias.
+type Registry struct {
+	cmds   []*Command
+	byName map[string]*Command
+}
+
+// NewRegistry creates a registry with all built-in commands registered.
+func NewRegistry() *Registry {
+	r := &Registry{
+		byName: make(map[string]*Command),
+	}
+
+	registerBuiltins(r)
+
+	return r
+}
+
+// Register adds a command to the registry. It panics if the name or any of
+// the aliases is already registered.
+func (r *Registry) Register(cmd *Command) {
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if _, exists := r.byName[name]; exists {
+			panic(fmt.Sprintf("termui: command %q registered twice", name))
+		}
+		r.byName[name] = cmd
+	}
+	r.cmds = append(r.cmds, cmd)
+}
+
+// Lookup returns the command registered under name or alias.
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	cmd, ok := r.byName[name]
+	return cmd, ok
+}
+
+// Commands returns all registered commands in registration order.
+func (r *Registry) Commands() []*Command {
+	return r.cmds
+}
+
+// Run runs the named command with args.
+// Possible errors: ErrCommandNotFound, or any error returned by the command.
+func (r *Registry) Run(env *Env, name string, args []string) error {
+	cmd, ok := r.Lookup(name)
+	if !ok {
+		return &ArgError{Arg: name, Err: ErrCommandNotFound}
+	}
+	return cmd.Run(env, args)
+}
+
+// errorMessages maps command errors to the message shown to the user.
+var errorMessages = []struct {
+	err error
+	msg string
+}{
+	{ErrCommandNotFound, "command not found..."},
+	{ErrFileNotFound, "No such file or directory"},
+	{ErrNotDirectory, "Not a directory"},
+	{ErrIsDirectory, "Is a directory"},
+	{ErrAccessDenied, "Permission denied"},
+	{ErrMissingArgument, "missing file argument"},
+	{ErrTooManyArguments, "too many arguments"},
+	{ErrInvalidFlag, "invalid flag or option"},
+	{ErrNotOpenable, "file is not openable"},
+	{ErrQuotaExceeded, "Disk quota exceeded"},
+	{ErrAmbiguousRedirect, "ambiguous redirect"},
+	{ErrFileExists, "File exists"},
+	{ErrDirectoryNotEmpty, "Directory not empty"},
+	{ErrIntoItself, "cannot move or copy a directory into itself"},
+	{ErrSameFile, "source and destination are the same file"},
+	{ErrInvalidIdentifier, "not a valid identifier"},
+	{ErrVarNotSet, "not set"},
+	{ErrMissingPattern, "missing pattern"},
+	{ErrInvalidPattern, "invalid regular expression"},
+	{ErrInvalidExpression, "invalid expression"},
+	{ErrUnknownPredicate, "unknown predicate"},
+	{ErrNoManualEntry, "No manual entry"},
+	{ErrNoManualMatch, "nothing appropriate"},
+	{ErrEventNotFound, "event not found"},
+	{ErrBadSubstitution, "substitution failed"},
+	{ErrNotNumeric, "numeric argument required"},
+	{ErrAliasNotFound, "not found"},
+	{ErrInvalidAliasName, "invalid alias name"},
+	{ErrAliasTooDeep, "alias expansion too deep, stopped"},
+	{ErrAliasTooLong, "alias expansion too long, stopped"},
+	{ErrIntegerExpected, "integer expression expected"},
+	{ErrUnaryExpected, "unary operator expected"},
+	{ErrBinaryExpected, "binary operator expected"},
+	{ErrMissingBracket, "missing `]'"},
+	{ErrStepLimit, "step limit exceeded, stopped"},
+	{ErrOutputLimit, "output limit exceeded, stopped"},
+	{ErrValueLimit, "value limit exceeded, stopped"},
+}
+
+// FormatError formats an error returned by the named command as a shell
+// style error message. If err wraps an ArgError the argument is included.
+func FormatError(name string, err error) string {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("%s: %s", name, syntaxErr.Msg)
+	}
+
+	msg := "internal error"
+	for _, em := range errorMessages {
+		if errors.Is(err, em.err) {
+			msg = em.msg
+			break
+		}
+	}
+
+	var argErr *ArgError
+	if errors.As(err, &argErr) && argErr.Arg != "" {
+		return fmt.Sprintf("%s: %s: %s", name, argErr.Arg, msg)
+	}
+
+	return fmt.Sprintf("%s: %s", name, msg)
+}
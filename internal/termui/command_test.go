@@ -0,0 +1,152 @@
+package termui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_lookup(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&Command{
+		Name:    "greet",
+		Aliases: []string{"hello", "hi"},
+		Usage:   "greet",
+		Summary: "Say hello",
+	})
+
+	for _, name := range []string{"ls", "cd", "pwd", "cat", "open", "clear", "help", "greet", "hello", "hi"} {
+		if _, ok := r.Lookup(name); !ok {
+			t.Errorf("Lookup(%q) ok = false, want true", name)
+		}
+	}
+
+	if _, ok := r.Lookup("nonexistent"); ok {
+		t.Errorf("Lookup(%q) ok = true, want false", "nonexistent")
+	}
+
+	cmd, _ := r.Lookup("hi")
+	if got, want := cmd.Name, "greet"; got != want {
+		t.Errorf("Lookup(%q).Name = %q, want %q", "hi", got, want)
+	}
+}
+
+func TestRegistry_register_duplicate(t *testing.T) {
+	r := NewRegistry()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of duplicate alias did not panic")
+		}
+	}()
+
+	r.Register(&Command{Name: "list", Aliases: []string{"ls"}})
+}
+
+func TestRegistry_run(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	env := newTestEnv(tfs, sessMgr, sessionID)
+
+	r := NewRegistry()
+
+	t.Run("builtin command", func(t *testing.T) {
+		sessMgr.SetCurrentDir(sessionID, "home/zorcal")
+
+		if err := r.Run(env, "pwd", nil); err != nil {
+			t.Fatalf("Run(env, %q, nil) error = %v, want nil", "pwd", err)
+		}
+		if got, want := env.Stdout.(*bytes.Buffer).String(), "/home/zorcal\n"; got != want {
+			t.Errorf("Run(env, %q, nil) output = %q, want %q", "pwd", got, want)
+		}
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		err := r.Run(env, "nonexistent", nil)
+		if !errors.Is(err, ErrCommandNotFound) {
+			t.Errorf("Run(env, %q, nil) error = %v, want %v", "nonexistent", err, ErrCommandNotFound)
+		}
+	})
+
+	t.Run("error carries argument", func(t *testing.T) {
+		err := r.Run(env, "cat", []string{"nonexistent.txt"})
+		if got, want := FormatError("cat", err), "cat: nonexistent.txt: No such file or directory"; got != want {
+			t.Errorf("FormatError(%q, %v) = %q, want %q", "cat", err, got, want)
+		}
+	})
+}
+
+func TestFormatError(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		err  error
+		want string
+	}{
+		{
+			name: "argument context",
+			cmd:  "cd",
+			err:  &ArgError{Arg: "foo", Err: fmt.Errorf("stat directory %q: %w", "foo", ErrNotDirectory)},
+			want: "cd: foo: Not a directory",
+		},
+		{
+			name: "empty argument context",
+			cmd:  "cat",
+			err:  &ArgError{Arg: "", Err: ErrMissingArgument},
+			want: "cat: missing file argument",
+		},
+		{
+			name: "no argument context",
+			cmd:  "ls",
+			err:  ErrTooManyArguments,
+			want: "ls: too many arguments",
+		},
+		{
+			name: "wrapped flag error",
+			cmd:  "ls",
+			err:  fmt.Errorf("%w: unknown flag: -x", ErrInvalidFlag),
+			want: "ls: invalid flag or option",
+		},
+		{
+			name: "command not found",
+			cmd:  "shell",
+			err:  &ArgError{Arg: "foo", Err: ErrCommandNotFound},
+			want: "shell: foo: command not found...",
+		},
+		{
+			name: "unknown error",
+			cmd:  "pwd",
+			err:  errors.New("boom"),
+			want: "pwd: internal error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatError(tt.cmd, tt.err); got != tt.want {
+				t.Errorf("FormatError(%q, %v) = %q, want %q", tt.cmd, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelpText(t *testing.T) {
+	r := NewRegistry()
+
+	got := helpText(r).String()
+	for _, cmd := range r.Commands() {
+		if !strings.Contains(got, cmd.Usage) {
+			t.Errorf("helpText() = %q, want to contain usage %q", got, cmd.Usage)
+		}
+		if !strings.Contains(got, cmd.Summary) {
+			t.Errorf("helpText() = %q, want to contain summary %q", got, cmd.Summary)
+		}
+	}
+
+	for _, want := range []string{"-a, --all", "-l, --long"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("helpText() = %q, want to contain flag %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package termui
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// Completion is the result of completing the word at the cursor of a command
+// line.
+type Completion struct {
+	// Start and End are the character offsets of the word in the line that a
+	// candidate replaces.
+	Start, End int
+	Candidates []Candidate
+}
+
+// Candidate is a possible completion of a word.
+type Candidate struct {
+	// Value replaces the word, escaped for the shell. Directories end in /.
+	Value string
+	// Display is the name shown when listing candidates.
+	Display string
+}
+
+// Complete completes the word that ends at cursor, a character offset in
+// line. In command position, the word completes to command names and the
+// aliases of the session. A word starting with - after a command completes
+// to the command's flags. Any other word completes to paths relative to the
+// session's current directory; hidden files are only candidates when the
+// word's last element starts with a dot. Candidates are sorted by value.
+func (r *Registry) Complete(env *Env, line string, cursor int) Completion {
+	runes := []rune(line)
+	cursor = max(0, min(cursor, len(runes)))
+
+	start := cursor
+	for start > 0 && !isWordBoundary(runes, start-1) {
+		start--
+	}
+
+	word := string(runes[start:cursor])
+	before := strings.TrimRight(string(runes[:start]), " \t")
+
+	comp := Completion{Start: start, End: cursor}
+	switch {
+	case before == "" || strings.ContainsRune("|;&", rune(before[len(before)-1])):
+		comp.Candidates = r.completeCommand(env, word)
+	case strings.HasPrefix(word, "-") && !strings.HasSuffix(before, ">"):
+		comp.Candidates = r.completeFlag(currentCommand(before), word)
+	default:
+		comp.Candidates = completePath(env, word)
+	}
+
+	sort.Slice(comp.Candidates, func(i, j int) bool {
+		return comp.Candidates[i].Value < comp.Candidates[j].Value
+	})
+
+	return comp
+}
+
+func (r *Registry) completeCommand(env *Env, prefix string) []Candidate {
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, Candidate{Value: name, Display: name})
+		}
+	}
+
+	for _, cmd := range r.Commands() {
+		add(cmd.Name)
+		for _, name := range cmd.Aliases {
+			add(name)
+		}
+	}
+	for name := range env.Sessions.GetAliases(env.SessionID) {
+		add(name)
+	}
+	return candidates
+}
+
+func (r *Registry) completeFlag(name, prefix string) []Candidate {
+	cmd, ok := r.Lookup(name)
+	if !ok || cmd.Flags == nil {
+		return nil
+	}
+
+	var candidates []Candidate
+	cmd.Flags().VisitAll(func(f *posixflag.Flag) {
+		names := []string{"--" + f.Name}
+		if f.Short != 0 {
+			names = append(names, "-"+string(f.Short))
+		}
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				candidates = append(candidates, Candidate{Value: name, Display: name})
+			}
+		}
+	})
+	return candidates
+}
+
+func completePath(env *Env, word string) []Candidate {
+	word = unescape(word)
+
+	dirPart, base := "", word
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		dirPart, base = word[:i+1], word[i+1:]
+	}
+
+	dirArg := dirPart
+	switch {
+	case dirArg == "":
+		dirArg = "."
+	case dirArg == "~/" || strings.HasPrefix(dirArg, "~/"):
+		dirArg = env.Sessions.GetEnv(env.SessionID)["HOME"] + dirArg[1:]
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+	entries, err := fs.ReadDir(env.FS, fsPath(resolvePath(currDir, dirArg)))
+	if err != nil {
+		return nil
+	}
+
+	var candidates []Candidate
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		candidates = append(candidates, Candidate{
+			Value:   escapeWord(dirPart + name),
+			Display: name,
+		})
+	}
+	return candidates
+}
+
+// currentCommand returns the name of the command of the last simple command
+// in line.
+func currentCommand(line string) string {
+	if i := strings.LastIndexAny(line, "|;&"); i >= 0 {
+		line = line[i+1:]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// isWordBoundary reports whether runes[i] separates words: unescaped
+// whitespace or an operator character.
+func isWordBoundary(runes []rune, i int) bool {
+	if !strings.ContainsRune(" \t|;&<>", runes[i]) {
+		return false
+	}
+	return i == 0 || runes[i-1] != '\\'
+}
+
+// shellSpecial are the characters escapeWord escapes.
+const shellSpecial = " \t\\'\"|;&<>$*?[~#"
+
+// escapeWord escapes the characters of s that the shell would otherwise
+// interpret, except for a leading ~/.
+func escapeWord(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if strings.ContainsRune(shellSpecial, r) && !(i == 0 && strings.HasPrefix(s, "~/")) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
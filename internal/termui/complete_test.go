@@ -0,0 +1,62 @@
+package termui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegistry_complete(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	sessMgr.SetCurrentDir(sessionID, "home/zorcal")
+	sessMgr.SetAlias(sessionID, "gs", "grep -s")
+
+	if err := tfs.Mkdir("home/zorcal/my docs"); err != nil {
+		t.Fatalf("Mkdir() failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		line      string
+		cursor    int
+		wantStart int
+		want      []string
+	}{
+		{"command name", "ec", 2, 0, []string{"echo"}},
+		{"several commands", "c", 1, 0, []string{"cat", "cd", "clear", "continue", "cp", "cut"}},
+		{"alias", "g", 1, 0, []string{"grep", "gs"}},
+		{"command after pipe", "ls | ca", 7, 5, []string{"cat"}},
+		{"command after operator", "cd .. &&pw", 10, 8, []string{"pwd"}},
+		{"path", "cat pro", 7, 4, []string{"projects/"}},
+		{"path in directory", "cat projects/t", 14, 4, []string{"projects/test-repo.md"}},
+		{"all entries", "ls ", 3, 3, []string{"my\\ docs/", "projects/"}},
+		{"hidden entries with dot", "cat .", 5, 4, []string{".secret.txt"}},
+		{"absolute path", "cd /ho", 6, 3, []string{"/home/"}},
+		{"tilde path", "cat ~/w", 7, 4, []string{"~/welcome.txt"}},
+		{"escaped space", `cd my\ d`, 8, 3, []string{"my\\ docs/"}},
+		{"redirect target", "ls >pro", 7, 4, []string{"projects/"}},
+		{"cursor in middle", "cat pro | wc", 7, 4, []string{"projects/"}},
+		{"missing directory", "cat nothing/", 12, 4, nil},
+		{"long flag", "ls --a", 6, 3, []string{"--all"}},
+		{"all flags", "rm -", 4, 3, []string{"--force", "--recursive", "-f", "-r"}},
+		{"command without flags", "cd -", 4, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(tfs, sessMgr, sessionID)
+
+			comp := NewRegistry().Complete(env, tt.line, tt.cursor)
+			if comp.Start != tt.wantStart || comp.End != tt.cursor {
+				t.Errorf("Complete(env, %q, %d) range = [%d, %d), want [%d, %d)", tt.line, tt.cursor, comp.Start, comp.End, tt.wantStart, tt.cursor)
+			}
+
+			var got []string
+			for _, c := range comp.Candidates {
+				got = append(got, c.Value)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Complete(env, %q, %d) = %q, want %q", tt.line, tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// EnvQuota is the number of bytes the environment variables of a session may
+// take up, counted as in their name=value form.
+const EnvQuota = 32 << 10
+
+// Vars holds values by name, such as the environment variables of a session,
+// within a quota of bytes the names and values may take up, counted as in
+// their name=value form. The zero value has no quota to hold anything.
+type Vars struct {
+	values map[string]string
+	size   int
+	quota  int
+}
+
+// NewVars returns values held within quota bytes. Values that exceed the
+// quota are held all the same, but no value may be added until some are
+// removed.
+func NewVars(values map[string]string, quota int) *Vars {
+	v := &Vars{values: values, quota: quota}
+	for name, value := range values {
+		v.size += varSize(name, value)
+	}
+	return v
+}
+
+// Map returns a copy of the values by name.
+func (v *Vars) Map() map[string]string {
+	return maps.Clone(v.values)
+}
+
+// Set sets name to value, unless the values would then exceed the quota.
+// Possible errors: ErrQuotaExceeded.
+func (v *Vars) Set(name, value string) error {
+	size := v.size + varSize(name, value)
+	if prev, exists := v.values[name]; exists {
+		size -= varSize(name, prev)
+	}
+	if size > v.quota && size > v.size {
+		return ErrQuotaExceeded
+	}
+
+	if v.values == nil {
+		v.values = make(map[string]string)
+	}
+	v.values[name] = value
+	v.size = size
+	return nil
+}
+
+// Unset removes name.
+func (v *Vars) Unset(name string) {
+	if prev, exists := v.values[name]; exists {
+		v.size -= varSize(name, prev)
+		delete(v.values, name)
+	}
+}
+
+// varSize returns the number of bytes name=value takes up.
+func varSize(name, value string) int {
+	return len(name) + 1 + len(value)
+}
+
+// DefaultEnv returns the environment variables of a new session.
+func DefaultEnv() map[string]string {
+	return map[string]string{
+		"HOME":  "/home/guest",
+		"PWD":   "/home/guest",
+		"USER":  "guest",
+		"PATH":  "/usr/local/bin:/usr/bin:/bin",
+		"SHELL": "/bin/sh",
+	}
+}
+
+// Export sets the session environment variables given as NAME=value
+// arguments. A NAME argument without a value leaves the variable unchanged.
+// Without arguments it writes every variable to standard output in a form
+// that can be reused as input. Invalid names and variables that do not fit
+// in the EnvQuota of the session are returned joined as *ArgError.
+// Possible errors: ErrInvalidIdentifier, ErrQuotaExceeded.
+func Export(env *Env, args []string) error {
+	if len(args) == 0 {
+		vars := env.Sessions.GetEnv(env.SessionID)
+		for _, name := range slices.Sorted(maps.Keys(vars)) {
+			fmt.Fprintf(env.Stdout, "export %s=%s\n", name, quoteValue(vars[name]))
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !isValidName(name) {
+			errs = append(errs, &ArgError{Arg: arg, Err: ErrInvalidIdentifier})
+			continue
+		}
+		if !hasValue {
+			continue
+		}
+		if err := env.Sessions.SetEnv(env.SessionID, name, value); err != nil {
+			errs = append(errs, &ArgError{Arg: name, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Unset removes the session environment variables given as arguments.
+// Invalid names are returned joined as *ArgError.
+// Possible errors: ErrInvalidIdentifier.
+func Unset(env *Env, args []string) error {
+	var errs []error
+	for _, name := range args {
+		if !isValidName(name) {
+			errs = append(errs, &ArgError{Arg: name, Err: ErrInvalidIdentifier})
+			continue
+		}
+		env.Sessions.UnsetEnv(env.SessionID, name)
+	}
+
+	return errors.Join(errs...)
+}
+
+// PrintEnv writes the session environment variables to standard output as
+// NAME=value lines, sorted by name.
+// Possible errors: ErrTooManyArguments.
+func PrintEnv(env *Env, args []string) error {
+	if len(args) > 0 {
+		return ErrTooManyArguments
+	}
+
+	vars := env.Sessions.GetEnv(env.SessionID)
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		fmt.Fprintf(env.Stdout, "%s=%s\n", name, vars[name])
+	}
+
+	return nil
+}
+
+// quoteValue quotes s with single quotes so that the shell reads it back
+// literally.
+func quoteValue(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,189 @@
+package termui
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestEnvCommands(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "default variables",
+			line:       "echo $HOME $USER $SHELL",
+			wantOutput: "/home/guest guest /bin/sh\n",
+		},
+		{
+			name:       "export",
+			line:       "export GREETING=hello NAME='big world' && echo \"$GREETING, ${NAME}!\"",
+			wantOutput: "hello, big world!\n",
+		},
+		{
+			name:       "export lists variables",
+			line:       "unset PATH SHELL USER PWD; export HOME=\"it's\"; export",
+			wantOutput: "export HOME='it'\\''s'\n",
+		},
+		{
+			name:       "export invalid name",
+			line:       "export 1X=a",
+			wantOutput: "export: 1X=a: not a valid identifier\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "unset",
+			line:       "unset USER && echo \"[$USER]\"",
+			wantOutput: "[]\n",
+		},
+		{
+			name:       "env",
+			line:       "unset PATH SHELL; export A=1; env",
+			wantOutput: "A=1\nHOME=/home/guest\nPWD=/home/zorcal/projects\nUSER=guest\n",
+		},
+		{
+			name:       "cd updates PWD and OLDPWD",
+			line:       "cd .. && echo $PWD $OLDPWD",
+			wantOutput: "/home/zorcal /home/zorcal/projects\n",
+		},
+		{
+			name:       "cd dash",
+			line:       "cd /home; cd -; pwd",
+			wantOutput: "/home/zorcal/projects\n/home/zorcal/projects\n",
+		},
+		{
+			name:       "cd dash without OLDPWD",
+			line:       "unset OLDPWD; cd -",
+			wantOutput: "cd: OLDPWD: not set\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "cd uses HOME",
+			line:       "export HOME=/home/zorcal && cd && pwd",
+			wantOutput: "/home/zorcal\n",
+		},
+		{
+			name:       "cd tilde",
+			line:       "cd ~ && pwd",
+			wantOutput: "/home/guest\n",
+		},
+		{
+			name:       "variable in redirect target",
+			line:       "export F=out.txt; echo hi > $F && cat out.txt",
+			wantOutput: "hi\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+			sessMgr.SetEnv(sessionID, "PWD", "/home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestChangeDirectory_homeNotSet(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	sessMgr.UnsetEnv(sessionID, "HOME")
+
+	_, gotErr := runCommand(tfs, sessMgr, sessionID, ChangeDirectory, nil)
+	if !errors.Is(gotErr, ErrVarNotSet) {
+		t.Errorf("ChangeDirectory(env, []) error = %v, want %v", gotErr, ErrVarNotSet)
+	}
+	if got, want := argContext(gotErr), "HOME"; got != want {
+		t.Errorf("ChangeDirectory(env, []) arg context = %q, want %q", got, want)
+	}
+}
+
+func TestEnvCommands_quota(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+	r := NewRegistry()
+
+	// x holds 10KiB, so that only a few copies fit in the quota.
+	const bigVar = "x=0123456789; for i in 1 2 3 4 5 6 7 8 9 10; do x=$x$x; done; "
+
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+	}{
+		{
+			name:    "export in a loop",
+			line:    bigVar + "for a in 0 1 2 3 4 5 6 7 8 9; do for b in 0 1 2 3 4 5 6 7 8 9; do export v$a$b=$x; done; done",
+			wantErr: "export: v02: Disk quota exceeded\n",
+		},
+		{
+			name:    "assignment",
+			line:    "y=$x",
+			wantErr: "shell: y: Disk quota exceeded\n",
+		},
+		{
+			name:    "assignment to a command",
+			line:    "y=$x pwd",
+			wantErr: "shell: y: Disk quota exceeded\n",
+		},
+		{
+			name:    "for variable",
+			line:    "for y in $x; do echo no; done",
+			wantErr: "shell: y: Disk quota exceeded\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, status := r.Exec(env, tt.line)
+			if status != 1 {
+				t.Errorf("Exec(env, %q) status = %d, want 1", tt.line, status)
+			}
+			if got := out.String(); !strings.HasPrefix(got, tt.wantErr) {
+				t.Errorf("Exec(env, %q) output = %.200q, want it to start with %q", tt.line, got, tt.wantErr)
+			}
+
+			var size int
+			for name, value := range sessMgr.GetEnv(sessionID) {
+				size += len(name) + 1 + len(value)
+			}
+			if size > EnvQuota {
+				t.Errorf("after Exec(env, %q) the variables take up %d bytes, want at most %d", tt.line, size, EnvQuota)
+			}
+		})
+	}
+}
+
+func TestVars_Set(t *testing.T) {
+	vars := NewVars(map[string]string{"A": "1"}, 10)
+
+	if err := vars.Set("B", "12345"); err != nil {
+		t.Fatalf("Set(B, 12345) error = %v, want nil", err)
+	}
+	if err := vars.Set("C", "1"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Set(C, 1) error = %v, want %v", err, ErrQuotaExceeded)
+	}
+	if err := vars.Set("B", "1234"); err != nil {
+		t.Errorf("Set(B, 1234) error = %v, want nil", err)
+	}
+
+	vars.Unset("B")
+	if err := vars.Set("C", "12345"); err != nil {
+		t.Errorf("Set(C, 12345) after Unset(B) error = %v, want nil", err)
+	}
+	if got, want := vars.Map(), map[string]string{"A": "1", "C": "12345"}; !maps.Equal(got, want) {
+		t.Errorf("Map() = %v, want %v", got, want)
+	}
+}
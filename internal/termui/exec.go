@@ -0,0 +1,313 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Exec parses and runs a command line. History designators such as !! are
+// expanded first, from the command lines in the session history, and the
+// expanded line is echoed to the output and recorded as out.Line. Aliases of
+// the session are then expanded in the first word of each simple command.
+// The line is run as a script, see parseScript: pipelines are run in order,
+// subject to the && and || operators joining them, along with the compound
+// commands of the line. The standard output of each command in a pipeline is
+// connected to the standard input of the next, and a command's standard
+// output may be redirected to a file in env.FS with > or >>. The standard
+// output of the last command of every pipeline, unless redirected, and the
+// standard error of every command are written to the returned Output.
+//
+// env provides the filesystem and session the commands run in, and
+// optionally the standard input of the first command. Exec returns the exit
+// status of the last command that ran, which is also recorded in the
+// session as the value of $?.
+func (r *Registry) Exec(env *Env, line string) (*Output, int) {
+	out := &Output{Line: line}
+	stderr := out.Writer(stderrClass)
+
+	expanded, err := expandHistory(line, env.Sessions.GetHistory(env.SessionID))
+	if err != nil {
+		writeError(stderr, "shell", err)
+		env.Sessions.SetLastStatus(env.SessionID, 1)
+		return out, 1
+	}
+	if expanded != line {
+		fmt.Fprintln(out.Writer(""), expanded)
+		line = expanded
+		out.Line = line
+	}
+
+	aliased, err := expandAliases(line, env.Sessions.GetAliases(env.SessionID))
+	if err != nil {
+		writeError(stderr, "shell", err)
+		env.Sessions.SetLastStatus(env.SessionID, 1)
+		return out, 1
+	}
+
+	nodes, err := parseScript(aliased)
+	if err != nil {
+		writeError(stderr, "shell", err)
+		status := exitStatus(err)
+		env.Sessions.SetLastStatus(env.SessionID, status)
+		return out, status
+	}
+
+	shellEnv := *env
+	shellEnv.Output = out
+	sh := r.newShell(&shellEnv)
+	sh.run(nodes)
+
+	env.Sessions.SetLastStatus(env.SessionID, sh.status)
+
+	return out, sh.status
+}
+
+// execPipeline runs the commands of a pipeline and returns the exit status of
+// the last one.
+func (sh *shell) execPipeline(pipeline Pipeline) int {
+	env, out := sh.env, sh.env.Output
+	stderr := out.Writer(stderrClass)
+	exp := sh.expander()
+
+	stdin := env.Stdin
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+
+	var status int
+	for i, cmd := range pipeline {
+		assigns, words := splitAssignments(cmd.Args)
+		var args []string
+		for _, w := range words {
+			args = append(args, exp.fields(w)...)
+		}
+		if err := checkValues(args); err != nil {
+			sh.abort(err)
+			return 1
+		}
+
+		stage := *env
+		stage.Stdin = stdin
+		stage.Stderr = stderr
+		stage.shell = sh
+
+		var pipe *outputBuffer
+		switch {
+		case i < len(pipeline)-1:
+			pipe = newOutputBuffer(out)
+			stage.Stdout = pipe
+		case sh.stdout != nil:
+			stage.Stdout = sh.stdout
+		case len(args) > 0:
+			stage.Stdout = out.Writer(sh.r.class(args[0]))
+		}
+		stdin = pipe
+
+		target, targetPath, err := openRedirects(env, cmd.Redirects, exp)
+		if err != nil {
+			writeError(stderr, "shell", err)
+			status = exitStatus(err)
+			continue
+		}
+
+		var redirected *outputBuffer
+		if targetPath != "" {
+			redirected = newOutputBuffer(out)
+			stage.Stdout = redirected
+		}
+
+		restore, err := sh.assign(exp, assigns)
+		if errors.Is(err, ErrValueLimit) {
+			sh.abort(err)
+			return 1
+		}
+		if err != nil {
+			writeError(stderr, "shell", err)
+			status = 1
+			continue
+		}
+
+		var runErr error
+		if len(args) > 0 {
+			runErr = sh.runCommand(&stage, args)
+			restore()
+			if runErr != nil {
+				name := args[0]
+				if strings.Contains(name, "/") {
+					name = "shell"
+				}
+				writeError(stderr, name, runErr)
+			}
+		}
+		status = exitStatus(runErr)
+
+		if redirected != nil {
+			if err := env.FS.AppendFile(targetPath, redirected.Bytes()); err != nil {
+				writeError(stderr, "shell", &ArgError{Arg: target, Err: mapFSErr(err)})
+				status = 1
+			}
+		}
+	}
+
+	return status
+}
+
+// assignment is a name=value word assigning a variable.
+type assignment struct {
+	name  string
+	value Word
+}
+
+// splitAssignments splits the words of a simple command into the variable
+// assignments it starts with and the words that follow.
+func splitAssignments(words []Word) ([]assignment, []Word) {
+	var assigns []assignment
+	for i, w := range words {
+		if len(w) == 0 || w[0].Quote != 0 {
+			return assigns, words[i:]
+		}
+		name, value, ok := strings.Cut(w[0].Text, "=")
+		if !ok || !isValidName(name) {
+			return assigns, words[i:]
+		}
+
+		a := assignment{name: name, value: slices.Clone(w[1:])}
+		if value != "" {
+			a.value = slices.Insert(a.value, 0, WordPart{Text: value})
+		}
+		assigns = append(assigns, a)
+	}
+	return assigns, nil
+}
+
+// assign sets the variables of the session assigned by assigns, their values
+// expanded by exp, and returns a function restoring their previous values,
+// for assignments that only apply to the command they precede. No variable
+// is set if a value is too long, and none is left set if one does not fit in
+// the EnvQuota of the session.
+// Possible errors: ErrValueLimit, or ErrQuotaExceeded as *ArgError carrying
+// the name of the variable.
+func (sh *shell) assign(exp *expander, assigns []assignment) (restore func(), err error) {
+	values := make([]string, len(assigns))
+	for i, a := range assigns {
+		values[i] = exp.expand(a.value)
+	}
+	if err := checkValues(values); err != nil {
+		return nil, err
+	}
+
+	env := sh.env
+	prev := make(map[string]*string)
+	restore = func() {
+		for name, value := range prev {
+			if value == nil {
+				env.Sessions.UnsetEnv(env.SessionID, name)
+			} else {
+				// The previous value fitted before the assignment, so it
+				// only fails to fit again if the command set other
+				// variables, in which case the variable is left as it is.
+				_ = env.Sessions.SetEnv(env.SessionID, name, *value)
+			}
+		}
+	}
+
+	for i, a := range assigns {
+		if _, saved := prev[a.name]; !saved {
+			prev[a.name] = nil
+			if value, exists := exp.vars[a.name]; exists {
+				prev[a.name] = &value
+			}
+		}
+		if err := env.Sessions.SetEnv(env.SessionID, a.name, values[i]); err != nil {
+			restore()
+			return nil, &ArgError{Arg: a.name, Err: err}
+		}
+	}
+
+	return restore, nil
+}
+
+// openRedirects expands the targets of the output redirections of a command
+// and creates or truncates them, in order, before the command runs. It
+// returns the file standard output is redirected to, which is the target of
+// the last redirection, both as written and as a filesystem path. Both are
+// empty if there are no redirections.
+func openRedirects(env *Env, redirects []Redirect, exp *expander) (target, targetPath string, err error) {
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+
+	for _, redirect := range redirects {
+		target = exp.expand(redirect.Target)
+		if target == "" {
+			return "", "", &ArgError{Arg: redirect.Target.String(), Err: ErrAmbiguousRedirect}
+		}
+
+		targetPath = resolvePath(currDir, target)
+		if targetPath == "" {
+			return "", "", &ArgError{Arg: target, Err: ErrIsDirectory}
+		}
+
+		if redirect.Op == RedirectAppend {
+			err = env.FS.AppendFile(targetPath, nil)
+		} else {
+			err = env.FS.WriteFile(targetPath, nil)
+		}
+		if err != nil {
+			return "", "", &ArgError{Arg: target, Err: mapFSErr(err)}
+		}
+	}
+
+	return target, targetPath, nil
+}
+
+// class returns the output class of the named command.
+func (r *Registry) class(name string) string {
+	if cmd, ok := r.Lookup(name); ok {
+		return cmd.Class
+	}
+	return ""
+}
+
+// exitStatus returns the exit status of a command that returned err.
+func exitStatus(err error) int {
+	var status ExitStatus
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &status):
+		return int(status)
+	case errors.Is(err, ErrCommandNotFound):
+		return 127
+	case errors.Is(err, ErrSyntax), errors.Is(err, ErrInvalidFlag),
+		errors.Is(err, ErrMissingArgument), errors.Is(err, ErrTooManyArguments),
+		errors.Is(err, ErrMissingPattern), errors.Is(err, ErrInvalidPattern),
+		errors.Is(err, ErrIntegerExpected), errors.Is(err, ErrUnaryExpected),
+		errors.Is(err, ErrBinaryExpected), errors.Is(err, ErrMissingBracket):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// writeError writes the error returned by the named command to w. Joined
+// errors are written one per line, and ExitStatus errors are not written.
+func writeError(w io.Writer, name string, err error) {
+	if errors.Is(err, ErrCommandNotFound) {
+		name = "shell"
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	for _, err := range errs {
+		var status ExitStatus
+		if errors.As(err, &status) {
+			continue
+		}
+		fmt.Fprintln(w, FormatError(name, err))
+	}
+}
@@ -0,0 +1,283 @@
+package termui
+
+import (
+	"testing"
+
+	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
+)
+
+func TestRegistry_exec(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "empty line",
+			line:       "",
+			wantOutput: "",
+		},
+		{
+			name:       "single command",
+			line:       "pwd",
+			wantOutput: "/home/zorcal/projects\n",
+		},
+		{
+			name:       "ls into cat lists one entry per line",
+			line:       "ls | cat",
+			wantOutput: "app.js\ntest-repo.md\n",
+		},
+		{
+			name:       "multi stage pipeline",
+			line:       "cat app.js | cat | cat -",
+			wantOutput: "console.log('hello world');\n\n**URL:** https://github.com/example/app-js",
+		},
+		{
+			name:       "failing first stage still runs the rest",
+			line:       "cat missing.txt | cat",
+			wantOutput: "cat: missing.txt: No such file or directory\n",
+		},
+		{
+			name:       "unknown command",
+			line:       "nonexistent | cat",
+			wantOutput: "shell: nonexistent: command not found...\n",
+		},
+		{
+			name:       "unknown command status",
+			line:       "nonexistent",
+			wantOutput: "shell: nonexistent: command not found...\n",
+			wantStatus: 127,
+		},
+		{
+			name:       "sequence runs every pipeline",
+			line:       "cat missing.txt; pwd",
+			wantOutput: "cat: missing.txt: No such file or directory\n/home/zorcal/projects\n",
+		},
+		{
+			name:       "and runs on success",
+			line:       "cd .. && pwd",
+			wantOutput: "/home/zorcal\n",
+		},
+		{
+			name:       "and skips on failure",
+			line:       "cd missing && pwd",
+			wantOutput: "cd: missing: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "or runs on failure",
+			line:       "cat missing.txt || echo fallback",
+			wantOutput: "cat: missing.txt: No such file or directory\nfallback\n",
+		},
+		{
+			name:       "or skips on success",
+			line:       "true || echo fallback",
+			wantOutput: "",
+		},
+		{
+			name:       "mixed operators are left associative",
+			line:       "false || true && echo yes",
+			wantOutput: "yes\n",
+		},
+		{
+			name:       "status of last command",
+			line:       "false; echo $?; true; echo \"$?\" '$?' \\$?",
+			wantOutput: "1\n0 $? $?\n",
+		},
+		{
+			name:       "status of failed pipeline",
+			line:       "ls -x; echo $?",
+			wantOutput: "ls: invalid flag or option\n2\n",
+		},
+		{
+			name:       "pipeline status is the status of the last command",
+			line:       "cat missing.txt | true; echo $?",
+			wantOutput: "cat: missing.txt: No such file or directory\n0\n",
+		},
+		{
+			name:       "error of last stage",
+			line:       "ls | cat missing.txt",
+			wantOutput: "cat: missing.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "syntax error",
+			line:       "ls |",
+			wantOutput: "shell: syntax error near unexpected token `newline'\n",
+			wantStatus: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			sessMgr.SetLastStatus(sessionID, 0)
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRegistry_exec_classes(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+	out, _ := NewRegistry().Exec(env, "cat missing.txt app.js | ls")
+
+	chunks := out.Chunks()
+	if len(chunks) != 2 {
+		t.Fatalf("Exec(env, ...) chunks = %q, want 2 chunks", chunks)
+	}
+	if got, want := chunks[0].Class, stderrClass; got != want {
+		t.Errorf("chunks[0].Class = %q, want %q", got, want)
+	}
+	if got, want := chunks[1].Class, "file-list"; got != want {
+		t.Errorf("chunks[1].Class = %q, want %q", got, want)
+	}
+	if got, want := chunks[1].Text, "app.js  test-repo.md\n"; got != want {
+		t.Errorf("chunks[1].Text = %q, want %q", got, want)
+	}
+}
+
+func TestRegistry_exec_lastStatus(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+	r := NewRegistry()
+
+	r.Exec(env, "cat missing.txt")
+
+	if got, want := sessMgr.GetLastStatus(sessionID), 1; got != want {
+		t.Errorf("GetLastStatus(%q) = %d, want %d", sessionID, got, want)
+	}
+
+	out, _ := r.Exec(env, "echo $?")
+	if got, want := out.String(), "1\n"; got != want {
+		t.Errorf("Exec(env, %q) output = %q, want %q", "echo $?", got, want)
+	}
+}
+
+func TestRegistry_exec_clear(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: "session1"}
+
+	out, _ := NewRegistry().Exec(env, "echo before; clear; echo after")
+	if !out.Clear {
+		t.Errorf("Exec(env, ...) Clear = false, want true")
+	}
+	if got, want := out.String(), "after\n"; got != want {
+		t.Errorf("Exec(env, ...) output = %q, want %q", got, want)
+	}
+}
+
+func TestRegistry_exec_redirect(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "write file",
+			line:       "echo hi > notes.txt; cat notes.txt",
+			wantOutput: "hi\n",
+		},
+		{
+			name:       "append file",
+			line:       "echo one > notes.txt; echo two >> notes.txt; cat notes.txt",
+			wantOutput: "one\ntwo\n",
+		},
+		{
+			name:       "overwrite file",
+			line:       "echo one > notes.txt; echo two > notes.txt; cat notes.txt",
+			wantOutput: "two\n",
+		},
+		{
+			name:       "listing is one entry per line",
+			line:       "ls > listing.txt; cat listing.txt",
+			wantOutput: "app.js\nlisting.txt\ntest-repo.md\n",
+		},
+		{
+			name:       "append to existing file",
+			line:       "echo more >> app.js && cat app.js",
+			wantOutput: "console.log('hello world');\n\n**URL:** https://github.com/example/app-js" + "more\n",
+		},
+		{
+			name:       "redirect in pipeline",
+			line:       "echo hi > notes.txt | cat; cat notes.txt",
+			wantOutput: "hi\n",
+		},
+		{
+			name:       "redirect only creates file",
+			line:       "> empty.txt && ls",
+			wantOutput: "app.js  empty.txt  test-repo.md\n",
+		},
+		{
+			name:       "errors are not redirected",
+			line:       "cat missing.txt > out.txt",
+			wantOutput: "cat: missing.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "missing directory",
+			line:       "echo hi > nodir/notes.txt",
+			wantOutput: "shell: nodir/notes.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "directory target",
+			line:       "echo hi > /home",
+			wantOutput: "shell: /home: Is a directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "ambiguous redirect",
+			line:       "echo hi > ''",
+			wantOutput: "shell: ambiguous redirect\n",
+			wantStatus: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRegistry_exec_redirectQuota(t *testing.T) {
+	_, sessMgr := setupTest()
+	base := termfs.New(nil)
+	env := &Env{FS: termfs.NewOverlay(base, 64), Sessions: sessMgr, SessionID: "session1"}
+
+	out, status := NewRegistry().Exec(env, "cat welcome.txt > copy.txt")
+	if got, want := status, 1; got != want {
+		t.Errorf("Exec(env, ...) status = %d, want %d", got, want)
+	}
+	if got, want := out.String(), "shell: copy.txt: Disk quota exceeded\n"; got != want {
+		t.Errorf("Exec(env, ...) output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,308 @@
+package termui
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// globMeta are the characters that make an unquoted word a glob pattern.
+const globMeta = "*?["
+
+// expander expands the words of a command line into arguments.
+type expander struct {
+	// vars are the environment variables of the session.
+	vars map[string]string
+	// params are the positional parameters, starting with $0.
+	params []string
+	// lastStatus is the value of $?.
+	lastStatus int
+	// fsys and dir are the filesystem and current directory patterns are
+	// matched in.
+	fsys fs.FS
+	dir  string
+}
+
+// expand expands w into a single argument. A leading unquoted ~ is replaced
+// by $HOME, and the parameters $?, $NAME and ${NAME} are replaced by their
+// values in the unquoted and double quoted parts of w, along with the
+// positional parameters $0 to $9 and ${N}, their number $#, and all of them
+// but $0, joined by spaces, $@ and $*. Unset variables expand to the empty
+// string.
+func (e *expander) expand(w Word) string {
+	value, _, _ := e.expandWord(w)
+	return value
+}
+
+// fields expands w like expand and then, if an unquoted part of w contains
+// *, ? or [, replaces it with the names of the files matching it as a glob
+// pattern. A pattern that matches nothing is kept as is. The word $@, quoted
+// or not, expands to the positional parameters, one argument each.
+func (e *expander) fields(w Word) []string {
+	if len(w) == 1 && w[0].Text == "$@" && (w[0].Quote == 0 || w[0].Quote == '"') {
+		return slices.Clone(e.args())
+	}
+
+	value, pattern, isPattern := e.expandWord(w)
+	if !isPattern {
+		return []string{value}
+	}
+
+	if matches := e.glob(pattern); len(matches) > 0 {
+		return matches
+	}
+
+	return []string{value}
+}
+
+// expandWord returns the expansion of w along with the same expansion as a
+// glob pattern, in which the characters of quoted parts are escaped, and
+// whether that pattern contains unquoted glob characters.
+func (e *expander) expandWord(w Word) (value, pattern string, isPattern bool) {
+	var v, p strings.Builder
+	for i, part := range w {
+		if part.Quote == '\'' || part.Quote == '\\' {
+			v.WriteString(part.Text)
+			p.WriteString(escapeGlob(part.Text))
+			continue
+		}
+
+		text := part.Text
+		if i == 0 && part.Quote == 0 && (text == "~" || strings.HasPrefix(text, "~/")) {
+			v.WriteString(e.vars["HOME"])
+			p.WriteString(escapeGlob(e.vars["HOME"]))
+			text = text[1:]
+		}
+
+		var expanded strings.Builder
+		e.expandParams(&expanded, text)
+
+		v.WriteString(expanded.String())
+		if part.Quote == 0 {
+			p.WriteString(expanded.String())
+			isPattern = isPattern || strings.ContainsAny(expanded.String(), globMeta)
+		} else {
+			p.WriteString(escapeGlob(expanded.String()))
+		}
+	}
+	return v.String(), p.String(), isPattern
+}
+
+// glob returns the paths matching pattern, written the way the user wrote the
+// pattern: relative to the current directory or absolute. Hidden files only
+// match pattern elements that start with a dot. Patterns ending in / only
+// match directories.
+func (e *expander) glob(pattern string) []string {
+	if e.fsys == nil {
+		return nil
+	}
+
+	dirsOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimRight(pattern, "/")
+
+	// Split the pattern into its leading literal directory and the elements
+	// that contain glob characters, which are matched relative to it.
+	elems := strings.Split(pattern, "/")
+	literal := 0
+	for literal < len(elems) && !hasGlobMeta(elems[literal]) {
+		literal++
+	}
+	if literal == len(elems) {
+		return nil
+	}
+
+	prefix := unescape(strings.Join(elems[:literal], "/"))
+	if prefix == "" && strings.HasPrefix(pattern, "/") {
+		prefix = "/"
+	}
+	patternElems := elems[literal:]
+
+	dirArg := prefix
+	if dirArg == "" {
+		dirArg = "."
+	}
+	dir := resolvePath(e.dir, dirArg)
+
+	matches, err := fs.Glob(e.fsys, path.Join(escapeGlob(dir), strings.Join(patternElems, "/")))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, match := range matches {
+		rel := match
+		if dir != "" {
+			rel = strings.TrimPrefix(match, dir+"/")
+		}
+
+		if isHiddenMatch(strings.Split(rel, "/"), patternElems) {
+			continue
+		}
+
+		if dirsOnly {
+			info, err := fs.Stat(e.fsys, match)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+			rel += "/"
+		}
+
+		switch {
+		case prefix == "":
+			names = append(names, rel)
+		case strings.HasSuffix(prefix, "/"):
+			names = append(names, prefix+rel)
+		default:
+			names = append(names, prefix+"/"+rel)
+		}
+	}
+
+	return names
+}
+
+// isHiddenMatch reports whether an element of a matched path starts with a
+// dot while the pattern element it matched does not.
+func isHiddenMatch(elems, patternElems []string) bool {
+	for i, elem := range elems {
+		if i >= len(patternElems) {
+			break
+		}
+		p := patternElems[i]
+		if strings.HasPrefix(elem, ".") && !strings.HasPrefix(p, ".") && !strings.HasPrefix(p, `\.`) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGlobMeta reports whether pattern contains unescaped glob characters.
+func hasGlobMeta(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == '\\':
+			i++
+		case strings.IndexByte(globMeta, pattern[i]) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// escapeGlob escapes the glob characters and backslashes in s so that a
+// pattern matches s literally.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\\' || strings.ContainsRune(globMeta, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// unescape removes the escaping backslashes from a glob pattern or a word.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// expandParams writes text to b with its parameters expanded. A $ that does
+// not start a parameter is written literally.
+func (e *expander) expandParams(b *strings.Builder, text string) {
+	for {
+		i := strings.IndexByte(text, '$')
+		if i < 0 {
+			b.WriteString(text)
+			return
+		}
+		b.WriteString(text[:i])
+		text = text[i+1:]
+
+		switch {
+		case strings.HasPrefix(text, "?"):
+			b.WriteString(strconv.Itoa(e.lastStatus))
+			text = text[1:]
+
+		case strings.HasPrefix(text, "#"):
+			b.WriteString(strconv.Itoa(max(len(e.params)-1, 0)))
+			text = text[1:]
+
+		case strings.HasPrefix(text, "@"), strings.HasPrefix(text, "*"):
+			b.WriteString(strings.Join(e.args(), " "))
+			text = text[1:]
+
+		case text != "" && isDigit(text[0]):
+			b.WriteString(e.param(int(text[0] - '0')))
+			text = text[1:]
+
+		case strings.HasPrefix(text, "{"):
+			end := strings.IndexByte(text, '}')
+			if end < 0 {
+				b.WriteByte('$')
+				continue
+			}
+			name := text[1:end]
+			if n, err := strconv.Atoi(name); err == nil && n >= 0 {
+				b.WriteString(e.param(n))
+			} else if isValidName(name) {
+				b.WriteString(e.vars[name])
+			} else {
+				b.WriteByte('$')
+				continue
+			}
+			text = text[end+1:]
+
+		default:
+			n := nameLen(text)
+			if n == 0 {
+				b.WriteByte('$')
+				continue
+			}
+			b.WriteString(e.vars[text[:n]])
+			text = text[n:]
+		}
+	}
+}
+
+// param returns the positional parameter n, or "" if it is unset.
+func (e *expander) param(n int) string {
+	if n >= len(e.params) {
+		return ""
+	}
+	return e.params[n]
+}
+
+// args returns the positional parameters after $0.
+func (e *expander) args() []string {
+	if len(e.params) == 0 {
+		return nil
+	}
+	return e.params[1:]
+}
+
+// nameLen returns the length of the variable name at the start of s.
+func nameLen(s string) int {
+	for i, r := range s {
+		isAlpha := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isAlpha && (!isDigit || i == 0) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// isValidName reports whether s is a valid variable name: a letter or
+// underscore followed by letters, digits and underscores.
+func isValidName(s string) bool {
+	return s != "" && nameLen(s) == len(s)
+}
@@ -0,0 +1,133 @@
+package termui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpander_expand(t *testing.T) {
+	exp := &expander{
+		vars: map[string]string{
+			"HOME":  "/home/guest",
+			"USER":  "guest",
+			"EMPTY": "",
+		},
+		params:     []string{"script.sh", "a", "b c"},
+		lastStatus: 3,
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"variable", "$USER", "guest"},
+		{"braced variable", "${USER}name", "guestname"},
+		{"variable followed by text", "$USER.txt", "guest.txt"},
+		{"unset variable", "a${MISSING}b", "ab"},
+		{"empty variable", "$EMPTY", ""},
+		{"last status", "$?", "3"},
+		{"double quoted", `"$USER and $?"`, "guest and 3"},
+		{"single quoted", `'$USER'`, "$USER"},
+		{"escaped", `\$USER`, "$USER"},
+		{"escaped in double quotes", `"\$USER"`, "$USER"},
+		{"lone dollar", "$", "$"},
+		{"positional parameter", "$1", "a"},
+		{"script name", "$0", "script.sh"},
+		{"unset positional parameter", "$3", ""},
+		{"braced positional parameter", "${2}x", "b cx"},
+		{"parameter count", "$#", "2"},
+		{"all parameters", `"$@"`, "a b c"},
+		{"all parameters joined", "$*", "a b c"},
+		{"unterminated brace", "${USER", "${USER"},
+		{"invalid braced name", "${1A}", "${1A}"},
+		{"tilde", "~", "/home/guest"},
+		{"tilde path", "~/notes.txt", "/home/guest/notes.txt"},
+		{"quoted tilde", `"~"`, "~"},
+		{"tilde not at start", "a~", "a~"},
+		{"tilde user", "~zorcal", "~zorcal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Parse("echo " + tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v, want nil", tt.line, err)
+			}
+
+			w := list[0].Pipeline[0].Args[1]
+			if got := exp.expand(w); got != tt.want {
+				t.Errorf("expand(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpander_fields(t *testing.T) {
+	tfs, _ := setupTest()
+	exp := &expander{
+		vars:   DefaultEnv(),
+		params: []string{"script.sh", "a b", "*.md"},
+		fsys:   tfs,
+		dir:    "home/zorcal",
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"relative pattern", "projects/*.md", []string{"projects/test-repo.md"}},
+		{"star", "projects/*", []string{"projects/app.js", "projects/test-repo.md"}},
+		{"question mark", "projects/app.j?", []string{"projects/app.js"}},
+		{"bracket", "projects/[at]*", []string{"projects/app.js", "projects/test-repo.md"}},
+		{"absolute pattern", "/home/*", []string{"/home/guest", "/home/zorcal"}},
+		{"root pattern", "/h*", []string{"/home"}},
+		{"tilde pattern", "~/*.txt", []string{"/home/guest/welcome.txt"}},
+		{"parent pattern", "../g*", []string{"../guest"}},
+		{"pattern in directory element", "/home/*/welcome.txt", []string{"/home/guest/welcome.txt"}},
+		{"directories only", "*/", []string{"projects/"}},
+		{"hidden files excluded", "*", []string{"projects"}},
+		{"hidden files with leading dot", ".*", []string{".secret.txt"}},
+		{"no match keeps literal", "projects/*.go", []string{"projects/*.go"}},
+		{"quoted pattern is literal", `"projects/*"`, []string{"projects/*"}},
+		{"escaped pattern is literal", `projects/\*`, []string{"projects/*"}},
+		{"quoted part of pattern", `"proj"*`, []string{"projects"}},
+		{"bad pattern keeps literal", "projects/[a", []string{"projects/[a"}},
+		{"not a pattern", "projects", []string{"projects"}},
+		{"positional parameters", "$@", []string{"a b", "*.md"}},
+		{"quoted positional parameters", `"$@"`, []string{"a b", "*.md"}},
+		{"joined positional parameters", `"$*"`, []string{"a b *.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Parse("echo " + tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v, want nil", tt.line, err)
+			}
+
+			w := list[0].Pipeline[0].Args[1]
+			if got := exp.fields(w); !slices.Equal(got, tt.want) {
+				t.Errorf("fields(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_exec_glob(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	sessMgr.SetCurrentDir(sessionID, "home/zorcal")
+
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+	out, status := NewRegistry().Exec(env, "touch projects/new.md; cat projects/*.md | cat; echo projects/*.md")
+	if status != 0 {
+		t.Errorf("Exec(env, ...) status = %d, want 0", status)
+	}
+
+	want := "# test-repo"
+	if got := out.String(); !strings.HasPrefix(got, want) || !strings.HasSuffix(got, "projects/new.md projects/test-repo.md\n") {
+		t.Errorf("Exec(env, ...) output = %q, want prefix %q and both files echoed", got, want)
+	}
+}
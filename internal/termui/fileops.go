@@ -0,0 +1,319 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// MakeDirectory creates the directories given as arguments. With -p, missing
+// parent directories are created as well and existing directories are not an
+// error. Errors are returned joined, each as *ArgError carrying the directory
+// the user attempted to create.
+// Possible errors: ErrMissingArgument, ErrFileExists, ErrFileNotFound,
+// ErrNotDirectory, ErrQuotaExceeded, ErrInvalidFlag.
+func MakeDirectory(env *Env, args []string) error {
+	var opts mkdirOptions
+	flagSet := newMkdirFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	remaining := flagSet.Args()
+	if len(remaining) == 0 {
+		return ErrMissingArgument
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+
+	var errs []error
+	for _, arg := range remaining {
+		dirPath := resolvePath(currDir, arg)
+
+		var err error
+		if opts.parents {
+			err = env.FS.MkdirAll(dirPath)
+		} else {
+			err = env.FS.Mkdir(fsPath(dirPath))
+		}
+		if err != nil {
+			errs = append(errs, &ArgError{Arg: arg, Err: fmt.Errorf("make directory %q: %w", dirPath, mapFSErr(err))})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// mkdirOptions holds the flags accepted by mkdir.
+type mkdirOptions struct {
+	parents bool
+}
+
+func newMkdirFlagSet(opts *mkdirOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.parents, "parents", 'p', false, "make parent directories as needed, no error if existing")
+	return flagSet
+}
+
+// TouchFile updates the modification time of the files given as arguments,
+// creating empty files for those that do not exist. Errors are returned
+// joined, each as *ArgError carrying the file the user attempted to touch.
+// Possible errors: ErrMissingArgument, ErrFileNotFound, ErrNotDirectory,
+// ErrQuotaExceeded.
+func TouchFile(env *Env, args []string) error {
+	if len(args) == 0 {
+		return ErrMissingArgument
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+
+	var errs []error
+	for _, arg := range args {
+		filePath := resolvePath(currDir, arg)
+		if err := env.FS.Touch(fsPath(filePath)); err != nil {
+			errs = append(errs, &ArgError{Arg: arg, Err: fmt.Errorf("touch %q: %w", filePath, mapFSErr(err))})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RemoveFile removes the files given as arguments. Directories are only
+// removed with -r, along with everything they contain. With -f, files that do
+// not exist are ignored. Files of the shared filesystem cannot be removed.
+// Errors are returned joined, each as *ArgError carrying the file the user
+// attempted to remove.
+// Possible errors: ErrMissingArgument, ErrFileNotFound, ErrIsDirectory,
+// ErrAccessDenied, ErrInvalidFlag.
+func RemoveFile(env *Env, args []string) error {
+	var opts rmOptions
+	flagSet := newRmFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	remaining := flagSet.Args()
+	if len(remaining) == 0 && !opts.force {
+		return ErrMissingArgument
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+
+	var errs []error
+	for _, arg := range remaining {
+		filePath := resolvePath(currDir, arg)
+		openPath := fsPath(filePath)
+
+		info, err := fs.Stat(env.FS, openPath)
+		if err != nil {
+			if opts.force && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			errs = append(errs, &ArgError{Arg: arg, Err: fmt.Errorf("stat %q: %w", openPath, mapFSErr(err))})
+			continue
+		}
+
+		if info.IsDir() && !opts.recursive {
+			errs = append(errs, &ArgError{Arg: arg, Err: ErrIsDirectory})
+			continue
+		}
+
+		if opts.recursive {
+			err = env.FS.RemoveAll(openPath)
+		} else {
+			err = env.FS.Remove(openPath)
+		}
+		if err != nil {
+			errs = append(errs, &ArgError{Arg: arg, Err: fmt.Errorf("remove %q: %w", openPath, mapFSErr(err))})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// rmOptions holds the flags accepted by rm.
+type rmOptions struct {
+	recursive bool
+	force     bool
+}
+
+func newRmFlagSet(opts *rmOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.recursive, "recursive", 'r', false, "remove directories and their contents recursively")
+	flagSet.BoolVar(&opts.force, "force", 'f', false, "ignore nonexistent files, never fail on a missing argument")
+	return flagSet
+}
+
+// MoveFile renames the source file to the destination, or moves one or more
+// sources into the destination directory. Files of the shared filesystem
+// cannot be moved. Errors are returned joined, each as *ArgError carrying the
+// source the user attempted to move.
+// Possible errors: ErrMissingArgument, ErrFileNotFound, ErrNotDirectory,
+// ErrFileExists, ErrIntoItself, ErrSameFile, ErrAccessDenied,
+// ErrQuotaExceeded, ErrInvalidFlag.
+func MoveFile(env *Env, args []string) error {
+	var opts mvOptions
+	flagSet := newMvFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	return transferFiles(env, flagSet.Args(), func(src, dst string) error {
+		if err := env.FS.Rename(src, dst); err != nil {
+			return fmt.Errorf("rename %q to %q: %w", src, dst, mapFSErr(err))
+		}
+		return nil
+	})
+}
+
+// mvOptions holds the flags accepted by mv.
+type mvOptions struct {
+	// force is accepted for compatibility: files are always replaced
+	// without asking.
+	force bool
+}
+
+func newMvFlagSet(opts *mvOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.force, "force", 'f', false, "do not prompt before overwriting")
+	return flagSet
+}
+
+// CopyFile copies the source file to the destination, or copies one or more
+// sources into the destination directory. Directories are only copied with
+// -r, along with everything they contain. Errors are returned joined, each as
+// *ArgError carrying the source the user attempted to copy.
+// Possible errors: ErrMissingArgument, ErrFileNotFound, ErrNotDirectory,
+// ErrIsDirectory, ErrIntoItself, ErrSameFile, ErrQuotaExceeded,
+// ErrInvalidFlag.
+func CopyFile(env *Env, args []string) error {
+	var opts cpOptions
+	flagSet := newCpFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	return transferFiles(env, flagSet.Args(), func(src, dst string) error {
+		info, err := fs.Stat(env.FS, src)
+		if err != nil {
+			return fmt.Errorf("stat %q: %w", src, mapFSErr(err))
+		}
+
+		if !info.IsDir() {
+			return copyFile(env, src, dst)
+		}
+
+		if !opts.recursive {
+			return ErrIsDirectory
+		}
+
+		return fs.WalkDir(env.FS, src, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("walk %q: %w", p, mapFSErr(err))
+			}
+
+			target := dst + strings.TrimPrefix(p, src)
+			if d.IsDir() {
+				if err := env.FS.MkdirAll(target); err != nil {
+					return fmt.Errorf("make directory %q: %w", target, mapFSErr(err))
+				}
+				return nil
+			}
+
+			return copyFile(env, p, target)
+		})
+	})
+}
+
+func copyFile(env *Env, src, dst string) error {
+	content, err := fs.ReadFile(env.FS, src)
+	if err != nil {
+		return fmt.Errorf("read file %q: %w", src, mapFSErr(err))
+	}
+
+	if err := env.FS.WriteFile(dst, content); err != nil {
+		return fmt.Errorf("write file %q: %w", dst, mapFSErr(err))
+	}
+
+	return nil
+}
+
+// cpOptions holds the flags accepted by cp.
+type cpOptions struct {
+	recursive bool
+}
+
+func newCpFlagSet(opts *cpOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.recursive, "recursive", 'r', false, "copy directories recursively")
+	return flagSet
+}
+
+// transferFiles resolves the source and destination arguments of mv and cp
+// and calls transfer with the filesystem path of every source and the path
+// it is transferred to. With more than one source, the destination must be
+// an existing directory the sources are transferred into.
+func transferFiles(env *Env, args []string, transfer func(src, dst string) error) error {
+	if len(args) < 2 {
+		return ErrMissingArgument
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+
+	sources, destArg := args[:len(args)-1], args[len(args)-1]
+	destPath := resolvePath(currDir, destArg)
+
+	destIsDir := false
+	if info, err := fs.Stat(env.FS, fsPath(destPath)); err == nil && info.IsDir() {
+		destIsDir = true
+	}
+
+	if len(sources) > 1 && !destIsDir {
+		return &ArgError{Arg: destArg, Err: ErrNotDirectory}
+	}
+
+	var errs []error
+	for _, src := range sources {
+		srcPath := resolvePath(currDir, src)
+
+		dst := destPath
+		if destIsDir {
+			dst = path.Join(destPath, path.Base("/"+srcPath))
+		}
+
+		if dst == srcPath && srcPath != "" {
+			err := ErrSameFile
+			if _, statErr := fs.Stat(env.FS, srcPath); statErr != nil {
+				err = mapFSErr(statErr)
+			}
+			errs = append(errs, &ArgError{Arg: src, Err: err})
+			continue
+		}
+		if srcPath == "" || strings.HasPrefix(dst, srcPath+"/") {
+			errs = append(errs, &ArgError{Arg: src, Err: ErrIntoItself})
+			continue
+		}
+
+		if err := transfer(srcPath, dst); err != nil {
+			errs = append(errs, &ArgError{Arg: src, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// fsPath returns the fs.FS name of a path returned by resolvePath.
+func fsPath(p string) string {
+	if p == "" {
+		return "."
+	}
+	return p
+}
@@ -0,0 +1,235 @@
+package termui
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFileCommands(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "mkdir",
+			line:       "mkdir docs && ls",
+			wantOutput: "app.js  docs/  test-repo.md\n",
+		},
+		{
+			name:       "mkdir existing",
+			line:       "mkdir docs; mkdir docs",
+			wantOutput: "mkdir: docs: File exists\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "mkdir missing parent",
+			line:       "mkdir a/b",
+			wantOutput: "mkdir: a/b: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "mkdir parents",
+			line:       "mkdir -p a/b/c && mkdir -p a/b && ls a/b",
+			wantOutput: "c/\n",
+		},
+		{
+			name:       "mkdir missing operand",
+			line:       "mkdir",
+			wantOutput: "mkdir: missing file argument\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "touch creates empty file",
+			line:       "touch new.txt && cat new.txt && ls",
+			wantOutput: "app.js  new.txt  test-repo.md\n",
+		},
+		{
+			name:       "touch keeps content",
+			line:       "touch app.js && cat app.js",
+			wantOutput: "console.log('hello world');\n\n**URL:** https://github.com/example/app-js",
+		},
+		{
+			name:       "rm file",
+			line:       "touch new.txt && rm new.txt && ls",
+			wantOutput: "app.js  test-repo.md\n",
+		},
+		{
+			name:       "rm base file",
+			line:       "rm app.js",
+			wantOutput: "rm: app.js: Permission denied\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "rm copied base file",
+			line:       "echo more >> app.js; rm app.js",
+			wantOutput: "rm: app.js: Permission denied\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "rm missing file",
+			line:       "rm missing.txt",
+			wantOutput: "rm: missing.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "rm force ignores missing file",
+			line:       "rm -f missing.txt",
+			wantOutput: "",
+		},
+		{
+			name:       "rm directory without recursive",
+			line:       "mkdir docs; rm docs",
+			wantOutput: "rm: docs: Is a directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "rm recursive",
+			line:       "mkdir -p docs/sub && touch docs/sub/a.txt && rm -rf docs missing && ls",
+			wantOutput: "app.js  test-repo.md\n",
+		},
+		{
+			name:       "rm recursive base directory",
+			line:       "rm -r /home/zorcal",
+			wantOutput: "rm: /home/zorcal: Permission denied\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "mv rename",
+			line:       "echo hi > a.txt && mv a.txt b.txt && cat b.txt && ls",
+			wantOutput: "hi\napp.js  b.txt  test-repo.md\n",
+		},
+		{
+			name:       "mv into directory",
+			line:       "mkdir docs; touch a.txt b.txt && mv a.txt b.txt docs && ls docs",
+			wantOutput: "a.txt  b.txt\n",
+		},
+		{
+			name:       "mv directory",
+			line:       "mkdir -p docs/sub && mv docs notes && ls notes",
+			wantOutput: "sub/\n",
+		},
+		{
+			name:       "mv base file",
+			line:       "mv app.js moved.js",
+			wantOutput: "mv: app.js: Permission denied\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "mv directory into itself",
+			line:       "mkdir docs; mv docs docs",
+			wantOutput: "mv: docs: cannot move or copy a directory into itself\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "mv file onto itself",
+			line:       "touch a.txt; mv a.txt ./a.txt",
+			wantOutput: "mv: a.txt: source and destination are the same file\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "mv force",
+			line:       "echo a > a.txt; echo b > b.txt; mv -f a.txt b.txt && cat b.txt && ls",
+			wantOutput: "a\napp.js  b.txt  test-repo.md\n",
+		},
+		{
+			name:       "mv end of flags",
+			line:       "touch ./-x.txt; mv -- -x.txt y.txt && ls",
+			wantOutput: "app.js  test-repo.md  y.txt\n",
+		},
+		{
+			name:       "mv invalid flag",
+			line:       "touch a.txt; mv -x a.txt b.txt",
+			wantOutput: "mv: invalid flag or option\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "mv several files to a file",
+			line:       "touch a.txt b.txt c.txt; mv a.txt b.txt c.txt",
+			wantOutput: "mv: c.txt: Not a directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "mv missing operand",
+			line:       "mv a.txt",
+			wantOutput: "mv: missing file argument\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "cp base file",
+			line:       "cp app.js copy.js && cat copy.js",
+			wantOutput: "console.log('hello world');\n\n**URL:** https://github.com/example/app-js",
+		},
+		{
+			name:       "cp into directory",
+			line:       "mkdir docs; cp app.js test-repo.md docs && ls docs",
+			wantOutput: "app.js  test-repo.md\n",
+		},
+		{
+			name:       "cp directory without recursive",
+			line:       "cp /home/zorcal /home/guest/copy",
+			wantOutput: "cp: /home/zorcal: Is a directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "cp recursive",
+			line:       "cp -r /home/zorcal/projects /home/guest/copy && ls /home/guest/copy",
+			wantOutput: "app.js  test-repo.md\n",
+		},
+		{
+			name:       "cp recursive into itself",
+			line:       "mkdir docs; cp -r docs docs/sub",
+			wantOutput: "cp: docs: cannot move or copy a directory into itself\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "cp file onto itself",
+			line:       "cp app.js app.js",
+			wantOutput: "cp: app.js: source and destination are the same file\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "cp file into its own directory",
+			line:       "cp app.js .",
+			wantOutput: "cp: app.js: source and destination are the same file\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "cp missing file",
+			line:       "cp missing.txt copy.txt",
+			wantOutput: "cp: missing.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRemoveFile_baseProtected(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	sessMgr.SetCurrentDir(sessionID, "home/guest")
+
+	_, gotErr := runCommand(tfs, sessMgr, sessionID, RemoveFile, []string{"-f", "welcome.txt"})
+	if !errors.Is(gotErr, ErrAccessDenied) {
+		t.Errorf("RemoveFile(env, %v) error = %v, want %v", []string{"-f", "welcome.txt"}, gotErr, ErrAccessDenied)
+	}
+	if got, want := argContext(gotErr), "welcome.txt"; got != want {
+		t.Errorf("RemoveFile(env, ...) arg context = %q, want %q", got, want)
+	}
+}
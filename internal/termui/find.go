@@ -0,0 +1,327 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Find writes the paths of the files under the directories given as
+// arguments that match an expression, the current directory if no directory
+// is given. Directories are searched recursively and the starting points are
+// included. The expression follows the directories and combines the tests
+// -name PATTERN, -type f|d, -size [+-]N[ckMG], -mtime [+-]N and -newer FILE
+// with -not (or !), -and (or -a, or nothing), -or (or -o) and parentheses,
+// like in GNU find. An empty expression matches every file. Errors are
+// returned joined, each as *ArgError carrying the argument that caused it.
+// Possible errors: ErrInvalidExpression, ErrUnknownPredicate,
+// ErrFileNotFound, ErrNotDirectory.
+func Find(env *Env, args []string) error {
+	paths := args
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") || arg == "!" || arg == "(" || arg == ")" {
+			paths = args[:i]
+			break
+		}
+	}
+
+	p := &findParser{env: env, args: args[len(paths):], now: time.Now()}
+	expr, err := p.parse()
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+
+	var errs []error
+	for _, arg := range paths {
+		root := fsPath(resolvePath(currDir, arg))
+
+		err := fs.WalkDir(env.FS, root, func(p string, d fs.DirEntry, err error) error {
+			name := arg
+			if p != root {
+				rel := strings.TrimPrefix(p, root+"/")
+				if root == "." {
+					rel = p
+				}
+				name = strings.TrimSuffix(arg, "/") + "/" + rel
+			}
+
+			if err != nil {
+				errs = append(errs, &ArgError{Arg: name, Err: fmt.Errorf("walk %q: %w", p, mapFSErr(err))})
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				errs = append(errs, &ArgError{Arg: name, Err: fmt.Errorf("stat %q: %w", p, mapFSErr(err))})
+				return nil
+			}
+
+			if expr(findFile{name: path.Base(name), info: info}) {
+				fmt.Fprintln(env.Stdout, name)
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, &ArgError{Arg: arg, Err: fmt.Errorf("walk %q: %w", root, mapFSErr(err))})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// findFile is a file tested by a find expression.
+type findFile struct {
+	// name is the base name of the file as given to or found by find.
+	name string
+	info fs.FileInfo
+}
+
+// findExpr reports whether a file matches a find expression.
+type findExpr func(f findFile) bool
+
+// findParser parses the expression of a find command line. The grammar, from
+// lowest to highest precedence, is:
+//
+//	or   = and { ( -o | -or ) and }
+//	and  = not { [ -a | -and ] not }
+//	not  = ( ! | -not ) not | "(" or ")" | test
+type findParser struct {
+	env  *Env
+	args []string
+	pos  int
+	// now is the time -mtime measures file ages from.
+	now time.Time
+}
+
+// parse parses the whole expression.
+func (p *findParser) parse() (findExpr, error) {
+	if len(p.args) == 0 {
+		return func(findFile) bool { return true }, nil
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.args) {
+		return nil, &ArgError{Arg: p.args[p.pos], Err: ErrInvalidExpression}
+	}
+
+	return expr, nil
+}
+
+func (p *findParser) parseOr() (findExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("-o", "-or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(f findFile) bool { return l(f) || right(f) }
+	}
+
+	return left, nil
+}
+
+func (p *findParser) parseAnd() (findExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if !p.accept("-a", "-and") {
+			// Adjacent expressions are joined by an implicit -and.
+			if next := p.peek(); next == "" || next == ")" || next == "-o" || next == "-or" {
+				return left, nil
+			}
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(f findFile) bool { return l(f) && right(f) }
+	}
+}
+
+func (p *findParser) parseNot() (findExpr, error) {
+	if p.pos >= len(p.args) {
+		return nil, &ArgError{Arg: p.args[len(p.args)-1], Err: ErrInvalidExpression}
+	}
+
+	tok := p.next()
+	switch tok {
+	case "!", "-not":
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(f findFile) bool { return !expr(f) }, nil
+
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, &ArgError{Arg: "(", Err: ErrInvalidExpression}
+		}
+		return expr, nil
+
+	default:
+		return p.parseTest(tok)
+	}
+}
+
+// parseTest parses the test tok and its argument.
+func (p *findParser) parseTest(tok string) (findExpr, error) {
+	switch tok {
+	case "-name", "-type", "-size", "-mtime", "-newer":
+	case ")", "-o", "-or", "-a", "-and":
+		return nil, &ArgError{Arg: tok, Err: ErrInvalidExpression}
+	default:
+		if strings.HasPrefix(tok, "-") {
+			return nil, &ArgError{Arg: tok, Err: ErrUnknownPredicate}
+		}
+		// A path after the expression has started.
+		return nil, &ArgError{Arg: tok, Err: ErrInvalidExpression}
+	}
+
+	if p.pos >= len(p.args) {
+		return nil, &ArgError{Arg: tok, Err: fmt.Errorf("%w: missing argument", ErrInvalidExpression)}
+	}
+	arg := p.next()
+	invalid := &ArgError{Arg: tok + " " + arg, Err: ErrInvalidExpression}
+
+	switch tok {
+	case "-name":
+		if _, err := path.Match(arg, ""); err != nil {
+			return nil, invalid
+		}
+		return func(f findFile) bool {
+			ok, _ := path.Match(arg, f.name)
+			return ok
+		}, nil
+
+	case "-type":
+		switch arg {
+		case "f":
+			return func(f findFile) bool { return f.info.Mode().IsRegular() }, nil
+		case "d":
+			return func(f findFile) bool { return f.info.IsDir() }, nil
+		}
+		return nil, invalid
+
+	case "-size":
+		cmp, n, unit := parseNumericArg(arg, "ckMG")
+		if cmp == 0 {
+			return nil, invalid
+		}
+		unitSize := map[string]int64{"": 512, "c": 1, "k": 1 << 10, "M": 1 << 20, "G": 1 << 30}[unit]
+		return func(f findFile) bool {
+			// Sizes are rounded up to whole units, like in GNU find.
+			size := (f.info.Size() + unitSize - 1) / unitSize
+			return compareNumeric(cmp, size, n)
+		}, nil
+
+	case "-mtime":
+		cmp, n, _ := parseNumericArg(arg, "")
+		if cmp == 0 {
+			return nil, invalid
+		}
+		return func(f findFile) bool {
+			days := int64(p.now.Sub(f.info.ModTime()) / (24 * time.Hour))
+			return compareNumeric(cmp, days, n)
+		}, nil
+
+	default: // -newer
+		currDir := p.env.Sessions.GetCurrentDir(p.env.SessionID)
+		openPath := fsPath(resolvePath(currDir, arg))
+		info, err := fs.Stat(p.env.FS, openPath)
+		if err != nil {
+			return nil, &ArgError{Arg: arg, Err: fmt.Errorf("stat %q: %w", openPath, mapFSErr(err))}
+		}
+		modTime := info.ModTime()
+		return func(f findFile) bool { return f.info.ModTime().After(modTime) }, nil
+	}
+}
+
+// parseNumericArg parses a numeric test argument of the form [+-]N[unit],
+// where unit is one of the characters of units. cmp is '+', '-' or '=', or 0
+// if arg is invalid.
+func parseNumericArg(arg, units string) (cmp byte, n int64, unit string) {
+	cmp = '='
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		cmp, arg = arg[0], arg[1:]
+	}
+
+	if arg != "" && strings.Contains(units, arg[len(arg)-1:]) {
+		unit, arg = arg[len(arg)-1:], arg[:len(arg)-1]
+	}
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, 0, ""
+	}
+
+	return cmp, n, unit
+}
+
+// compareNumeric reports whether v is greater than, less than or equal to n
+// as cmp requests.
+func compareNumeric(cmp byte, v, n int64) bool {
+	switch cmp {
+	case '+':
+		return v > n
+	case '-':
+		return v < n
+	default:
+		return v == n
+	}
+}
+
+// accept consumes the next argument if it is one of toks.
+func (p *findParser) accept(toks ...string) bool {
+	for _, tok := range toks {
+		if p.peek() == tok {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// peek returns the next argument without consuming it, or "" at the end.
+func (p *findParser) peek() string {
+	if p.pos >= len(p.args) {
+		return ""
+	}
+	return p.args[p.pos]
+}
+
+// next consumes and returns the next argument, or "" at the end.
+func (p *findParser) next() string {
+	tok := p.peek()
+	if p.pos < len(p.args) {
+		p.pos++
+	}
+	return tok
+}
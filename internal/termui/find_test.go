@@ -0,0 +1,140 @@
+package termui
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "current directory",
+			line:       "find",
+			wantOutput: ".\n./app.js\n./test-repo.md\n",
+		},
+		{
+			name:       "path",
+			line:       "find /home/zorcal",
+			wantOutput: "/home/zorcal\n/home/zorcal/.secret.txt\n/home/zorcal/projects\n/home/zorcal/projects/app.js\n/home/zorcal/projects/test-repo.md\n",
+		},
+		{
+			name:       "several paths",
+			line:       "find app.js ../projects/",
+			wantOutput: "app.js\n../projects/\n../projects/app.js\n../projects/test-repo.md\n",
+		},
+		{
+			name:       "name",
+			line:       "find / -name '*.md'",
+			wantOutput: "/home/zorcal/projects/test-repo.md\n",
+		},
+		{
+			name:       "type",
+			line:       "find /home -type d",
+			wantOutput: "/home\n/home/guest\n/home/zorcal\n/home/zorcal/projects\n",
+		},
+		{
+			name:       "implicit and",
+			line:       "find /home/zorcal -type f -name '.*'",
+			wantOutput: "/home/zorcal/.secret.txt\n",
+		},
+		{
+			name:       "or",
+			line:       "find -name '*.js' -o -name '*.md'",
+			wantOutput: "./app.js\n./test-repo.md\n",
+		},
+		{
+			name:       "not",
+			line:       "find ! -name '*.js' -not -type d",
+			wantOutput: "./test-repo.md\n",
+		},
+		{
+			name:       "and binds tighter than or",
+			line:       "find -type d -o -name '*.js' -a -type f",
+			wantOutput: ".\n./app.js\n",
+		},
+		{
+			name:       "parentheses",
+			line:       "find \\( -type d -o -name '*.js' \\) -a -type f",
+			wantOutput: "./app.js\n",
+		},
+		{
+			name:       "size in bytes",
+			line:       "echo -n 12345 > five; echo -n 123456 > six; find -size 5c -o -size +5c -type f -name s*",
+			wantOutput: "./five\n./six\n",
+		},
+		{
+			name:       "size in blocks rounds up",
+			line:       "touch empty; echo x > small; find -type f \\( -size -1 -o -size 1 -name small \\)",
+			wantOutput: "./empty\n./small\n",
+		},
+		{
+			name:       "mtime",
+			line:       "find -mtime 0 -type f",
+			wantOutput: "./app.js\n./test-repo.md\n",
+		},
+		{
+			name:       "mtime older",
+			line:       "find -mtime +0",
+			wantOutput: "",
+		},
+		{
+			name:       "newer",
+			line:       "touch new.txt; find -newer app.js",
+			wantOutput: "./new.txt\n",
+		},
+		{
+			name:       "missing path",
+			line:       "find missing app.js",
+			wantOutput: "app.js\nfind: missing: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "unknown predicate",
+			line:       "find -nam app.js",
+			wantOutput: "find: -nam: unknown predicate\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "missing argument",
+			line:       "find -name",
+			wantOutput: "find: -name: invalid expression\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "invalid type",
+			line:       "find -type x",
+			wantOutput: "find: -type x: invalid expression\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "unbalanced parentheses",
+			line:       "find \\( -type d",
+			wantOutput: "find: (: invalid expression\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "path after expression",
+			line:       "find -type d app.js",
+			wantOutput: "find: app.js: invalid expression\n",
+			wantStatus: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// CSS classes of the parts of grep output highlighted in the terminal.
+const (
+	grepMatchClass = "grep-match"
+	grepFileClass  = "grep-file"
+	grepLineClass  = "grep-line"
+)
+
+// Grep writes the lines of the files given as arguments that match a regular
+// expression, or of standard input if there are none. Matches are highlighted
+// when written to the terminal. With -r, directories are searched
+// recursively, the current directory if no file is given. Lines are prefixed
+// with the file name when more than one file is searched. It exits with
+// status 1 if no line is selected. Errors are returned joined, each as
+// *ArgError carrying the file or pattern that caused it.
+// Possible errors: ErrMissingPattern, ErrInvalidPattern, ErrFileNotFound,
+// ErrIsDirectory, ErrInvalidFlag.
+func Grep(env *Env, args []string) error {
+	var opts grepOptions
+	flagSet := newGrepFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	remaining := flagSet.Args()
+	if len(remaining) == 0 {
+		return ErrMissingPattern
+	}
+
+	pattern, files := remaining[0], remaining[1:]
+	if opts.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return &ArgError{Arg: remaining[0], Err: fmt.Errorf("%w: %v", ErrInvalidPattern, err)}
+	}
+
+	g := &grepper{env: env, opts: opts, re: re}
+
+	switch {
+	case len(files) == 0 && opts.recursive:
+		g.withNames = true
+		currDir := env.Sessions.GetCurrentDir(env.SessionID)
+		g.searchDir("", fsPath(currDir))
+	case len(files) == 0:
+		content, err := io.ReadAll(env.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		g.search("(standard input)", string(content))
+	default:
+		g.withNames = len(files) > 1 || opts.recursive
+		for _, file := range files {
+			g.searchArg(file)
+		}
+	}
+
+	if len(g.errs) > 0 {
+		return errors.Join(g.errs...)
+	}
+	if !g.selected {
+		return ExitStatus(1)
+	}
+
+	return nil
+}
+
+// grepOptions holds the flags accepted by grep.
+type grepOptions struct {
+	ignoreCase bool
+	lineNumber bool
+	invert     bool
+	count      bool
+	filesOnly  bool
+	recursive  bool
+}
+
+func newGrepFlagSet(opts *grepOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.ignoreCase, "ignore-case", 'i', false, "ignore case distinctions")
+	flagSet.BoolVar(&opts.lineNumber, "line-number", 'n', false, "prefix each line with its line number")
+	flagSet.BoolVar(&opts.invert, "invert-match", 'v', false, "select non-matching lines")
+	flagSet.BoolVar(&opts.count, "count", 'c', false, "print only a count of selected lines per file")
+	flagSet.BoolVar(&opts.filesOnly, "files-with-matches", 'l', false, "print only names of files with selected lines")
+	flagSet.BoolVar(&opts.recursive, "recursive", 'r', false, "search directories recursively")
+	return flagSet
+}
+
+// grepper searches files for the lines selected by a grep invocation.
+type grepper struct {
+	env  *Env
+	opts grepOptions
+	re   *regexp.Regexp
+	// withNames reports whether output lines are prefixed with file names.
+	withNames bool
+
+	selected bool
+	errs     []error
+}
+
+// searchArg searches the file or, with -r, directory named by arg.
+func (g *grepper) searchArg(arg string) {
+	if arg == "-" {
+		content, err := io.ReadAll(g.env.Stdin)
+		if err != nil {
+			g.errs = append(g.errs, fmt.Errorf("read stdin: %w", err))
+			return
+		}
+		g.search("(standard input)", string(content))
+		return
+	}
+
+	currDir := g.env.Sessions.GetCurrentDir(g.env.SessionID)
+	openPath := fsPath(resolvePath(currDir, arg))
+
+	info, err := fs.Stat(g.env.FS, openPath)
+	if err != nil {
+		g.errs = append(g.errs, &ArgError{Arg: arg, Err: fmt.Errorf("stat %q: %w", openPath, mapFSErr(err))})
+		return
+	}
+
+	if !info.IsDir() {
+		g.searchFile(arg, openPath)
+		return
+	}
+
+	if !g.opts.recursive {
+		g.errs = append(g.errs, &ArgError{Arg: arg, Err: ErrIsDirectory})
+		return
+	}
+
+	g.searchDir(strings.TrimSuffix(arg, "/")+"/", openPath)
+}
+
+// searchDir searches the files under root, naming them by their path relative
+// to root with prefix prepended.
+func (g *grepper) searchDir(prefix, root string) {
+	fs.WalkDir(g.env.FS, root, func(p string, d fs.DirEntry, err error) error {
+		name := prefix + p
+		if root != "." {
+			name = prefix + strings.TrimPrefix(p, root+"/")
+		}
+
+		if err != nil {
+			g.errs = append(g.errs, &ArgError{Arg: name, Err: fmt.Errorf("walk %q: %w", p, mapFSErr(err))})
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		g.searchFile(name, p)
+		return nil
+	})
+}
+
+// searchFile searches the file at openPath, naming it name in the output.
+func (g *grepper) searchFile(name, openPath string) {
+	content, err := fs.ReadFile(g.env.FS, openPath)
+	if err != nil {
+		g.errs = append(g.errs, &ArgError{Arg: name, Err: fmt.Errorf("read file %q: %w", openPath, mapFSErr(err))})
+		return
+	}
+
+	g.search(name, string(content))
+}
+
+// search writes the selected lines of content, a file named name.
+func (g *grepper) search(name, content string) {
+	out := new(styledText)
+	count := 0
+	for i, line := range splitLines(content) {
+		if g.re.MatchString(line) == g.opts.invert {
+			continue
+		}
+
+		count++
+		if g.opts.count || g.opts.filesOnly {
+			continue
+		}
+
+		if g.withNames {
+			out.Element("span", grepFileClass, name)
+			out.WriteString(":")
+		}
+		if g.opts.lineNumber {
+			out.Element("span", grepLineClass, strconv.Itoa(i+1))
+			out.WriteString(":")
+		}
+		g.highlight(out, line)
+		out.WriteString("\n")
+	}
+
+	switch {
+	case g.opts.filesOnly:
+		if count > 0 {
+			out.Element("span", grepFileClass, name)
+			out.WriteString("\n")
+		}
+	case g.opts.count:
+		if g.withNames {
+			out.Element("span", grepFileClass, name)
+			out.WriteString(":")
+		}
+		out.WriteString(strconv.Itoa(count) + "\n")
+	}
+
+	g.selected = g.selected || count > 0
+	writeStyled(g.env.Stdout, out)
+}
+
+// highlight writes line with the matches of the pattern highlighted. Lines
+// selected by -v have no matches.
+func (g *grepper) highlight(out *styledText, line string) {
+	if g.opts.invert {
+		out.WriteString(line)
+		return
+	}
+
+	last := 0
+	for _, m := range g.re.FindAllStringIndex(line, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+		out.WriteString(line[last:m[0]])
+		out.Element("span", grepMatchClass, line[m[0]:m[1]])
+		last = m[1]
+	}
+	out.WriteString(line[last:])
+}
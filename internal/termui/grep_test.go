@@ -0,0 +1,159 @@
+package termui
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "single file",
+			line:       "grep URL test-repo.md",
+			wantOutput: "**URL:** https://github.com/test/test-repo\n",
+		},
+		{
+			name:       "regular expression",
+			line:       "grep '^\\*\\*(Language|Stars)' test-repo.md",
+			wantOutput: "**Language:** Go\n**Stars:** 42\n",
+		},
+		{
+			name:       "several files",
+			line:       "grep -n URL app.js test-repo.md",
+			wantOutput: "app.js:3:**URL:** https://github.com/example/app-js\ntest-repo.md:7:**URL:** https://github.com/test/test-repo\n",
+		},
+		{
+			name:       "ignore case",
+			line:       "grep -i 'language:' test-repo.md",
+			wantOutput: "**Language:** Go\n",
+		},
+		{
+			name:       "case sensitive by default",
+			line:       "grep 'language:' test-repo.md",
+			wantOutput: "",
+			wantStatus: 1,
+		},
+		{
+			name:       "invert match",
+			line:       "grep -v '[a-z*]' test-repo.md",
+			wantOutput: "\n\n",
+		},
+		{
+			name:       "count",
+			line:       "grep -c '^\\*\\*' app.js test-repo.md",
+			wantOutput: "app.js:1\ntest-repo.md:4\n",
+		},
+		{
+			name:       "files with matches",
+			line:       "grep -l hello app.js test-repo.md",
+			wantOutput: "app.js\n",
+		},
+		{
+			name:       "recursive",
+			line:       "grep -rl 'github.com/test' /home/zorcal",
+			wantOutput: "/home/zorcal/projects/test-repo.md\n",
+		},
+		{
+			name:       "recursive current directory",
+			line:       "grep -ri 'GO$'",
+			wantOutput: "test-repo.md:**Language:** Go\n",
+		},
+		{
+			name:       "standard input",
+			line:       "cat test-repo.md | grep -n Stars",
+			wantOutput: "6:**Stars:** 42\n",
+		},
+		{
+			name:       "directory without recursive",
+			line:       "grep hello /home/zorcal/projects",
+			wantOutput: "grep: /home/zorcal/projects: Is a directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "missing file",
+			line:       "grep hello missing.txt app.js",
+			wantOutput: "app.js:console.log('hello world');\ngrep: missing.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "invalid pattern",
+			line:       "grep 'a(' app.js",
+			wantOutput: "grep: a(: invalid regular expression\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "missing pattern",
+			line:       "grep",
+			wantOutput: "grep: missing pattern\n",
+			wantStatus: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestGrep_highlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantHTML template.HTML
+	}{
+		{
+			name:     "matches",
+			line:     "grep -n 'l+o' app.js",
+			wantHTML: `<span class="grep-line">1</span>:console.<span class="grep-match">lo</span>g(&#39;he<span class="grep-match">llo</span> world&#39;);` + "\n",
+		},
+		{
+			name:     "file names",
+			line:     "grep -l hello app.js test-repo.md",
+			wantHTML: `<span class="grep-file">app.js</span>` + "\n",
+		},
+		{
+			name:     "inverted lines have no matches",
+			line:     "grep -v '^$' app.js | grep -v URL",
+			wantHTML: "console.log(&#39;hello world&#39;);\n",
+		},
+		{
+			name:     "piped output is plain",
+			line:     "grep hello app.js | cat",
+			wantHTML: "console.log(&#39;hello world&#39;);\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, _ := NewRegistry().Exec(env, tt.line)
+
+			chunks := out.Chunks()
+			if len(chunks) != 1 {
+				t.Fatalf("Exec(env, %q) chunks = %q, want 1 chunk", tt.line, chunks)
+			}
+			if got := chunks[0].HTML; got != tt.wantHTML {
+				t.Errorf("Exec(env, %q) HTML = %q, want %q", tt.line, got, tt.wantHTML)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package termui
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CSS classes of the tokens of highlighted source code.
+const (
+	tokKeywordClass  = "tok-keyword"
+	tokStringClass   = "tok-string"
+	tokNumberClass   = "tok-number"
+	tokCommentClass  = "tok-comment"
+	tokKeyClass      = "tok-key"
+	tokVariableClass = "tok-variable"
+)
+
+// lexRule matches a kind of token of a language. Tokens of a rule without a
+// class are words, highlighted if they are keywords.
+type lexRule struct {
+	class   string
+	pattern string
+}
+
+// language is the lexical syntax of a language cat highlights.
+type language struct {
+	// pattern matches any token, with one group for each rule.
+	pattern  *regexp.Regexp
+	classes  []string
+	keywords map[string]bool
+	// keys reports whether strings and words followed by a colon are keys,
+	// like in JSON and YAML.
+	keys bool
+}
+
+func newLanguage(keywords string, keys bool, rules ...lexRule) *language {
+	lang := &language{keywords: make(map[string]bool), keys: keys}
+	for _, kw := range strings.Fields(keywords) {
+		lang.keywords[kw] = true
+	}
+
+	patterns := make([]string, len(rules))
+	for i, r := range rules {
+		patterns[i] = "(" + r.pattern + ")"
+		lang.classes = append(lang.classes, r.class)
+	}
+	lang.pattern = regexp.MustCompile(strings.Join(patterns, "|"))
+
+	return lang
+}
+
+// Rules shared by several languages.
+var (
+	cComments = lexRule{tokCommentClass, `//[^\n]*|/\*(?s:.*?)(?:\*/|$)`}
+	// hashComments start with a # at the start of a line or after a blank.
+	hashComments  = lexRule{tokCommentClass, `(?m:^|[ \t])#[^\n]*`}
+	quotedStrings = lexRule{tokStringClass, `"(?:[^"\\\n]|\\.)*"?|'(?:[^'\\\n]|\\.)*'?`}
+	numbers       = lexRule{tokNumberClass, `-?\b(?:0[xXbBoO][0-9a-fA-F_]+|\d[\d_]*(?:\.\d+)?(?:[eE][+-]?\d+)?)\b`}
+	words         = lexRule{"", `\b[A-Za-z_]\w*`}
+)
+
+var (
+	goLanguage = newLanguage(
+		"break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var true false nil iota",
+		false,
+		cComments, quotedStrings, lexRule{tokStringClass, "`[^`]*`?"}, numbers, words,
+	)
+	jsLanguage = newLanguage(
+		"async await break case catch class const continue debugger default delete do else export extends finally for function if import in instanceof let new of return super switch this throw try typeof var void while with yield true false null undefined",
+		false,
+		cComments, quotedStrings, lexRule{tokStringClass, "`(?:[^`\\\\]|\\\\.)*`?"}, numbers, words,
+	)
+	jsonLanguage = newLanguage(
+		"true false null",
+		true,
+		quotedStrings, numbers, words,
+	)
+	yamlLanguage = newLanguage(
+		"true false null yes no on off",
+		true,
+		hashComments, quotedStrings, numbers, lexRule{"", `\b[A-Za-z_][\w.-]*`},
+	)
+	shellLanguage = newLanguage(
+		"if then else elif fi for while until do done case esac in function return local export select break continue",
+		false,
+		lexRule{tokVariableClass, `\$(?:\{[^}\n]*\}|[A-Za-z_]\w*|[0-9?#@*$!-])`},
+		hashComments,
+		lexRule{tokStringClass, `"(?:[^"\\]|\\.)*"?|'[^']*'?`},
+		numbers,
+		lexRule{"", `\b[A-Za-z_][\w-]*`},
+	)
+)
+
+// languages are the highlighted languages by file extension and by the name
+// of the language of markdown code blocks.
+var languages = map[string]*language{
+	"go":         goLanguage,
+	"js":         jsLanguage,
+	"javascript": jsLanguage,
+	"json":       jsonLanguage,
+	"yaml":       yamlLanguage,
+	"yml":        yamlLanguage,
+	"sh":         shellLanguage,
+	"bash":       shellLanguage,
+	"shell":      shellLanguage,
+}
+
+// languageOf returns the language of the file name, or nil if it is not
+// highlighted.
+func languageOf(name string) *language {
+	return languages[strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))]
+}
+
+// keyColon matches the colon after a key, and wordKeyColon the colon after a
+// key that is a word, which is followed by a space as in YAML.
+var (
+	keyColon     = regexp.MustCompile(`^\s*:`)
+	wordKeyColon = regexp.MustCompile(`^:(?:\s|$)`)
+)
+
+// highlight returns source with its tokens highlighted.
+func highlight(lang *language, source string) *styledText {
+	out := new(styledText)
+	last := 0
+	for _, m := range lang.pattern.FindAllStringSubmatchIndex(source, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+
+		// The rule of the token is the group that matched.
+		rule := 0
+		for m[2+2*rule] < 0 {
+			rule++
+		}
+
+		token := source[m[0]:m[1]]
+		class := lang.classes[rule]
+		rest := source[m[1]:]
+		switch {
+		case lang.keys && class == tokStringClass && keyColon.MatchString(rest):
+			class = tokKeyClass
+		case class != "":
+		case lang.keys && wordKeyColon.MatchString(rest):
+			class = tokKeyClass
+		case lang.keywords[token]:
+			class = tokKeywordClass
+		}
+
+		out.WriteString(source[last:m[0]])
+		if class == "" {
+			out.WriteString(token)
+		} else {
+			out.Element("span", class, token)
+		}
+		last = m[1]
+	}
+	out.WriteString(source[last:])
+
+	return out
+}
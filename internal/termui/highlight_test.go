@@ -0,0 +1,132 @@
+package termui
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		source   string
+		wantHTML template.HTML
+	}{
+		{
+			name:   "go",
+			file:   "main.go",
+			source: "func f() int { // one\n\treturn 0x1F + len(`a\nb`) }",
+			wantHTML: `<span class="tok-keyword">func</span> f() int { <span class="tok-comment">// one</span>` + "\n" +
+				"\t" + `<span class="tok-keyword">return</span> <span class="tok-number">0x1F</span> + len(<span class="tok-string">` + "`a</span>\n" + `<span class="tok-string">b` + "`</span>) }",
+		},
+		{
+			name:   "javascript",
+			file:   "app.js",
+			source: "/* a\n */ const s = 'it\\'s' + 1.5e3;",
+			wantHTML: `<span class="tok-comment">/* a</span>` + "\n" + `<span class="tok-comment"> */</span> ` +
+				`<span class="tok-keyword">const</span> s = <span class="tok-string">&#39;it\&#39;s&#39;</span> + <span class="tok-number">1.5e3</span>;`,
+		},
+		{
+			name:   "json",
+			file:   "data.JSON",
+			source: `{"name": "zorcal", "stars": -42, "ok": true}`,
+			wantHTML: `{<span class="tok-key">&#34;name&#34;</span>: <span class="tok-string">&#34;zorcal&#34;</span>, ` +
+				`<span class="tok-key">&#34;stars&#34;</span>: <span class="tok-number">-42</span>, ` +
+				`<span class="tok-key">&#34;ok&#34;</span>: <span class="tok-keyword">true</span>}`,
+		},
+		{
+			name:   "yaml",
+			file:   "config.yaml",
+			source: "api-version: 2 # comment\nurl: https://example.com#top\nenabled: yes",
+			wantHTML: `<span class="tok-key">api-version</span>: <span class="tok-number">2</span><span class="tok-comment"> # comment</span>` + "\n" +
+				`<span class="tok-key">url</span>: https://example.com#top` + "\n" +
+				`<span class="tok-key">enabled</span>: <span class="tok-keyword">yes</span>`,
+		},
+		{
+			name:   "shell",
+			file:   "tour.sh",
+			source: "# tour\nif [ -n \"$1\" ]; then echo ${HOME}#x; fi",
+			wantHTML: `<span class="tok-comment"># tour</span>` + "\n" +
+				`<span class="tok-keyword">if</span> [ -n <span class="tok-string">&#34;$1&#34;</span> ]; <span class="tok-keyword">then</span> echo <span class="tok-variable">${HOME}</span>#x; <span class="tok-keyword">fi</span>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang := languageOf(tt.file)
+			if lang == nil {
+				t.Fatalf("languageOf(%q) = nil, want language", tt.file)
+			}
+
+			out := highlight(lang, tt.source)
+			if got := out.String(); got != tt.source {
+				t.Errorf("highlight(%q) text = %q, want %q", tt.source, got, tt.source)
+			}
+			if got := template.HTML(out.markup.String()); got != tt.wantHTML {
+				t.Errorf("highlight(%q) HTML = %q, want %q", tt.source, got, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func TestLanguageOf_unknown(t *testing.T) {
+	for _, name := range []string{"notes.txt", "Makefile", ".secret", "go"} {
+		if lang := languageOf(name); lang != nil {
+			t.Errorf("languageOf(%q) = %v, want nil", name, lang)
+		}
+	}
+}
+
+func TestCatFile_number(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantHTML   template.HTML
+	}{
+		{
+			name:       "files",
+			line:       "cat -n app.js test-repo.md | head -n 5",
+			wantOutput: "     1\tconsole.log('hello world');\n     2\t\n     3\t**URL:** https://github.com/example/app-js# test-repo\n     4\t\n     5\tA test repository\n",
+		},
+		{
+			name:       "standard input",
+			line:       "echo a b | cat -n",
+			wantOutput: "     1\ta b\n",
+			wantHTML:   `<span class="line-number">     1</span>` + "\ta b\n",
+		},
+		{
+			name:       "highlighted",
+			line:       "echo 'x := `a' > a.go; echo 'b`' >> a.go; cat -n a.go",
+			wantOutput: "     1\tx := `a\n     2\tb`\n",
+			wantHTML: `<span class="line-number">     1</span>` + "\tx := " + `<span class="tok-string">` + "`a</span>\n" +
+				`<span class="line-number">     2</span>` + "\t" + `<span class="tok-string">b` + "`</span>\n",
+		},
+		{
+			name:       "rendered markdown",
+			line:       "cat -n test-repo.md | tail -n 1; cat -n test-repo.md",
+			wantOutput: "     8\t**Last Updated:** 2024-01-01T00:00:00Z\n     1\ttest-repo\n     2\t\n     3\tA test repository\n     4\t\n     5\tLanguage: Go\n     6\tStars: 42\n     7\tURL: https://github.com/test/test-repo\n     8\tLast Updated: 2024-01-01T00:00:00Z\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != 0 {
+				t.Errorf("Exec(env, %q) status = %d, want 0", tt.line, status)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+			if tt.wantHTML == "" {
+				return
+			}
+			if chunks := out.Chunks(); len(chunks) != 1 || chunks[0].HTML != tt.wantHTML {
+				t.Errorf("Exec(env, %q) chunks = %q, want 1 chunk with HTML %q", tt.line, chunks, tt.wantHTML)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package termui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// expandHistory expands the history designators of line, like bash does
+// before parsing a command line. history holds the command lines run
+// before, oldest first, and numbered from 1. A designator refers to a
+// command line in history:
+//
+//	!!       the previous command line
+//	!n       command line n
+//	!-n      the command line n lines back
+//	!prefix  the last command line starting with prefix
+//
+// and is replaced by it. A line of the form ^old^new^, with the last ^
+// optional, runs the previous command line with the first old replaced by
+// new. A ! in single quotes, escaped with a backslash, or followed by a
+// blank, =, (, $ or " is left as it is. Errors are returned as *ArgError
+// carrying the designator.
+// Possible errors: ErrEventNotFound, ErrBadSubstitution.
+func expandHistory(line string, history []string) (string, error) {
+	if rest, ok := strings.CutPrefix(line, "^"); ok {
+		old, repl, _ := strings.Cut(rest, "^")
+		repl = strings.TrimSuffix(repl, "^")
+
+		if len(history) == 0 {
+			return "", &ArgError{Arg: line, Err: ErrEventNotFound}
+		}
+		prev := history[len(history)-1]
+		if old == "" || !strings.Contains(prev, old) {
+			return "", &ArgError{Arg: line, Err: ErrBadSubstitution}
+		}
+		return strings.Replace(prev, old, repl, 1), nil
+	}
+
+	var (
+		b                        strings.Builder
+		singleQuote, doubleQuote bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\'' && !doubleQuote:
+			singleQuote = !singleQuote
+		case c == '"' && !singleQuote:
+			doubleQuote = !doubleQuote
+		case c == '\\' && !singleQuote && i+1 < len(line):
+			b.WriteByte(c)
+			i++
+			c = line[i]
+		case c == '!' && !singleQuote:
+			designator := historyDesignator(line[i:])
+			if designator == "" {
+				break
+			}
+			event, err := historyEvent(designator, history)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(event)
+			i += len(designator) - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String(), nil
+}
+
+// historyDesignator returns the history designator at the start of s, which
+// starts with !, or "" if the ! does not start one.
+func historyDesignator(s string) string {
+	if len(s) < 2 {
+		return ""
+	}
+
+	switch c := s[1]; {
+	case c == '!':
+		return "!!"
+	case c == ' ' || c == '\t' || c == '=' || c == '(' || c == '$' || c == '"':
+		return ""
+	}
+
+	end := 1
+	if s[end] == '-' {
+		end++
+	}
+	if end < len(s) && isDigit(s[end]) {
+		for end < len(s) && isDigit(s[end]) {
+			end++
+		}
+		return s[:end]
+	}
+	for end < len(s) && !strings.ContainsRune(" \t;&|<>()'\"", rune(s[end])) {
+		end++
+	}
+	return s[:end]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// historyEvent returns the command line in history the designator refers
+// to.
+func historyEvent(designator string, history []string) (string, error) {
+	spec := designator[1:]
+
+	var i int
+	if spec == "!" {
+		i = len(history) - 1
+	} else if n, err := strconv.Atoi(spec); err == nil {
+		i = n - 1
+		if n < 0 {
+			i = len(history) + n
+		}
+	} else {
+		i = -1
+		for j := len(history) - 1; j >= 0; j-- {
+			if strings.HasPrefix(history[j], spec) {
+				i = j
+				break
+			}
+		}
+	}
+
+	if i < 0 || i >= len(history) {
+		return "", &ArgError{Arg: designator, Err: ErrEventNotFound}
+	}
+	return history[i], nil
+}
+
+type historyOptions struct {
+	clear bool
+}
+
+func newHistoryFlagSet(opts *historyOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.clear, "clear", 'c', false, "clear the history")
+	return flagSet
+}
+
+// History writes the command lines run in the session to standard output,
+// numbered from the oldest, or only the last N if an argument N is given.
+// With -c the history is cleared instead.
+// Possible errors: ErrNotNumeric, ErrTooManyArguments, ErrInvalidFlag.
+func History(env *Env, args []string) error {
+	var opts historyOptions
+	flagSet := newHistoryFlagSet(&opts)
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	if opts.clear {
+		env.Sessions.ClearHistory(env.SessionID)
+		return nil
+	}
+
+	history := env.Sessions.GetHistory(env.SessionID)
+	first := 0
+
+	switch args := flagSet.Args(); len(args) {
+	case 0:
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return &ArgError{Arg: args[0], Err: ErrNotNumeric}
+		}
+		first = max(len(history)-n, 0)
+	default:
+		return ErrTooManyArguments
+	}
+
+	for i := first; i < len(history); i++ {
+		if _, err := fmt.Fprintf(env.Stdout, "%5d  %s\n", i+1, history[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SearchHistory returns the distinct lines of history, the command lines run
+// oldest first, that contain query. Lines starting with query rank first,
+// then lines with query after a blank, then any other, each from the most
+// recent. An empty query matches nothing.
+func SearchHistory(history []string, query string) []string {
+	if query == "" {
+		return nil
+	}
+
+	var ranks [3][]string
+	seen := make(map[string]bool)
+	for i := len(history) - 1; i >= 0; i-- {
+		line := history[i]
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+
+		switch {
+		case strings.HasPrefix(line, query):
+			ranks[0] = append(ranks[0], line)
+		case strings.Contains(" "+line, " "+query):
+			ranks[1] = append(ranks[1], line)
+		case strings.Contains(line, query):
+			ranks[2] = append(ranks[2], line)
+		}
+	}
+
+	return slices.Concat(ranks[:]...)
+}
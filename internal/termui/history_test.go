@@ -0,0 +1,191 @@
+package termui
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestExpandHistory(t *testing.T) {
+	history := []string{"ls -l", "cd projects", "cat test-repo.md", "echo hi"}
+
+	tests := []struct {
+		name    string
+		line    string
+		want    string
+		wantErr error
+	}{
+		{name: "no designators", line: "ls -la", want: "ls -la"},
+		{name: "previous", line: "!!", want: "echo hi"},
+		{name: "previous in a line", line: "!! | wc -l && !!", want: "echo hi | wc -l && echo hi"},
+		{name: "number", line: "!2", want: "cd projects"},
+		{name: "number followed by text", line: "!1a", want: "ls -la"},
+		{name: "relative", line: "!-2", want: "cat test-repo.md"},
+		{name: "prefix", line: "!c", want: "cat test-repo.md"},
+		{name: "prefix before an operator", line: "!cd;pwd", want: "cd projects;pwd"},
+		{name: "double quotes", line: `echo "it's !!"`, want: `echo "it's echo hi"`},
+		{name: "single quotes", line: "echo '!!'", want: "echo '!!'"},
+		{name: "escaped", line: `echo \!!ls`, want: `echo \!ls -l`},
+		{name: "not designators", line: "echo ! != !$ !", want: "echo ! != !$ !"},
+		{name: "quick substitution", line: "^hi^there", want: "echo there"},
+		{name: "quick substitution with final caret", line: "^cho^xit 1^", want: "exit 1 hi"},
+		{name: "number out of range", line: "!5", wantErr: ErrEventNotFound},
+		{name: "zero", line: "!0", wantErr: ErrEventNotFound},
+		{name: "relative out of range", line: "!-5", wantErr: ErrEventNotFound},
+		{name: "unknown prefix", line: "echo !nope", wantErr: ErrEventNotFound},
+		{name: "failed substitution", line: "^nope^x", wantErr: ErrBadSubstitution},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandHistory(tt.line, history)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expandHistory(%q) error = %v, want %v", tt.line, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandHistory(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHistory_empty(t *testing.T) {
+	for _, line := range []string{"!!", "^a^b"} {
+		if _, err := expandHistory(line, nil); !errors.Is(err, ErrEventNotFound) {
+			t.Errorf("expandHistory(%q, nil) error = %v, want %v", line, err, ErrEventNotFound)
+		}
+	}
+}
+
+func TestExec_historyExpansion(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+		wantLine   string
+	}{
+		{
+			name:       "expanded",
+			line:       "!! | wc -w",
+			wantOutput: "echo a b | wc -w\n2\n",
+			wantLine:   "echo a b | wc -w",
+		},
+		{
+			name:       "not expanded",
+			line:       "echo '!!'",
+			wantOutput: "!!\n",
+			wantLine:   "echo '!!'",
+		},
+		{
+			name:       "event not found",
+			line:       "!nope",
+			wantOutput: "shell: !nope: event not found\n",
+			wantStatus: 1,
+			wantLine:   "!nope",
+		},
+		{
+			name:       "failed substitution",
+			line:       "^x^d",
+			wantOutput: "shell: ^x^d: substitution failed\n",
+			wantStatus: 1,
+			wantLine:   "^x^d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.addHistory(sessionID, "echo a b")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+			if out.Line != tt.wantLine {
+				t.Errorf("Exec(env, %q) line = %q, want %q", tt.line, out.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "all",
+			line:       "history",
+			wantOutput: "    1  ls\n    2  cd projects\n    3  echo hi\n",
+		},
+		{
+			name:       "last",
+			line:       "history 2",
+			wantOutput: "    2  cd projects\n    3  echo hi\n",
+		},
+		{
+			name:       "more than there are",
+			line:       "history 10 | wc -l",
+			wantOutput: "3\n",
+		},
+		{
+			name:       "clear",
+			line:       "history -c; history",
+			wantOutput: "",
+		},
+		{
+			name:       "not numeric",
+			line:       "history x",
+			wantOutput: "history: x: numeric argument required\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "too many arguments",
+			line:       "history 1 2",
+			wantOutput: "history: too many arguments\n",
+			wantStatus: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.addHistory(sessionID, "ls", "cd projects", "echo hi")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestSearchHistory(t *testing.T) {
+	history := []string{"cat notes.txt", "ls -l", "grep -r cat .", "echo concat", "cat app.js", "ls -l"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "cat", want: []string{"cat app.js", "cat notes.txt", "grep -r cat .", "echo concat"}},
+		{query: "ls", want: []string{"ls -l"}},
+		{query: "-", want: []string{"ls -l", "grep -r cat ."}},
+		{query: "nope"},
+		{query: ""},
+	}
+	for _, tt := range tests {
+		if got := SearchHistory(history, tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("SearchHistory(history, %q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package termui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// ListDirectoryContents writes the contents of the directories given as
+// arguments, or of the current directory if there are none, to standard
+// output. Files given as arguments are listed first, followed by each
+// directory under a header naming it if there is more than one. Entries are
+// separated by two spaces when writing to the terminal and by newlines
+// otherwise, or always by newlines in long format. Directories are marked
+// with a trailing /, and with -F, openable files with a trailing *. Errors
+// are returned joined, each as *ArgError carrying the path that caused it.
+// Possible errors: ErrFileNotFound, ErrAccessDenied, ErrInvalidFlag.
+func ListDirectoryContents(env *Env, args []string) error {
+	var opts lsOptions
+	flagSet := newLsFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	remaining := flagSet.Args()
+	for _, arg := range remaining {
+		// Validate that the arguments look like reasonable paths before
+		// using them.
+		if !isValidPathArgument(arg) {
+			return fmt.Errorf("%w: invalid path argument", ErrInvalidFlag)
+		}
+	}
+	if len(remaining) == 0 {
+		remaining = []string{"."}
+	}
+
+	l := &lister{env: env, opts: opts, now: time.Now()}
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+
+	var (
+		files []lsEntry
+		dirs  []lsEntry
+		errs  []error
+	)
+	for _, arg := range remaining {
+		openPath := fsPath(resolvePath(currDir, arg))
+
+		info, err := fs.Stat(env.FS, openPath)
+		if err != nil {
+			errs = append(errs, &ArgError{Arg: arg, Err: fmt.Errorf("stat path %q: %w", openPath, mapFSErr(err))})
+			continue
+		}
+
+		entry := lsEntry{name: arg, path: openPath, info: info}
+		if info.IsDir() {
+			dirs = append(dirs, entry)
+		} else {
+			files = append(files, entry)
+		}
+	}
+
+	l.sort(files)
+	l.sort(dirs)
+
+	listed := false
+	if len(files) > 0 {
+		l.writeEntries(files)
+		listed = true
+	}
+
+	withHeaders := len(remaining) > 1 || opts.recursive
+	for _, dir := range dirs {
+		if err := l.writeDir(dir, withHeaders, listed); err != nil {
+			errs = append(errs, err)
+		}
+		listed = true
+	}
+
+	return errors.Join(errs...)
+}
+
+// lsOptions holds the flags accepted by ls.
+type lsOptions struct {
+	showAll       bool
+	longList      bool
+	humanReadable bool
+	sortByTime    bool
+	sortBySize    bool
+	reverse       bool
+	recursive     bool
+	classify      bool
+}
+
+func newLsFlagSet(opts *lsOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.showAll, "all", 'a', false, "show hidden files (starting with .)")
+	flagSet.BoolVar(&opts.longList, "long", 'l', false, "long format: mode, owner, group, size and modification time")
+	flagSet.BoolVar(&opts.humanReadable, "human-readable", 'h', false, "with -l, print sizes like 1.5K and 2M")
+	flagSet.BoolVar(&opts.sortByTime, "time", 't', false, "sort by modification time, newest first")
+	flagSet.BoolVar(&opts.sortBySize, "size", 'S', false, "sort by file size, largest first")
+	flagSet.BoolVar(&opts.reverse, "reverse", 'r', false, "reverse the sort order")
+	flagSet.BoolVar(&opts.recursive, "recursive", 'R', false, "list subdirectories recursively")
+	flagSet.BoolVar(&opts.classify, "classify", 'F', false, "mark openable files with *")
+	return flagSet
+}
+
+// lsEntry is a file listed by ls.
+type lsEntry struct {
+	// name is the name the entry is listed by.
+	name string
+	// path is the path of the entry in the filesystem.
+	path string
+	info fs.FileInfo
+}
+
+// lister writes the listings of an ls invocation.
+type lister struct {
+	env  *Env
+	opts lsOptions
+	// now is the time modification times are compared to when choosing
+	// whether to show the year.
+	now time.Time
+}
+
+// writeDir writes the listing of the directory dir, and with -R those of its
+// subdirectories. withHeader writes the directory's name before the
+// listing, separated from any previous listing by a blank line.
+func (l *lister) writeDir(dir lsEntry, withHeader, separate bool) error {
+	dirEntries, err := fs.ReadDir(l.env.FS, dir.path)
+	if err != nil {
+		return &ArgError{Arg: dir.name, Err: fmt.Errorf("read directory %q: %w", dir.path, mapFSErr(err))}
+	}
+
+	var entries []lsEntry
+	for _, dirEntry := range dirEntries {
+		// Filter hidden files unless -a is used.
+		if strings.HasPrefix(dirEntry.Name(), ".") && !l.opts.showAll {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return &ArgError{Arg: dir.name, Err: fmt.Errorf("stat %q: %w", dirEntry.Name(), mapFSErr(err))}
+		}
+
+		entryPath := dirEntry.Name()
+		if dir.path != "." {
+			entryPath = dir.path + "/" + dirEntry.Name()
+		}
+		entries = append(entries, lsEntry{name: dirEntry.Name(), path: entryPath, info: info})
+	}
+	l.sort(entries)
+
+	if separate && withHeader {
+		fmt.Fprintln(l.env.Stdout)
+	}
+	if withHeader {
+		fmt.Fprintf(l.env.Stdout, "%s:\n", dir.name)
+	}
+	l.writeEntries(entries)
+
+	if !l.opts.recursive {
+		return nil
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if !entry.info.IsDir() {
+			continue
+		}
+		entry.name = strings.TrimSuffix(dir.name, "/") + "/" + entry.name
+		if err := l.writeDir(entry, true, true); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sort sorts entries by name, or by modification time or size with -t or -S,
+// reversed with -r.
+func (l *lister) sort(entries []lsEntry) {
+	slices.SortStableFunc(entries, func(a, b lsEntry) int {
+		var c int
+		switch {
+		case l.opts.sortBySize:
+			c = cmp.Compare(b.info.Size(), a.info.Size())
+		case l.opts.sortByTime:
+			c = b.info.ModTime().Compare(a.info.ModTime())
+		}
+		if c == 0 {
+			c = strings.Compare(a.name, b.name)
+		}
+		if l.opts.reverse {
+			c = -c
+		}
+		return c
+	})
+}
+
+// writeEntries writes entries in short or long format.
+func (l *lister) writeEntries(entries []lsEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	if !l.opts.longList {
+		separator := "\n"
+		if isTerminal(l.env.Stdout) {
+			separator = "  "
+		}
+
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = l.displayName(entry)
+		}
+		fmt.Fprintln(l.env.Stdout, strings.Join(names, separator))
+		return
+	}
+
+	// Long format columns are aligned to their widest value.
+	rows := make([][]string, len(entries))
+	widths := make([]int, 4)
+	for i, entry := range entries {
+		owner := fileOwner(entry.path)
+		rows[i] = []string{entry.info.Mode().String(), owner, owner, l.formatSize(entry.info.Size())}
+		for j, col := range rows[i] {
+			widths[j] = max(widths[j], len(col))
+		}
+	}
+
+	for i, entry := range entries {
+		row := rows[i]
+		fmt.Fprintf(l.env.Stdout, "%s %-*s %-*s %*s %s %s\n",
+			row[0],
+			widths[1], row[1],
+			widths[2], row[2],
+			widths[3], row[3],
+			l.formatTime(entry.info.ModTime()),
+			l.displayName(entry),
+		)
+	}
+}
+
+// displayName returns the name of entry with its type suffix.
+func (l *lister) displayName(entry lsEntry) string {
+	switch {
+	case entry.info.IsDir():
+		return strings.TrimSuffix(entry.name, "/") + "/"
+	case l.opts.classify && extractURLFromContents(l.env.FS, entry.path) != "":
+		return entry.name + "*"
+	default:
+		return entry.name
+	}
+}
+
+// formatSize formats a size in bytes, in units of 1024 with -h.
+func (l *lister) formatSize(size int64) string {
+	if !l.opts.humanReadable || size < 1024 {
+		return strconv.FormatInt(size, 10)
+	}
+
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(sizeUnits)-1 {
+		value /= 1024
+		unit++
+	}
+
+	// Like GNU ls, sizes are rounded up, with one decimal below 10.
+	if value < 10 {
+		return fmt.Sprintf("%.1f%c", math.Ceil(value*10)/10, sizeUnits[unit])
+	}
+	return fmt.Sprintf("%.0f%c", math.Ceil(value), sizeUnits[unit])
+}
+
+// sizeUnits are the units of human readable sizes, in powers of 1024.
+const sizeUnits = "BKMGT"
+
+// formatTime formats a modification time like ls does: with the time of day
+// for recent files and with the year for files older than six months or in
+// the future.
+func (l *lister) formatTime(t time.Time) string {
+	sixMonthsAgo := l.now.AddDate(0, -6, 0)
+	if t.Before(sixMonthsAgo) || t.After(l.now.Add(time.Hour)) {
+		return t.Format("Jan _2  2006")
+	}
+	return t.Format("Jan _2 15:04")
+}
+
+// fileOwner returns the owner of the file at filePath: the user whose home
+// directory it is in, or root.
+func fileOwner(filePath string) string {
+	elems := strings.Split(path.Clean(filePath), "/")
+	if len(elems) >= 2 && elems[0] == "home" {
+		return elems[1]
+	}
+	return "root"
+}
@@ -0,0 +1,156 @@
+package termui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListDirectoryContents_listing(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "multiple paths",
+			line:       "ls /home/zorcal/projects/app.js /home/zorcal /home/guest",
+			wantOutput: "/home/zorcal/projects/app.js\n\n/home/guest:\nwelcome.txt\n\n/home/zorcal:\nprojects/\n",
+		},
+		{
+			name:       "multiple directories",
+			line:       "ls /home/zorcal /home",
+			wantOutput: "/home:\nguest/  zorcal/\n\n/home/zorcal:\nprojects/\n",
+		},
+		{
+			name:       "missing path",
+			line:       "ls missing /home/zorcal",
+			wantOutput: "/home/zorcal:\nprojects/\nls: missing: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "recursive",
+			line:       "ls -R /home/zorcal",
+			wantOutput: "/home/zorcal:\nprojects/\n\n/home/zorcal/projects:\napp.js  test-repo.md\n",
+		},
+		{
+			name:       "recursive hidden directories",
+			line:       "mkdir -p .hidden/sub && ls -R && ls -aR .hidden",
+			wantOutput: ".:\nwelcome.txt\n.hidden:\nsub/\n\n.hidden/sub:\n",
+		},
+		{
+			name:       "sort by size",
+			line:       "ls -S /home/zorcal/projects",
+			wantOutput: "test-repo.md  app.js\n",
+		},
+		{
+			name:       "sort by size reversed",
+			line:       "ls -Sr /home/zorcal/projects",
+			wantOutput: "app.js  test-repo.md\n",
+		},
+		{
+			name:       "sort by time",
+			line:       "touch b.txt; touch a.txt; ls -t",
+			wantOutput: "a.txt  b.txt  welcome.txt\n",
+		},
+		{
+			name:       "sort by name reversed",
+			line:       "touch b.txt a.txt; ls -r",
+			wantOutput: "welcome.txt  b.txt  a.txt\n",
+		},
+		{
+			name:       "classify",
+			line:       "ls -F /home/zorcal/projects /home/guest",
+			wantOutput: "/home/guest:\nwelcome.txt\n\n/home/zorcal/projects:\napp.js*  test-repo.md*\n",
+		},
+		{
+			name:       "long format",
+			line:       "ls -l /home/zorcal/projects/app.js | cut -d ' ' -f 1-4",
+			wantOutput: "-rw-r--r-- zorcal zorcal 71\n",
+		},
+		{
+			name:       "long format owners",
+			line:       "ls -l / | cut -d ' ' -f 1-3",
+			wantOutput: "drwxr-xr-x root root\ndrwxr-xr-x root root\n",
+		},
+		{
+			name:       "long format session files",
+			line:       "mkdir docs && ls -l | cut -d ' ' -f 1-3",
+			wantOutput: "drwxr-xr-x guest guest\n-rw-r--r-- guest guest\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/guest")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestLister_formatSize(t *testing.T) {
+	tests := []struct {
+		size          int64
+		humanReadable bool
+		want          string
+	}{
+		{size: 1536, humanReadable: false, want: "1536"},
+		{size: 0, humanReadable: true, want: "0"},
+		{size: 1023, humanReadable: true, want: "1023"},
+		{size: 1024, humanReadable: true, want: "1.0K"},
+		{size: 1536, humanReadable: true, want: "1.5K"},
+		{size: 1537, humanReadable: true, want: "1.6K"},
+		{size: 10*1024 + 1, humanReadable: true, want: "11K"},
+		{size: 5 << 20, humanReadable: true, want: "5.0M"},
+		{size: 3 << 30, humanReadable: true, want: "3.0G"},
+	}
+	for _, tt := range tests {
+		l := &lister{opts: lsOptions{humanReadable: tt.humanReadable}}
+		if got := l.formatSize(tt.size); got != tt.want {
+			t.Errorf("formatSize(%d) with humanReadable %v = %q, want %q", tt.size, tt.humanReadable, got, tt.want)
+		}
+	}
+}
+
+func TestLister_formatTime(t *testing.T) {
+	now := time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)
+	l := &lister{now: now}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{
+			name: "recent",
+			t:    time.Date(2025, time.March, 5, 9, 30, 0, 0, time.UTC),
+			want: "Mar  5 09:30",
+		},
+		{
+			name: "older than six months",
+			t:    time.Date(2024, time.August, 20, 9, 30, 0, 0, time.UTC),
+			want: "Aug 20  2024",
+		},
+		{
+			name: "in the future",
+			t:    time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC),
+			want: "Apr  1  2025",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.formatTime(tt.t); got != tt.want {
+				t.Errorf("formatTime(%v) = %q, want %q", tt.t, got, tt.want)
+			}
+		})
+	}
+}
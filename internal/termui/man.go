@@ -0,0 +1,410 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// manDir is the directory of the manual pages of the commands, section 1 of
+// the manual.
+const manDir = "usr/share/man/man1"
+
+// Manual pages are filled to manWidth columns, with the text of sections
+// indented by manIndent and the descriptions of options by manTagIndent.
+const (
+	manWidth     = 78
+	manIndent    = 7
+	manTagIndent = 14
+)
+
+type manOptions struct {
+	apropos bool
+}
+
+func newManFlagSet(opts *manOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.apropos, "apropos", 'k', false, "search the manual pages for keywords instead of showing a page")
+	return flagSet
+}
+
+// InstallManPages writes the manual page of each command registered in r to
+// fsys, under /usr/share/man/man1. The pages are generated from the usage,
+// summary, description and flags of the commands.
+func (r *Registry) InstallManPages(fsys *termfs.FS) {
+	fsys.AddDir("usr")
+	fsys.AddDir("usr/share")
+	fsys.AddDir("usr/share/man")
+	fsys.AddDir(manDir)
+
+	for _, cmd := range r.Commands() {
+		page := []byte(manPage(cmd))
+		fsys.AddFile(path.Join(manDir, cmd.Name+".1"), page)
+
+		// Aliases that are names, like more, have the page of the command
+		// as well.
+		for _, alias := range cmd.Aliases {
+			if isValidName(alias) {
+				fsys.AddFile(path.Join(manDir, alias+".1"), page)
+			}
+		}
+	}
+}
+
+// manPage returns the manual page of cmd in the subset of roff that man
+// renders: .TH, .SH, .PP, .TP and .B requests between lines of text.
+func manPage(cmd *Command) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, ".TH %s 1\n", strings.ToUpper(cmd.Name))
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(cmd.Name), roffEscape(cmd.Summary))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	name, operands, _ := strings.Cut(cmd.Usage, " ")
+	fmt.Fprintf(&b, ".B %s\n", roffEscape(name))
+	if operands != "" {
+		b.WriteString(roffEscape(operands) + "\n")
+	}
+
+	if cmd.Description != "" {
+		b.WriteString(".SH DESCRIPTION\n")
+		for i, para := range strings.Split(strings.TrimSpace(cmd.Description), "\n\n") {
+			if i > 0 {
+				b.WriteString(".PP\n")
+			}
+			for _, line := range strings.Split(para, "\n") {
+				b.WriteString(roffEscape(line) + "\n")
+			}
+		}
+	}
+
+	if cmd.Flags != nil {
+		b.WriteString(".SH OPTIONS\n")
+		cmd.Flags().VisitAll(func(f *posixflag.Flag) {
+			tag := "--" + f.Name
+			if f.Short != 0 {
+				tag = fmt.Sprintf("-%c, %s", f.Short, tag)
+			}
+			usage := f.Usage
+			if !f.IsBool() {
+				tag += "=" + strings.ToUpper(f.Name)
+				if def := f.DefValue; def != "" {
+					if strings.ContainsFunc(def, unicode.IsSpace) {
+						def = strconv.Quote(def)
+					}
+					usage += " (default " + def + ")"
+				}
+			}
+			b.WriteString(".TP\n")
+			fmt.Fprintf(&b, ".B %s\n", roffEscape(tag))
+			b.WriteString(roffEscape(usage) + "\n")
+		})
+	}
+
+	return b.String()
+}
+
+var (
+	roffEscaper   = strings.NewReplacer(`\`, `\e`, "-", `\-`)
+	roffUnescaper = strings.NewReplacer(`\e`, `\`, `\-`, "-", `\&`, "")
+)
+
+// roffEscape escapes a line of text, so that it is not taken for a request.
+func roffEscape(text string) string {
+	text = roffEscaper.Replace(text)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// manWord is a word of the text of a manual page.
+type manWord struct {
+	text string
+	bold bool
+}
+
+// manRenderer renders a manual page the way man shows it in a terminal.
+type manRenderer struct {
+	out    *styledText
+	indent int
+	// words are the words of the paragraph being filled.
+	words []manWord
+	// tag reports whether the next line is the tag of a .TP paragraph.
+	tag bool
+	// para reports whether a paragraph was written since the last heading.
+	para bool
+}
+
+// renderManPage renders the manual page source, written in the subset of
+// roff written by manPage: a header line from .TH, section headings from .SH
+// in bold, and paragraphs filled to the width of the page, with .B text in
+// bold and .TP paragraphs indented under their tag. Unknown requests are
+// ignored.
+func renderManPage(source string) *styledText {
+	m := &manRenderer{out: new(styledText), indent: manIndent}
+
+	for _, line := range splitLines(source) {
+		if !strings.HasPrefix(line, ".") && !strings.HasPrefix(line, "'") {
+			m.text(roffUnescaper.Replace(line), false)
+			continue
+		}
+
+		request, arg, _ := strings.Cut(line[1:], " ")
+		arg = roffUnescaper.Replace(strings.TrimSpace(arg))
+		switch request {
+		case "TH":
+			m.header(strings.Fields(arg))
+		case "SH":
+			m.flush()
+			if m.out.text.Len() > 0 {
+				m.out.WriteString("\n")
+			}
+			m.out.Element("strong", "", arg)
+			m.out.WriteString("\n")
+			m.indent, m.para, m.tag = manIndent, false, false
+		case "PP":
+			m.paragraph()
+		case "TP":
+			m.paragraph()
+			m.tag = true
+		case "B":
+			m.text(arg, true)
+		}
+	}
+	m.flush()
+
+	return m.out
+}
+
+// header writes the header line of the page titled by the arguments of .TH,
+// the name and section of the page.
+func (m *manRenderer) header(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	title := args[0]
+	if len(args) > 1 {
+		title += "(" + args[1] + ")"
+	}
+
+	const center = "User Commands"
+	gap := max(manWidth-2*utf8.RuneCountInString(title)-len(center), 2)
+	m.out.WriteString(title + strings.Repeat(" ", gap/2) + center + strings.Repeat(" ", gap-gap/2) + title + "\n")
+}
+
+// paragraph starts a new paragraph, separated from the previous one by a
+// blank line.
+func (m *manRenderer) paragraph() {
+	m.flush()
+	if m.para {
+		m.out.WriteString("\n")
+	}
+	m.indent, m.tag = manIndent, false
+}
+
+// text adds a line of text to the page.
+func (m *manRenderer) text(line string, bold bool) {
+	m.para = true
+
+	if m.tag {
+		m.out.WriteString(strings.Repeat(" ", m.indent))
+		if bold {
+			m.out.Element("strong", "", line)
+		} else {
+			m.out.WriteString(line)
+		}
+		m.out.WriteString("\n")
+		m.indent, m.tag = manTagIndent, false
+		return
+	}
+
+	for _, word := range strings.Fields(line) {
+		m.words = append(m.words, manWord{text: word, bold: bold})
+	}
+}
+
+// flush writes the paragraph being filled.
+func (m *manRenderer) flush() {
+	col := 0
+	for _, w := range m.words {
+		width := utf8.RuneCountInString(w.text)
+		switch {
+		case col == 0:
+		case col+1+width > manWidth:
+			m.out.WriteString("\n")
+			col = 0
+		default:
+			m.out.WriteString(" ")
+			col++
+		}
+		if col == 0 {
+			m.out.WriteString(strings.Repeat(" ", m.indent))
+			col = m.indent
+		}
+
+		if w.bold {
+			m.out.Element("strong", "", w.text)
+		} else {
+			m.out.WriteString(w.text)
+		}
+		col += width
+	}
+	if col > 0 {
+		m.out.WriteString("\n")
+	}
+	m.words = nil
+}
+
+// Man writes the manual pages of the commands given as arguments, rendered
+// from their sources under /usr/share/man/man1. With -k the arguments are
+// keywords instead, and Man lists the pages whose text contains any of them,
+// ignoring case. Errors are returned joined, each as *ArgError carrying the
+// page or keyword that caused it.
+// Possible errors: ErrMissingArgument, ErrNoManualEntry,
+// ErrNoManualMatch, ErrInvalidFlag.
+func Man(env *Env, args []string) error {
+	var opts manOptions
+	flagSet := newManFlagSet(&opts)
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	args = flagSet.Args()
+	if len(args) == 0 {
+		return ErrMissingArgument
+	}
+	if opts.apropos {
+		return apropos(env, args)
+	}
+
+	out := new(styledText)
+	var errs []error
+	for _, name := range args {
+		source, err := readManPage(env, name)
+		if err != nil {
+			errs = append(errs, &ArgError{Arg: name, Err: err})
+			continue
+		}
+
+		if out.text.Len() > 0 {
+			out.WriteString("\n")
+		}
+		out.Append(renderManPage(source))
+	}
+
+	if err := writeStyled(env.Stdout, out); err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+// readManPage returns the source of the manual page of the named command.
+func readManPage(env *Env, name string) (string, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return "", ErrNoManualEntry
+	}
+
+	source, err := fs.ReadFile(env.FS, path.Join(manDir, name+".1"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNoManualEntry
+	}
+	if err != nil {
+		return "", fmt.Errorf("read manual page %q: %w", name, mapFSErr(err))
+	}
+
+	return string(source), nil
+}
+
+// apropos writes the name and summary of the manual pages containing any of
+// the keywords, like man -k.
+func apropos(env *Env, keywords []string) error {
+	names, err := fs.Glob(env.FS, path.Join(manDir, "*.1"))
+	if err != nil {
+		return fmt.Errorf("glob manual pages: %w", err)
+	}
+
+	var (
+		entries [][2]string
+		width   int
+		found   = make(map[string]bool)
+	)
+	for _, name := range names {
+		source, err := fs.ReadFile(env.FS, name)
+		if err != nil {
+			return fmt.Errorf("read manual page %q: %w", name, mapFSErr(err))
+		}
+
+		text := strings.ToLower(manText(string(source)))
+		var match bool
+		for _, kw := range keywords {
+			if strings.Contains(text, strings.ToLower(kw)) {
+				found[kw], match = true, true
+			}
+		}
+		if !match {
+			continue
+		}
+
+		page, summary := manSummary(strings.TrimSuffix(path.Base(name), ".1"), string(source))
+		page += " (1)"
+		entries = append(entries, [2]string{page, summary})
+		width = max(width, len(page))
+	}
+
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(env.Stdout, "%-*s - %s\n", width, e[0], e[1]); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, kw := range keywords {
+		if !found[kw] {
+			errs = append(errs, &ArgError{Arg: kw, Err: ErrNoManualMatch})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// manText returns the text of the sections of a manual page, without the
+// header and the section headings.
+func manText(source string) string {
+	var b strings.Builder
+	for _, line := range splitLines(source) {
+		if arg, ok := strings.CutPrefix(line, ".B "); ok {
+			line = arg
+		} else if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			continue
+		}
+		b.WriteString(roffUnescaper.Replace(line) + "\n")
+	}
+	return b.String()
+}
+
+// manSummary returns the name and summary of the page from its NAME
+// section, e.g. "ls - List directory contents". Pages without one are
+// summarized by the name of their file.
+func manSummary(file, source string) (name, summary string) {
+	_, section, ok := strings.Cut(source, ".SH NAME\n")
+	if !ok {
+		return file, ""
+	}
+
+	line, _, _ := strings.Cut(section, "\n")
+	name, summary, ok = strings.Cut(roffUnescaper.Replace(line), " - ")
+	if !ok {
+		return file, ""
+	}
+	return name, summary
+}
@@ -0,0 +1,144 @@
+package termui
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
+)
+
+func TestMan(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name: "page",
+			line: "man pwd | cat",
+			wantOutput: "PWD(1)                          User Commands                           PWD(1)\n" +
+				"\n" +
+				"NAME\n" +
+				"       pwd - Print working directory\n" +
+				"\n" +
+				"SYNOPSIS\n" +
+				"       pwd\n" +
+				"\n" +
+				"DESCRIPTION\n" +
+				"       Print the absolute path of the current directory.\n",
+		},
+		{
+			name:       "options with values",
+			line:       "man cut | tail -n 5",
+			wantOutput: "              use the given character instead of tab as field delimiter\n              (default \"\\t\")\n\n       -f, --fields=FIELDS\n              select only these fields, e.g. 1,3-5\n",
+		},
+		{
+			name:       "pages",
+			line:       "man true false | grep Exit",
+			wantOutput: "       Exit with status 0.\n       Exit with status 1.\n",
+		},
+		{
+			name:       "alias",
+			line:       "man more | head -n 4 | tail -n 2",
+			wantOutput: "NAME\n       less - View a file or standard input one screen at a time\n",
+		},
+		{
+			name:       "search",
+			line:       "man -k HIERARCHY",
+			wantOutput: "tree (1) - Show the directory hierarchy\n",
+		},
+		{
+			name:       "search keywords",
+			line:       "man -k oldpwd highlighted",
+			wantOutput: "cat (1)  - Display file contents, or standard input\ncd (1)   - Change directory\ngrep (1) - Search files for lines matching a regular expression\n",
+		},
+		{
+			name:       "search without match",
+			line:       "man -k hierarchy nope",
+			wantOutput: "tree (1) - Show the directory hierarchy\nman: nope: nothing appropriate\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "missing page",
+			line:       "man nope",
+			wantOutput: "man: nope: No manual entry\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "path",
+			line:       "man ../man1/ls",
+			wantOutput: "man: ../man1/ls: No manual entry\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "missing argument",
+			line:       "man",
+			wantOutput: "man: missing file argument\n",
+			wantStatus: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			tfs := termfs.New(nil)
+			r.InstallManPages(tfs)
+			sessMgr := newMockSessionManager()
+			sessionID := "session1"
+
+			env := &Env{FS: termfs.NewOverlay(tfs, 1<<16), Sessions: sessMgr, SessionID: sessionID}
+			out, status := r.Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRenderManPage(t *testing.T) {
+	source := ".TH X 1\n" +
+		".SH NAME\n" +
+		"x \\- do \\ex\n" +
+		".SH OPTIONS\n" +
+		".TP\n" +
+		".B \\-a, \\-\\-all\n" +
+		"all <files>\n" +
+		".TP\n" +
+		".B \\-b\n" +
+		"\\&.b " + strings.Repeat("word ", 16) + "\n" +
+		".XX ignored\n"
+
+	want := template.HTML("X(1)                            User Commands                             X(1)\n" +
+		"\n" +
+		"<strong>NAME</strong>\n" +
+		"       x - do \\x\n" +
+		"\n" +
+		"<strong>OPTIONS</strong>\n" +
+		"       <strong>-a, --all</strong>\n" +
+		"              all &lt;files&gt;\n" +
+		"\n" +
+		"       <strong>-b</strong>\n" +
+		"              .b word word word word word word word word word word word word\n" +
+		"              word word word word\n")
+
+	if got := template.HTML(renderManPage(source).markup.String()); got != want {
+		t.Errorf("renderManPage(%q) = %q, want %q", source, got, want)
+	}
+}
+
+func TestManPage_roundTrip(t *testing.T) {
+	r := NewRegistry()
+	for _, cmd := range r.Commands() {
+		page := manPage(cmd)
+		if name, summary := manSummary(cmd.Name, page); name != cmd.Name || summary != cmd.Summary {
+			t.Errorf("manSummary of the page of %s = %q, %q, want %q, %q", cmd.Name, name, summary, cmd.Name, cmd.Summary)
+		}
+		if cmd.Description == "" {
+			t.Errorf("command %s has no description", cmd.Name)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package termui
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CSS classes of the parts of markdown rendered in the terminal.
+const (
+	mdHeadingClass = "md-heading"
+	mdBulletClass  = "md-bullet"
+	mdCodeClass    = "md-code"
+	mdLinkClass    = "md-link"
+)
+
+var (
+	// markdownLinkPattern matches a link [text](url), capturing the text and
+	// the URL.
+	markdownLinkPattern = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItemPattern = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	// inlinePattern matches the inline elements of a line: **bold**,
+	// `code`, a link or a bare web URL.
+	inlinePattern = regexp.MustCompile(`\*\*(.+?)\*\*|` + "`([^`]+)`" + `|` + markdownLinkPattern.String() + `|https?://[^\s<>()\[\]]*[^\s<>()\[\].,;:!?'"]`)
+)
+
+// isMarkdown reports whether the file name is a markdown file.
+func isMarkdown(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".md")
+}
+
+// renderMarkdown renders markdown source the way a terminal would show it.
+// Headings are shown bold without their #, list items with a bullet or their
+// number, and fenced code blocks without their fences, highlighted if their
+// language is known. Within other lines, **bold**, `code`, links and bare
+// web URLs are rendered, with links opening in a new tab. Anything else is
+// shown as it is.
+func renderMarkdown(source string) *styledText {
+	out := new(styledText)
+
+	var (
+		inCode   bool
+		codeLang *language
+		code     strings.Builder
+	)
+	for _, line := range splitLines(source) {
+		if fence, ok := strings.CutPrefix(strings.TrimSpace(line), "```"); ok {
+			if inCode && codeLang != nil {
+				out.Append(highlight(codeLang, code.String()))
+				code.Reset()
+			}
+			inCode = !inCode
+			codeLang = languages[strings.ToLower(strings.TrimSpace(fence))]
+			continue
+		}
+
+		switch {
+		case inCode && codeLang != nil:
+			code.WriteString(line + "\n")
+			continue
+
+		case inCode:
+			out.Element("span", mdCodeClass, line)
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			out.Element("strong", mdHeadingClass, m[2])
+
+		case listItemPattern.MatchString(line):
+			m := listItemPattern.FindStringSubmatch(line)
+			marker := m[2]
+			if strings.ContainsAny(marker, "-*+") {
+				marker = "•"
+			}
+			out.WriteString(m[1] + "  ")
+			out.Element("span", mdBulletClass, marker)
+			out.WriteString(" ")
+			renderInline(out, m[3])
+
+		default:
+			renderInline(out, line)
+		}
+		out.WriteString("\n")
+	}
+
+	// An unterminated code block ends with the source.
+	if inCode && codeLang != nil {
+		out.Append(highlight(codeLang, code.String()))
+	}
+
+	return out
+}
+
+// renderInline writes the line of text with its inline elements rendered.
+func renderInline(out *styledText, line string) {
+	last := 0
+	for _, m := range inlinePattern.FindAllStringSubmatchIndex(line, -1) {
+		out.WriteString(line[last:m[0]])
+		switch {
+		case m[2] >= 0:
+			out.Element("strong", "", line[m[2]:m[3]])
+		case m[4] >= 0:
+			out.Element("span", mdCodeClass, line[m[4]:m[5]])
+		case m[6] >= 0:
+			out.Link(mdLinkClass, line[m[8]:m[9]], line[m[6]:m[7]])
+		default:
+			out.Link(mdLinkClass, line[m[0]:m[1]], line[m[0]:m[1]])
+		}
+		last = m[1]
+	}
+	out.WriteString(line[last:])
+}
@@ -0,0 +1,134 @@
+package termui
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		wantText string
+		wantHTML template.HTML
+	}{
+		{
+			name:     "headings",
+			source:   "# Title\n\n### Section ###\n#not a heading\n",
+			wantText: "Title\n\nSection\n#not a heading\n",
+			wantHTML: `<strong class="md-heading">Title</strong>` + "\n\n" + `<strong class="md-heading">Section</strong>` + "\n#not a heading\n",
+		},
+		{
+			name:     "bold",
+			source:   "**Language:** Go & more",
+			wantText: "Language: Go & more\n",
+			wantHTML: "<strong>Language:</strong> Go &amp; more\n",
+		},
+		{
+			name:     "links",
+			source:   "See [the docs](https://example.com/docs?a=1&b=2).",
+			wantText: "See the docs.\n",
+			wantHTML: `See <a class="md-link" href="https://example.com/docs?a=1&amp;b=2" target="_blank" rel="noopener noreferrer">the docs</a>.` + "\n",
+		},
+		{
+			name:     "bare URLs",
+			source:   "Visit https://example.com/app, then leave.",
+			wantText: "Visit https://example.com/app, then leave.\n",
+			wantHTML: `Visit <a class="md-link" href="https://example.com/app" target="_blank" rel="noopener noreferrer">https://example.com/app</a>, then leave.` + "\n",
+		},
+		{
+			name:     "links to other schemes are text",
+			source:   "[click](javascript:void) [mail](mailto:a@example.com)",
+			wantText: "click mail\n",
+			wantHTML: "click mail\n",
+		},
+		{
+			name:     "lists",
+			source:   "- one\n  * two\n3. three",
+			wantText: "  • one\n    • two\n  3. three\n",
+			wantHTML: `  <span class="md-bullet">•</span> one` + "\n" + `    <span class="md-bullet">•</span> two` + "\n" + `  <span class="md-bullet">3.</span> three` + "\n",
+		},
+		{
+			name:     "inline code",
+			source:   "Run `ls **` now",
+			wantText: "Run ls ** now\n",
+			wantHTML: `Run <span class="md-code">ls **</span> now` + "\n",
+		},
+		{
+			name:     "code blocks",
+			source:   "```\n# not a heading\n**x** <b>\n```\ntext",
+			wantText: "# not a heading\n**x** <b>\ntext\n",
+			wantHTML: `<span class="md-code"># not a heading</span>` + "\n" + `<span class="md-code">**x** &lt;b&gt;</span>` + "\ntext\n",
+		},
+		{
+			name:     "highlighted code blocks",
+			source:   "```go\nx := \"<b>\"\n``` \n```sh\necho $HOME",
+			wantText: "x := \"<b>\"\necho $HOME\n",
+			wantHTML: `x := <span class="tok-string">&#34;&lt;b&gt;&#34;</span>` + "\n" + `echo <span class="tok-variable">$HOME</span>` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out Output
+			if err := writeStyled(out.Writer(""), renderMarkdown(tt.source)); err != nil {
+				t.Fatalf("writeStyled() error = %v", err)
+			}
+
+			chunks := out.Chunks()
+			if len(chunks) != 1 {
+				t.Fatalf("renderMarkdown(%q) chunks = %q, want 1 chunk", tt.source, chunks)
+			}
+			if got := chunks[0].Text; got != tt.wantText {
+				t.Errorf("renderMarkdown(%q) text = %q, want %q", tt.source, got, tt.wantText)
+			}
+			if got := chunks[0].HTML; got != tt.wantHTML {
+				t.Errorf("renderMarkdown(%q) HTML = %q, want %q", tt.source, got, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func TestCatFile_markdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+	}{
+		{
+			name:       "rendered in the terminal",
+			line:       "cat test-repo.md",
+			wantOutput: "test-repo\n\nA test repository\n\nLanguage: Go\nStars: 42\nURL: https://github.com/test/test-repo\nLast Updated: 2024-01-01T00:00:00Z\n",
+		},
+		{
+			name:       "raw",
+			line:       "cat -r test-repo.md | head -n 1; cat --raw test-repo.md | tail -n 1",
+			wantOutput: "# test-repo\n**Last Updated:** 2024-01-01T00:00:00Z\n",
+		},
+		{
+			name:       "source in pipes",
+			line:       "cat test-repo.md | head -n 1",
+			wantOutput: "# test-repo\n",
+		},
+		{
+			name:       "other files",
+			line:       "cat app.js",
+			wantOutput: "console.log('hello world');\n\n**URL:** https://github.com/example/app-js",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != 0 {
+				t.Errorf("Exec(env, %q) status = %d, want 0", tt.line, status)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
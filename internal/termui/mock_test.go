@@ -1,21 +1,32 @@
 package termui
 
-import "sync"
+import (
+	"slices"
+	"sync"
+)
 
 type mockSessionManager struct {
-	dirs   map[string]string
-	dirsMu sync.RWMutex
+	dirs      map[string]string
+	statuses  map[string]int
+	envs      map[string]*Vars
+	histories map[string][]string
+	aliases   map[string]*Vars
+	mu        sync.RWMutex
 }
 
 func newMockSessionManager() *mockSessionManager {
 	return &mockSessionManager{
-		dirs: make(map[string]string),
+		dirs:      make(map[string]string),
+		statuses:  make(map[string]int),
+		envs:      make(map[string]*Vars),
+		histories: make(map[string][]string),
+		aliases:   make(map[string]*Vars),
 	}
 }
 
 func (m *mockSessionManager) GetCurrentDir(sessionID string) string {
-	m.dirsMu.RLock()
-	defer m.dirsMu.RUnlock()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
 	if dir, exists := m.dirs[sessionID]; exists {
 		return dir
@@ -24,7 +35,101 @@ func (m *mockSessionManager) GetCurrentDir(sessionID string) string {
 }
 
 func (m *mockSessionManager) SetCurrentDir(sessionID, dir string) {
-	m.dirsMu.Lock()
-	defer m.dirsMu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.dirs[sessionID] = dir
 }
+
+func (m *mockSessionManager) GetLastStatus(sessionID string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.statuses[sessionID]
+}
+
+func (m *mockSessionManager) SetLastStatus(sessionID string, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses[sessionID] = status
+}
+
+func (m *mockSessionManager) GetEnv(sessionID string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if vars, exists := m.envs[sessionID]; exists {
+		return vars.Map()
+	}
+	return DefaultEnv()
+}
+
+func (m *mockSessionManager) SetEnv(sessionID, name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessionEnv(sessionID).Set(name, value)
+}
+
+func (m *mockSessionManager) UnsetEnv(sessionID, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionEnv(sessionID).Unset(name)
+}
+
+// sessionEnv returns the environment of a session, creating it if needed.
+// The caller must hold m.mu.
+func (m *mockSessionManager) sessionEnv(sessionID string) *Vars {
+	vars, exists := m.envs[sessionID]
+	if !exists {
+		vars = NewVars(DefaultEnv(), EnvQuota)
+		m.envs[sessionID] = vars
+	}
+	return vars
+}
+
+func (m *mockSessionManager) GetHistory(sessionID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.histories[sessionID])
+}
+
+func (m *mockSessionManager) ClearHistory(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.histories, sessionID)
+}
+
+func (m *mockSessionManager) GetAliases(sessionID string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if aliases, exists := m.aliases[sessionID]; exists {
+		return aliases.Map()
+	}
+	return nil
+}
+
+func (m *mockSessionManager) SetAlias(sessionID, name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.aliases[sessionID]; !exists {
+		m.aliases[sessionID] = NewVars(nil, AliasQuota)
+	}
+	return m.aliases[sessionID].Set(name, value)
+}
+
+func (m *mockSessionManager) UnsetAlias(sessionID, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if aliases, exists := m.aliases[sessionID]; exists {
+		aliases.Unset(name)
+	}
+}
+
+// addHistory records command lines in the history of a session, like the
+// terminal does after running them.
+func (m *mockSessionManager) addHistory(sessionID string, lines ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histories[sessionID] = append(m.histories[sessionID], lines...)
+}
@@ -0,0 +1,288 @@
+package termui
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// stderrClass is the CSS class of text written to standard error.
+const stderrClass = "stderr"
+
+// maxOutputSize is the number of bytes the commands of a command line may
+// write to the terminal, pipes and redirections together. Further writes are
+// discarded, and the command line is stopped.
+const maxOutputSize = 1 << 20
+
+// Output is the terminal output of a command line. It keeps the text written
+// to the terminal by standard output and standard error in the order it was
+// written, along with requests commands make to the client terminal.
+type Output struct {
+	// Line is the command line that ran, after history expansion. It is the
+	// line to record in the session history.
+	Line string
+	// OpenURL is a URL the client should open in a new tab.
+	OpenURL string
+	// Clear requests that the terminal screen is cleared before the output
+	// is shown.
+	Clear bool
+	// Pager is a pager the client should show full-screen, sending it the
+	// keys the user presses until it quits.
+	Pager *Pager
+
+	chunks []*chunkBuilder
+	// written is the number of bytes written by commands, counted towards
+	// maxOutputSize.
+	written int
+}
+
+// Chunk is a run of output text written with the same CSS class.
+type Chunk struct {
+	Class string
+	// Text is the plain text of the run.
+	Text string
+	// HTML is the run rendered as HTML: Text escaped, with the markup added
+	// by trusted renderers. Text written by commands never becomes markup.
+	HTML template.HTML
+}
+
+// chunkBuilder accumulates the text of a Chunk as it is written.
+type chunkBuilder struct {
+	class  string
+	text   strings.Builder
+	markup strings.Builder
+}
+
+// Chunks returns the text written to the output, merging consecutive writes
+// with the same class.
+func (o *Output) Chunks() []Chunk {
+	chunks := make([]Chunk, 0, len(o.chunks))
+	for _, c := range o.chunks {
+		chunks = append(chunks, Chunk{
+			Class: c.class,
+			Text:  c.text.String(),
+			HTML:  template.HTML(c.markup.String()),
+		})
+	}
+	return chunks
+}
+
+// String returns the text written to the output without any styling.
+func (o *Output) String() string {
+	var b strings.Builder
+	for _, c := range o.chunks {
+		b.WriteString(c.text.String())
+	}
+	return b.String()
+}
+
+// clearScreen discards the output written so far and requests that the
+// terminal screen is cleared.
+func (o *Output) clearScreen() {
+	o.Clear = true
+	o.chunks = nil
+}
+
+// Writer returns a writer that appends text with the given CSS class. Text
+// written once the output limit is reached is discarded.
+func (o *Output) Writer(class string) io.Writer {
+	return &terminalWriter{out: o, class: class}
+}
+
+// errorWriter returns a writer of standard error that is not subject to the
+// output limit, for the shell to report why it stopped.
+func (o *Output) errorWriter() io.Writer {
+	return &terminalWriter{out: o, class: stderrClass, unlimited: true}
+}
+
+// full reports whether the commands of the command line have written as much
+// as they may.
+func (o *Output) full() bool {
+	return o.written >= maxOutputSize
+}
+
+// count counts n bytes written by a command and reports whether they may be
+// kept, which they may until the output is full.
+func (o *Output) count(n int) bool {
+	if o.full() {
+		return false
+	}
+	o.written += n
+	return true
+}
+
+// terminalWriter appends text to an Output. Commands writing to a
+// terminalWriter write directly to the client terminal.
+type terminalWriter struct {
+	out       *Output
+	class     string
+	unlimited bool
+}
+
+// Write implements io.Writer. The text is escaped when rendered as HTML.
+func (w *terminalWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	w.write(string(p), html.EscapeString(string(p)))
+
+	return len(p), nil
+}
+
+// write appends text along with its HTML rendering, which the caller must
+// have made safe.
+func (w *terminalWriter) write(text, markup string) {
+	if !w.unlimited && !w.out.count(len(text)) {
+		return
+	}
+
+	chunks := w.out.chunks
+	if n := len(chunks); n == 0 || chunks[n-1].class != w.class {
+		w.out.chunks = append(chunks, &chunkBuilder{class: w.class})
+	}
+
+	c := w.out.chunks[len(w.out.chunks)-1]
+	c.text.WriteString(text)
+	c.markup.WriteString(markup)
+}
+
+// outputBuffer holds the standard output of a command written to a pipe or a
+// redirection. Its writes count towards the output limit of out, and are
+// discarded once it is reached.
+type outputBuffer struct {
+	buf bytes.Buffer
+	out *Output
+}
+
+func newOutputBuffer(out *Output) *outputBuffer {
+	return &outputBuffer{out: out}
+}
+
+// Write implements io.Writer.
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	if b.out.count(len(p)) {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// Read implements io.Reader.
+func (b *outputBuffer) Read(p []byte) (int, error) {
+	return b.buf.Read(p)
+}
+
+// Bytes returns the unread bytes written to b.
+func (b *outputBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// isTerminal reports whether w writes directly to the client terminal, as
+// opposed to a pipe.
+func isTerminal(w io.Writer) bool {
+	_, ok := w.(*terminalWriter)
+	return ok
+}
+
+// styledText is text with markup added by a trusted renderer. Text added to
+// it is always escaped; only the renderer chooses the elements wrapping it.
+type styledText struct {
+	text   strings.Builder
+	markup strings.Builder
+}
+
+// WriteString appends plain text.
+func (s *styledText) WriteString(text string) {
+	s.text.WriteString(text)
+	s.markup.WriteString(html.EscapeString(text))
+}
+
+// Write implements io.Writer by appending p as plain text.
+func (s *styledText) Write(p []byte) (int, error) {
+	s.WriteString(string(p))
+	return len(p), nil
+}
+
+// Element appends text wrapped in an element with the given tag and CSS
+// class. tag and class must be constants of the renderer; class may be
+// empty. Text spanning several lines is wrapped line by line, so that no
+// element spans lines.
+func (s *styledText) Element(tag, class, text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		if line == "" {
+			continue
+		}
+
+		s.text.WriteString(line)
+		s.markup.WriteString("<" + tag)
+		if class != "" {
+			s.markup.WriteString(` class="` + html.EscapeString(class) + `"`)
+		}
+		s.markup.WriteString(">" + html.EscapeString(line) + "</" + tag + ">")
+	}
+}
+
+// Link appends text as a link to href that opens in a new tab, with the
+// given CSS class. href is written by users, so unless it is a web URL the
+// text is appended without the link.
+func (s *styledText) Link(class, href, text string) {
+	if !isWebURL(href) {
+		s.WriteString(text)
+		return
+	}
+
+	s.text.WriteString(text)
+	s.markup.WriteString(`<a class="` + html.EscapeString(class) + `" href="` + html.EscapeString(href) + `" target="_blank" rel="noopener noreferrer">`)
+	s.markup.WriteString(html.EscapeString(text) + "</a>")
+}
+
+// Append appends t.
+func (s *styledText) Append(t *styledText) {
+	s.text.WriteString(t.text.String())
+	s.markup.WriteString(t.markup.String())
+}
+
+// Lines splits s after each newline. A final line without a newline is
+// included.
+func (s *styledText) Lines() []*styledText {
+	// Neither elements nor their attributes span lines, so the markup has
+	// the lines of the text.
+	texts := strings.SplitAfter(s.text.String(), "\n")
+	markups := strings.SplitAfter(s.markup.String(), "\n")
+
+	var lines []*styledText
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		line := new(styledText)
+		line.text.WriteString(text)
+		line.markup.WriteString(markups[i])
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// String returns the plain text.
+func (s *styledText) String() string {
+	return s.text.String()
+}
+
+// writeStyled writes s to w. A terminal receives the markup, any other
+// writer, e.g. a pipe, the plain text.
+func writeStyled(w io.Writer, s *styledText) error {
+	if tw, ok := w.(*terminalWriter); ok {
+		if s.text.Len() > 0 {
+			tw.write(s.text.String(), s.markup.String())
+		}
+		return nil
+	}
+
+	_, err := io.WriteString(w, s.text.String())
+	return err
+}
@@ -0,0 +1,164 @@
+package termui
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOutput_writer(t *testing.T) {
+	out := &Output{}
+	w := out.Writer("file-content")
+
+	payload := `<img src=x onerror=alert(1)> & "quotes"`
+	if _, err := w.Write([]byte(payload)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	chunks := out.Chunks()
+	if len(chunks) != 1 {
+		t.Fatalf("Chunks() = %q, want 1 chunk", chunks)
+	}
+	if got := chunks[0].Text; got != payload {
+		t.Errorf("chunks[0].Text = %q, want %q", got, payload)
+	}
+	want := template.HTML(`&lt;img src=x onerror=alert(1)&gt; &amp; &#34;quotes&#34;`)
+	if got := chunks[0].HTML; got != want {
+		t.Errorf("chunks[0].HTML = %q, want %q", got, want)
+	}
+}
+
+func TestWriteStyled(t *testing.T) {
+	s := new(styledText)
+	s.WriteString("usage: ")
+	s.Element("strong", "", "<b>ls</b>")
+	s.WriteString(" & more\n")
+
+	t.Run("terminal", func(t *testing.T) {
+		out := &Output{}
+		w := out.Writer("help")
+		if _, err := w.Write([]byte("> ")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if err := writeStyled(w, s); err != nil {
+			t.Fatalf("writeStyled() error = %v", err)
+		}
+
+		chunks := out.Chunks()
+		if len(chunks) != 1 {
+			t.Fatalf("Chunks() = %q, want 1 chunk", chunks)
+		}
+		if got, want := chunks[0].Text, "> usage: <b>ls</b> & more\n"; got != want {
+			t.Errorf("chunks[0].Text = %q, want %q", got, want)
+		}
+		want := template.HTML("&gt; usage: <strong>&lt;b&gt;ls&lt;/b&gt;</strong> &amp; more\n")
+		if got := chunks[0].HTML; got != want {
+			t.Errorf("chunks[0].HTML = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("pipe", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeStyled(&buf, s); err != nil {
+			t.Fatalf("writeStyled() error = %v", err)
+		}
+		if got, want := buf.String(), "usage: <b>ls</b> & more\n"; got != want {
+			t.Errorf("writeStyled() wrote %q, want %q", got, want)
+		}
+	})
+}
+
+// injectionPayloads are strings that run script or break out of the output
+// markup if written to the terminal unescaped.
+var injectionPayloads = []string{
+	`<img src=x onerror=alert(1)>`,
+	`<script>alert(1)</script>`,
+	`"><svg onload=alert(1)>`,
+	`</pre><iframe src=javascript:alert(1)></iframe><pre>`,
+	`&lt;script&gt;alert(1)&lt;/script&gt;`,
+}
+
+// allowedTags are the elements trusted renderers may add to the output.
+var allowedTags = map[string]bool{
+	"a":      true,
+	"span":   true,
+	"strong": true,
+}
+
+var (
+	tagPattern  = regexp.MustCompile(`</?([^\s>/]*)`)
+	hrefPattern = regexp.MustCompile(`href="([^"]*)"`)
+)
+
+func TestRegistry_exec_injection(t *testing.T) {
+	r := NewRegistry()
+
+	var names []string
+	for _, cmd := range r.Commands() {
+		names = append(names, cmd.Name)
+	}
+
+	for _, payload := range injectionPayloads {
+		quoted := quoteValue(payload)
+
+		lines := []string{
+			quoted,
+			"cd " + quoted,
+			"export X=" + quoted + " && echo $X",
+		}
+		for _, name := range names {
+			lines = append(lines,
+				name+" "+quoted,
+				name+" --"+quoted,
+				name+" -- "+quoted+" "+quoted+".md",
+				"cat "+quoted+".md | "+name,
+				name+" *",
+			)
+		}
+
+		for _, line := range lines {
+			t.Run(line, func(t *testing.T) {
+				tfs, sessMgr := setupTest()
+				sessionID := "session1"
+				sessMgr.SetCurrentDir(sessionID, "home/guest")
+
+				// Files named like the payload, with the payload as contents
+				// and in URLs, where a name can hold it.
+				content := []byte(payload + "\n**URL:** https://example.com/" + payload + "\n" +
+					"[" + payload + "](javascript:alert(1)) [x](https://example.com/" + payload + ")\n")
+				if !strings.Contains(payload, "/") {
+					if err := tfs.WriteFile("home/guest/"+payload+".md", content); err != nil {
+						t.Fatalf("WriteFile() error = %v", err)
+					}
+					if err := tfs.Mkdir("home/guest/" + payload); err != nil {
+						t.Fatalf("Mkdir() error = %v", err)
+					}
+				}
+				if err := tfs.WriteFile("home/guest/payload.md", content); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+
+				env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+				out, _ := r.Exec(env, line)
+
+				for _, chunk := range out.Chunks() {
+					if strings.Contains(string(chunk.HTML), payload) {
+						t.Errorf("Exec(env, %q) chunk HTML = %q, contains payload unescaped", line, chunk.HTML)
+					}
+					for _, m := range tagPattern.FindAllStringSubmatch(string(chunk.HTML), -1) {
+						if !allowedTags[m[1]] {
+							t.Errorf("Exec(env, %q) chunk HTML = %q, contains markup %q", line, chunk.HTML, m[0])
+						}
+					}
+					for _, m := range hrefPattern.FindAllStringSubmatch(string(chunk.HTML), -1) {
+						if !strings.HasPrefix(m[1], "https://example.com/") {
+							t.Errorf("Exec(env, %q) chunk HTML = %q, links to %q", line, chunk.HTML, m[1])
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package termui
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+)
+
+// pagerMatchClass is the CSS class of the search matches shown by a pager.
+const pagerMatchClass = "pager-match"
+
+// defaultPagerHeight is the number of rows of a pager until the client
+// terminal tells its size.
+const defaultPagerHeight = 24
+
+// Less shows a file, or standard input if no file is given, in a pager: a
+// full-screen view the user pages through with keys until quitting. The
+// pager is requested from the client terminal through env.Output. When
+// standard output is not the terminal, e.g. a pipe, the file is copied to it
+// instead. Errors are returned as *ArgError carrying the file the user
+// attempted to read.
+// Possible errors: ErrMissingArgument, ErrFileNotFound, ErrIsDirectory,
+// ErrTooManyArguments.
+func Less(env *Env, args []string) error {
+	if len(args) > 1 {
+		return ErrTooManyArguments
+	}
+
+	name := inputNames(args)[0]
+	content, err := readInput(env, name)
+	if err != nil {
+		return err
+	}
+	if name == "-" && content == "" {
+		return ErrMissingArgument
+	}
+
+	if !isTerminal(env.Stdout) {
+		_, err := io.WriteString(env.Stdout, content)
+		return err
+	}
+
+	env.Output.Pager = NewPager(displayName(name), content)
+	return nil
+}
+
+// Pager pages through text, one screen at a time, like less. Its state is
+// kept by the caller between the keys the user presses. A Pager is not safe
+// for concurrent use.
+type Pager struct {
+	name  string
+	lines []string
+	// top is the index of the first line shown.
+	top    int
+	height int
+	// pattern is the last pattern searched for, highlighted on the screen.
+	pattern *regexp.Regexp
+	// message replaces the status line until the next key.
+	message string
+}
+
+// NewPager returns a pager showing content, named name in the status line.
+func NewPager(name, content string) *Pager {
+	return &Pager{name: name, lines: splitLines(content), height: defaultPagerHeight}
+}
+
+// SetHeight sets the number of rows of the screen, including the status
+// line. Heights below 2 are treated as 2.
+func (p *Pager) SetHeight(rows int) {
+	p.height = max(rows, 2)
+	p.scrollTo(p.top)
+}
+
+// Key handles a key pressed by the user: space and b move a screen forward
+// and back, j and k a line, g and G to the first and last screen, n and N to
+// the next and previous line matching the last search, and q quits. Other
+// keys are ignored. Key reports whether the pager quit.
+func (p *Pager) Key(key string) (quit bool) {
+	p.message = ""
+
+	switch key {
+	case " ":
+		p.scrollTo(p.top + p.rows())
+	case "b":
+		p.scrollTo(p.top - p.rows())
+	case "j":
+		p.scrollTo(p.top + 1)
+	case "k":
+		p.scrollTo(p.top - 1)
+	case "g":
+		p.scrollTo(0)
+	case "G":
+		p.scrollTo(len(p.lines))
+	case "n":
+		p.find(p.top+1, 1)
+	case "N":
+		p.find(p.top-1, -1)
+	case "q":
+		return true
+	}
+
+	return false
+}
+
+// Search moves to the first line from the top of the screen that matches
+// the regular expression pattern, and highlights its matches. An empty
+// pattern repeats the last search. An invalid pattern is reported in the
+// status line.
+func (p *Pager) Search(pattern string) {
+	p.message = ""
+
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			p.message = "Invalid pattern"
+			return
+		}
+		p.pattern = re
+	}
+
+	p.find(p.top, 1)
+}
+
+// find moves to the first line matching the last search, looking from line
+// start in direction dir, 1 or -1.
+func (p *Pager) find(start, dir int) {
+	if p.pattern == nil {
+		p.message = "No previous regular expression"
+		return
+	}
+
+	for i := start; i >= 0 && i < len(p.lines); i += dir {
+		if p.pattern.MatchString(p.lines[i]) {
+			p.top = i
+			return
+		}
+	}
+	p.message = "Pattern not found"
+}
+
+// rows returns the number of lines of text shown on a screen.
+func (p *Pager) rows() int {
+	return p.height - 1
+}
+
+// scrollTo moves the top of the screen to line top, stopping at the first
+// and last screen.
+func (p *Pager) scrollTo(top int) {
+	p.top = max(min(top, len(p.lines)-p.rows()), 0)
+}
+
+// PagerView is the screen of a pager.
+type PagerView struct {
+	// Lines are the lines of text shown, with search matches highlighted.
+	Lines []template.HTML
+	// Status is the status line, e.g. "notes.txt lines 1-23/80 28%".
+	Status string
+}
+
+// View returns the current screen.
+func (p *Pager) View() PagerView {
+	var view PagerView
+
+	end := min(p.top+p.rows(), len(p.lines))
+	for _, line := range p.lines[p.top:end] {
+		out := new(styledText)
+		last := 0
+		if p.pattern != nil {
+			for _, m := range p.pattern.FindAllStringIndex(line, -1) {
+				if m[0] == m[1] {
+					continue
+				}
+				out.WriteString(line[last:m[0]])
+				out.Element("span", pagerMatchClass, line[m[0]:m[1]])
+				last = m[1]
+			}
+		}
+		out.WriteString(line[last:])
+		view.Lines = append(view.Lines, template.HTML(out.markup.String()))
+	}
+
+	switch {
+	case p.message != "":
+		view.Status = p.message
+	case end == len(p.lines):
+		view.Status = p.name + " (END)"
+	default:
+		view.Status = fmt.Sprintf("%s lines %d-%d/%d %d%%", p.name, p.top+1, end, len(p.lines), end*100/len(p.lines))
+	}
+
+	return view
+}
@@ -0,0 +1,235 @@
+package termui
+
+import (
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLess(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+		wantPager  string
+	}{
+		{
+			name:      "file",
+			line:      "less test-repo.md",
+			wantPager: "test-repo.md (END)",
+		},
+		{
+			name:      "standard input",
+			line:      "cat app.js | more",
+			wantPager: "standard input (END)",
+		},
+		{
+			name:       "piped output",
+			line:       "less app.js | head -n 1",
+			wantOutput: "console.log('hello world');\n",
+		},
+		{
+			name:       "missing file",
+			line:       "less missing.txt",
+			wantOutput: "less: missing.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "directory",
+			line:       "less /home",
+			wantOutput: "less: /home: Is a directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "nothing to show",
+			line:       "less",
+			wantOutput: "less: missing file argument\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "too many files",
+			line:       "less app.js test-repo.md",
+			wantOutput: "less: too many arguments\n",
+			wantStatus: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+
+			var gotPager string
+			if out.Pager != nil {
+				gotPager = out.Pager.View().Status
+			}
+			if gotPager != tt.wantPager {
+				t.Errorf("Exec(env, %q) pager status = %q, want %q", tt.line, gotPager, tt.wantPager)
+			}
+		})
+	}
+}
+
+// numberedLines returns n lines reading "line 1" to "line n".
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "line %d\n", i+1)
+	}
+	return b.String()
+}
+
+func TestPager_key(t *testing.T) {
+	tests := []struct {
+		name       string
+		keys       []string
+		wantFirst  template.HTML
+		wantStatus string
+	}{
+		{
+			name:       "first screen",
+			wantFirst:  "line 1",
+			wantStatus: "notes.txt lines 1-4/10 40%",
+		},
+		{
+			name:       "screen forward",
+			keys:       []string{" "},
+			wantFirst:  "line 5",
+			wantStatus: "notes.txt lines 5-8/10 80%",
+		},
+		{
+			name:       "stops at the last screen",
+			keys:       []string{" ", " ", " "},
+			wantFirst:  "line 7",
+			wantStatus: "notes.txt (END)",
+		},
+		{
+			name:       "screen back",
+			keys:       []string{" ", " ", "b"},
+			wantFirst:  "line 3",
+			wantStatus: "notes.txt lines 3-6/10 60%",
+		},
+		{
+			name:       "lines",
+			keys:       []string{"j", "j", "k"},
+			wantFirst:  "line 2",
+			wantStatus: "notes.txt lines 2-5/10 50%",
+		},
+		{
+			name:       "stops at the first line",
+			keys:       []string{"k"},
+			wantFirst:  "line 1",
+			wantStatus: "notes.txt lines 1-4/10 40%",
+		},
+		{
+			name:       "last and first screens",
+			keys:       []string{"G", "g"},
+			wantFirst:  "line 1",
+			wantStatus: "notes.txt lines 1-4/10 40%",
+		},
+		{
+			name:       "unknown keys",
+			keys:       []string{"x", ""},
+			wantFirst:  "line 1",
+			wantStatus: "notes.txt lines 1-4/10 40%",
+		},
+		{
+			name:       "next match without search",
+			keys:       []string{"n"},
+			wantFirst:  "line 1",
+			wantStatus: "No previous regular expression",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPager("notes.txt", numberedLines(10))
+			p.SetHeight(5)
+			for _, key := range tt.keys {
+				if p.Key(key) {
+					t.Fatalf("Key(%q) = true, want false", key)
+				}
+			}
+
+			view := p.View()
+			if got := view.Lines[0]; got != tt.wantFirst {
+				t.Errorf("after keys %q first line = %q, want %q", tt.keys, got, tt.wantFirst)
+			}
+			if view.Status != tt.wantStatus {
+				t.Errorf("after keys %q status = %q, want %q", tt.keys, view.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPager_quit(t *testing.T) {
+	p := NewPager("notes.txt", numberedLines(10))
+	if !p.Key("q") {
+		t.Errorf("Key(%q) = false, want true", "q")
+	}
+}
+
+func TestPager_search(t *testing.T) {
+	p := NewPager("notes.txt", numberedLines(30)+"<b>line</b>\n")
+	p.SetHeight(5)
+
+	steps := []struct {
+		pattern    string
+		key        string
+		wantFirst  template.HTML
+		wantStatus string
+	}{
+		{pattern: "line 1[0-9]", wantFirst: `<span class="pager-match">line 10</span>`, wantStatus: "notes.txt lines 10-13/31 41%"},
+		{key: "n", wantFirst: `<span class="pager-match">line 11</span>`, wantStatus: "notes.txt lines 11-14/31 45%"},
+		{key: "N", wantFirst: `<span class="pager-match">line 10</span>`, wantStatus: "notes.txt lines 10-13/31 41%"},
+		{pattern: "2$", wantFirst: "line 1<span class=\"pager-match\">2</span>", wantStatus: "notes.txt lines 12-15/31 48%"},
+		{pattern: "", wantFirst: "line 1<span class=\"pager-match\">2</span>", wantStatus: "notes.txt lines 12-15/31 48%"},
+		{pattern: "nope", wantFirst: "line 12", wantStatus: "Pattern not found"},
+		{pattern: "b>l", wantFirst: `&lt;<span class="pager-match">b&gt;l</span>ine&lt;/b&gt;`, wantStatus: "notes.txt (END)"},
+		{pattern: "a(", wantFirst: `&lt;<span class="pager-match">b&gt;l</span>ine&lt;/b&gt;`, wantStatus: "Invalid pattern"},
+	}
+	for _, step := range steps {
+		if step.key != "" {
+			p.Key(step.key)
+		} else {
+			p.Search(step.pattern)
+		}
+
+		view := p.View()
+		if got := view.Lines[0]; got != step.wantFirst {
+			t.Errorf("after pattern %q key %q first line = %q, want %q", step.pattern, step.key, got, step.wantFirst)
+		}
+		if view.Status != step.wantStatus {
+			t.Errorf("after pattern %q key %q status = %q, want %q", step.pattern, step.key, view.Status, step.wantStatus)
+		}
+	}
+}
+
+func TestPager_setHeight(t *testing.T) {
+	p := NewPager("notes.txt", numberedLines(10))
+	p.SetHeight(5)
+	p.Key("G")
+	p.SetHeight(8)
+
+	view := p.View()
+	want := []template.HTML{"line 4", "line 5", "line 6", "line 7", "line 8", "line 9", "line 10"}
+	if !slices.Equal(view.Lines, want) {
+		t.Errorf("View().Lines = %q, want %q", view.Lines, want)
+	}
+
+	p.SetHeight(0)
+	if got := len(p.View().Lines); got != 1 {
+		t.Errorf("SetHeight(0) shows %d lines, want 1", got)
+	}
+}
@@ -0,0 +1,347 @@
+package termui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Command line parse errors.
+var (
+	ErrSyntax            = errors.New("syntax error")
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+)
+
+// SyntaxError describes why a command line could not be parsed. Msg is
+// formatted the way a shell would report it.
+type SyntaxError struct {
+	Msg string
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Msg
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrSyntax
+}
+
+// Control operators separating the pipelines of a List.
+const (
+	OpSeq = ";"
+	OpAnd = "&&"
+	OpOr  = "||"
+)
+
+// List is a sequence of pipelines separated by control operators.
+type List []ListItem
+
+// ListItem is a pipeline and the control operator that precedes it. The
+// operator of the first item is empty.
+type ListItem struct {
+	Op       string
+	Pipeline Pipeline
+}
+
+// Pipeline is a sequence of commands where the standard output of each
+// command is connected to the standard input of the next.
+type Pipeline []SimpleCommand
+
+// Redirection operators.
+const (
+	RedirectOut    = ">"
+	RedirectAppend = ">>"
+)
+
+// SimpleCommand is a command name followed by its arguments, along with the
+// redirections of its standard output.
+type SimpleCommand struct {
+	Args      []Word
+	Redirects []Redirect
+}
+
+// Redirect redirects the standard output of a command to the file Target. Op
+// is RedirectOut to truncate the file or RedirectAppend to append to it.
+type Redirect struct {
+	Op     string
+	Target Word
+}
+
+// empty reports whether the command has neither arguments nor redirections.
+func (c SimpleCommand) empty() bool {
+	return len(c.Args) == 0 && len(c.Redirects) == 0
+}
+
+// Word is a shell word as written on the command line, before expansion.
+type Word []WordPart
+
+// WordPart is a piece of a word with uniform quoting.
+type WordPart struct {
+	Text string
+	// Quote is the quote character that enclosed Text: 0 if unquoted, ' or "
+	// if quoted, or \ if Text is a single backslash escaped character.
+	Quote rune
+}
+
+// String returns the word with quotes removed and without expansion.
+func (w Word) String() string {
+	var b strings.Builder
+	for _, part := range w {
+		b.WriteString(part.Text)
+	}
+	return b.String()
+}
+
+// Parse parses a command line into a list of pipelines. An empty line yields
+// an empty list.
+// Possible errors: ErrSyntax, ErrUnterminatedQuote.
+func Parse(line string) (List, error) {
+	tokens, err := lex(line)
+	if err != nil {
+		return nil, err
+	}
+	return parseList(tokens)
+}
+
+// parseList parses tokens into a list of pipelines. A newline separates
+// pipelines like ;, unless it follows an operator expecting more.
+// Possible errors: ErrSyntax.
+func parseList(tokens []token) (List, error) {
+	var (
+		list     List
+		op       string
+		pipeline Pipeline
+		cmd      SimpleCommand
+	)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.kind {
+		case tokenWord:
+			cmd.Args = append(cmd.Args, tok.word)
+
+		case tokenRedirect:
+			if i+1 >= len(tokens) {
+				return nil, unexpectedTokenError("newline")
+			}
+			i++
+			if tokens[i].kind != tokenWord {
+				return nil, unexpectedTokenError(tokens[i].op)
+			}
+			cmd.Redirects = append(cmd.Redirects, Redirect{Op: tok.op, Target: tokens[i].word})
+
+		case tokenPipe:
+			if cmd.empty() {
+				return nil, unexpectedTokenError(tok.op)
+			}
+			pipeline = append(pipeline, cmd)
+			cmd = SimpleCommand{}
+
+		case tokenControl:
+			if cmd.empty() {
+				return nil, unexpectedTokenError(tok.op)
+			}
+			list = append(list, ListItem{Op: op, Pipeline: append(pipeline, cmd)})
+			op, pipeline, cmd = tok.op, nil, SimpleCommand{}
+
+		case tokenNewline:
+			if cmd.empty() {
+				continue
+			}
+			list = append(list, ListItem{Op: op, Pipeline: append(pipeline, cmd)})
+			op, pipeline, cmd = OpSeq, nil, SimpleCommand{}
+		}
+	}
+
+	if cmd.empty() {
+		// A trailing ; terminates the last pipeline, any other trailing
+		// operator expects another pipeline to follow.
+		if len(pipeline) > 0 || (op != "" && op != OpSeq) {
+			return nil, unexpectedTokenError("newline")
+		}
+		return list, nil
+	}
+
+	return append(list, ListItem{Op: op, Pipeline: append(pipeline, cmd)}), nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPipe
+	tokenControl
+	tokenRedirect
+	tokenNewline
+)
+
+type token struct {
+	kind tokenKind
+	word Word
+	op   string
+	// start and end are the rune offsets of a word in the line.
+	start, end int
+}
+
+// lex splits a command line into tokens the way a POSIX shell does. Words
+// are separated by unquoted whitespace and operators, and newlines are
+// tokens of their own. Single quotes preserve every character literally,
+// double quotes preserve everything except backslash escapes of ", \, $ and
+// `, and an unquoted backslash escapes the character that follows it. Quoted
+// empty strings yield empty words. A # starting a word starts a comment,
+// which runs to the end of the line.
+// Possible errors: ErrSyntax, ErrUnterminatedQuote.
+func lex(line string) ([]token, error) {
+	var (
+		tokens []token
+		word   Word
+		inWord bool
+		// at is the offset of the rune being lexed, and start the offset
+		// of the word being lexed.
+		at, start int
+	)
+
+	endWord := func() {
+		if inWord {
+			tokens = append(tokens, token{kind: tokenWord, word: word, start: start, end: at})
+			word = nil
+			inWord = false
+		}
+	}
+
+	addPart := func(text string, quote rune) {
+		if !inWord {
+			start = at
+		}
+		inWord = true
+		if n := len(word); n > 0 && word[n-1].Quote == quote && quote != '\\' {
+			word[n-1].Text += text
+			return
+		}
+		word = append(word, WordPart{Text: text, Quote: quote})
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		at = i
+		r := runes[i]
+
+		switch {
+		case r == ' ' || r == '\t':
+			endWord()
+
+		case r == '\n':
+			endWord()
+			tokens = append(tokens, token{kind: tokenNewline, op: "newline"})
+
+		case r == '#' && !inWord:
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+
+		case r == '|' || r == '&' || r == ';':
+			endWord()
+
+			op := string(r)
+			if r != ';' && i+1 < len(runes) && runes[i+1] == r {
+				op += string(r)
+				i++
+			}
+
+			switch op {
+			case "|":
+				tokens = append(tokens, token{kind: tokenPipe, op: op})
+			case OpSeq, OpAnd, OpOr:
+				tokens = append(tokens, token{kind: tokenControl, op: op})
+			default:
+				return nil, unexpectedTokenError(op)
+			}
+
+		case r == '>':
+			endWord()
+
+			op := RedirectOut
+			if i+1 < len(runes) && runes[i+1] == '>' {
+				op = RedirectAppend
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenRedirect, op: op})
+
+		case r == '\\':
+			if i+1 >= len(runes) {
+				addPart(string(r), 0)
+				continue
+			}
+			i++
+			if runes[i] == '\n' { // backslash-newline is a line continuation
+				continue
+			}
+			addPart(string(runes[i]), '\\')
+
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, unterminatedQuoteError(r)
+			}
+			addPart(string(runes[i+1:end]), '\'')
+			i = end
+
+		case r == '"':
+			var text strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+					// Escaped characters are kept as separate parts so that
+					// they are not subject to expansion.
+					addPart(text.String(), '"')
+					text.Reset()
+					addPart(string(runes[i]), '\\')
+					continue
+				}
+				text.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, unterminatedQuoteError(r)
+			}
+			addPart(text.String(), '"')
+
+		default:
+			addPart(string(r), 0)
+		}
+	}
+
+	at = len(runes)
+	endWord()
+
+	return tokens, nil
+}
+
+// indexRune returns the index of the first r in runes at or after start, or
+// -1 if r is not present.
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func unexpectedTokenError(tok string) error {
+	return &SyntaxError{Msg: fmt.Sprintf("syntax error near unexpected token `%s'", tok)}
+}
+
+func unterminatedQuoteError(quote rune) error {
+	return &SyntaxError{
+		Msg: fmt.Sprintf("unexpected EOF while looking for matching `%c'", quote),
+		Err: ErrUnterminatedQuote,
+	}
+}
@@ -0,0 +1,312 @@
+package termui
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "empty line",
+			line: "",
+			want: nil,
+		},
+		{
+			name: "whitespace only",
+			line: "  \t ",
+			want: nil,
+		},
+		{
+			name: "simple words",
+			line: "ls -l  projects",
+			want: []string{"ls", "-l", "projects"},
+		},
+		{
+			name: "double quoted word with space",
+			line: `cat "my notes.txt"`,
+			want: []string{"cat", "my notes.txt"},
+		},
+		{
+			name: "single quoted word with space",
+			line: "cd 'dir with space'",
+			want: []string{"cd", "dir with space"},
+		},
+		{
+			name: "quotes joined with unquoted text",
+			line: `cat my" "notes'.txt'`,
+			want: []string{"cat", "my notes.txt"},
+		},
+		{
+			name: "escaped space",
+			line: `cat my\ notes.txt`,
+			want: []string{"cat", "my notes.txt"},
+		},
+		{
+			name: "escaped quote",
+			line: `echo \"hi\'`,
+			want: []string{"echo", `"hi'`},
+		},
+		{
+			name: "escapes inside double quotes",
+			line: `echo "a \"b\" \\ \$HOME \n"`,
+			want: []string{"echo", `a "b" \ $HOME \n`},
+		},
+		{
+			name: "backslash inside single quotes is literal",
+			line: `echo 'a\'`,
+			want: []string{"echo", `a\`},
+		},
+		{
+			name: "double quote inside single quotes",
+			line: `echo '"'`,
+			want: []string{"echo", `"`},
+		},
+		{
+			name: "empty quoted words",
+			line: `echo "" ''`,
+			want: []string{"echo", "", ""},
+		},
+		{
+			name: "line continuation",
+			line: "echo a\\\nb",
+			want: []string{"echo", "ab"},
+		},
+		{
+			name: "pipe operator",
+			line: "ls|cat | cat",
+			want: []string{"ls", "|", "cat", "|", "cat"},
+		},
+		{
+			name: "quoted pipe",
+			line: `echo "|" \|`,
+			want: []string{"echo", "|", "|"},
+		},
+		{
+			name: "control operators",
+			line: "cd a&&ls;pwd || cat",
+			want: []string{"cd", "a", "&&", "ls", ";", "pwd", "||", "cat"},
+		},
+		{
+			name: "trailing backslash",
+			line: `echo a\`,
+			want: []string{"echo", `a\`},
+		},
+		{
+			name: "newlines",
+			line: "ls\n\npwd",
+			want: []string{"ls", "newline", "newline", "pwd"},
+		},
+		{
+			name: "comments",
+			line: "# list\nls # here\necho a#b '#c' \\#d",
+			want: []string{"newline", "ls", "newline", "echo", "a#b", "#c", "#d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := lex(tt.line)
+			if err != nil {
+				t.Fatalf("lex(%q) error = %v, want nil", tt.line, err)
+			}
+
+			var got []string
+			for _, tok := range tokens {
+				if tok.kind == tokenWord {
+					got = append(got, tok.word.String())
+				} else {
+					got = append(got, tok.op)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lex(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantMsg string
+	}{
+		{
+			name:    "unterminated double quote",
+			line:    `cat "my notes.txt`,
+			wantMsg: "shell: unexpected EOF while looking for matching `\"'",
+		},
+		{
+			name:    "unterminated single quote",
+			line:    "cd 'dir",
+			wantMsg: "shell: unexpected EOF while looking for matching `''",
+		},
+		{
+			name:    "escaped closing double quote",
+			line:    `echo "a\"`,
+			wantMsg: "shell: unexpected EOF while looking for matching `\"'",
+		},
+		{
+			name:    "leading pipe",
+			line:    "| ls",
+			wantMsg: "shell: syntax error near unexpected token `|'",
+		},
+		{
+			name:    "double pipe",
+			line:    "ls | | cat",
+			wantMsg: "shell: syntax error near unexpected token `|'",
+		},
+		{
+			name:    "leading control operator",
+			line:    "&& ls",
+			wantMsg: "shell: syntax error near unexpected token `&&'",
+		},
+		{
+			name:    "empty command between separators",
+			line:    "ls ;; pwd",
+			wantMsg: "shell: syntax error near unexpected token `;'",
+		},
+		{
+			name:    "trailing and operator",
+			line:    "ls &&",
+			wantMsg: "shell: syntax error near unexpected token `newline'",
+		},
+		{
+			name:    "background operator",
+			line:    "ls &",
+			wantMsg: "shell: syntax error near unexpected token `&'",
+		},
+		{
+			name:    "trailing pipe",
+			line:    "ls |",
+			wantMsg: "shell: syntax error near unexpected token `newline'",
+		},
+		{
+			name:    "redirect without target",
+			line:    "ls >",
+			wantMsg: "shell: syntax error near unexpected token `newline'",
+		},
+		{
+			name:    "redirect to operator",
+			line:    "ls > | cat",
+			wantMsg: "shell: syntax error near unexpected token `|'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.line)
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.line, err, ErrSyntax)
+			}
+			if got := FormatError("shell", err); got != tt.wantMsg {
+				t.Errorf("FormatError(%q, %v) = %q, want %q", "shell", err, got, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "empty line",
+			line: "",
+			want: "",
+		},
+		{
+			name: "single command",
+			line: "ls -l",
+			want: "[ls -l]",
+		},
+		{
+			name: "pipeline",
+			line: `cat "my notes.txt" | cat | cat -`,
+			want: "[cat my notes.txt | cat | cat -]",
+		},
+		{
+			name: "list",
+			line: "cd projects && ls -l | cat; pwd || echo failed;",
+			want: "[cd projects] && [ls -l | cat] ; [pwd] || [echo failed]",
+		},
+		{
+			name: "redirects",
+			line: `ls -l > listing.txt; echo hi>>"my notes.txt"`,
+			want: "[ls -l > listing.txt] ; [echo hi >> my notes.txt]",
+		},
+		{
+			name: "redirect before command",
+			line: "> out.txt echo hi",
+			want: "[echo hi > out.txt]",
+		},
+		{
+			name: "redirect only",
+			line: "> empty.txt",
+			want: "[> empty.txt]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v, want nil", tt.line, err)
+			}
+			if got := formatList(list); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_quoting(t *testing.T) {
+	list, err := Parse(`echo a'$b'"$c\$d"\$e`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want nil", err)
+	}
+
+	got := list[0].Pipeline[0].Args[1]
+	want := Word{
+		{Text: "a", Quote: 0},
+		{Text: "$b", Quote: '\''},
+		{Text: "$c", Quote: '"'},
+		{Text: "$", Quote: '\\'},
+		{Text: "d", Quote: '"'},
+		{Text: "$", Quote: '\\'},
+		{Text: "e", Quote: 0},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Parse() word = %+v, want %+v", got, want)
+	}
+}
+
+// formatList formats a parsed list for comparison in tests.
+func formatList(list List) string {
+	var items []string
+	for _, item := range list {
+		var cmds []string
+		for _, cmd := range item.Pipeline {
+			var args []string
+			for _, arg := range cmd.Args {
+				args = append(args, arg.String())
+			}
+			for _, redirect := range cmd.Redirects {
+				args = append(args, redirect.Op, redirect.Target.String())
+			}
+			cmds = append(cmds, strings.Join(args, " "))
+		}
+		s := "[" + strings.Join(cmds, " | ") + "]"
+		if item.Op != "" {
+			s = item.Op + " " + s
+		}
+		items = append(items, s)
+	}
+	return strings.Join(items, " ")
+}
@@ -0,0 +1,368 @@
+package termui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// node is a command of a script: a List, or one of the compound commands
+// below, built from the commands in their bodies.
+type node any
+
+// ifNode is an if command. The body of the first condition that succeeds
+// runs, or elseBody if none does.
+type ifNode struct {
+	conds    [][]node
+	bodies   [][]node
+	elseBody []node
+}
+
+// forNode is a for command, running body with the variable name set to each
+// of words in turn, or to each positional parameter if inWords is false.
+type forNode struct {
+	name    string
+	words   []Word
+	inWords bool
+	body    []node
+}
+
+// loopNode is a while command, running body as long as cond succeeds, or an
+// until command, running body as long as cond fails.
+type loopNode struct {
+	until bool
+	cond  []node
+	body  []node
+}
+
+// funcNode defines the function name, whose body runs when a command calls
+// it.
+type funcNode struct {
+	name string
+	body []node
+}
+
+// groupNode is a { ...; } command, running body.
+type groupNode struct {
+	body []node
+}
+
+// reservedWords are the words that start or end compound commands when
+// they are the first word of a command.
+var reservedWords = []string{"if", "then", "elif", "else", "fi", "for", "in", "while", "until", "do", "done", "{", "}", "function"}
+
+// scriptParser parses the tokens of a script into commands.
+type scriptParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseScript parses a script, or a command line, into the commands it runs
+// in order. Besides lists of pipelines, a script may have the compound
+// commands:
+//
+//	if list; then list; [elif list; then list;]... [else list;] fi
+//	for name [in word...]; do list; done
+//	while list; do list; done
+//	until list; do list; done
+//	{ list; }
+//	name() { list; }
+//	function name { list; }
+//
+// where list is a sequence of commands separated by ; or newlines. A
+// compound command must be separated from the next command by ; or a
+// newline, and cannot be part of a pipeline or of an && or || list.
+// Possible errors: ErrSyntax, ErrUnterminatedQuote.
+func parseScript(src string) ([]node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &scriptParser{tokens: tokens}
+	nodes, term, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	if term != "" {
+		return nil, unexpectedTokenError(term)
+	}
+
+	return nodes, nil
+}
+
+// parseBody parses commands up to the first of the reserved words terms in
+// command position, which is returned without being consumed, or to the
+// end of the script. The end of the script is an error if terms are given.
+func (p *scriptParser) parseBody(terms ...string) ([]node, string, error) {
+	var nodes []node
+	for {
+		p.skipNewlines()
+		if p.pos >= len(p.tokens) {
+			if len(terms) > 0 {
+				return nil, "", unexpectedEOFError()
+			}
+			return nodes, "", nil
+		}
+
+		if word := p.reservedWord(); slices.Contains(terms, word) {
+			return nodes, word, nil
+		}
+
+		n, err := p.parseCommand()
+		if err != nil {
+			return nil, "", err
+		}
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+}
+
+// parseCommand parses the command at the current token.
+func (p *scriptParser) parseCommand() (node, error) {
+	switch word := p.reservedWord(); word {
+	case "if":
+		return p.parseIf()
+	case "for":
+		return p.parseFor()
+	case "while", "until":
+		return p.parseLoop(word == "until")
+	case "{":
+		p.pos++
+		body, err := p.parseCompoundBody("}")
+		if err != nil {
+			return nil, err
+		}
+		return &groupNode{body: body}, p.endCompound()
+	case "function":
+		p.pos++
+		name, ok := p.word()
+		if !ok {
+			return nil, p.unexpected()
+		}
+		p.pos++
+		return p.parseFunc(strings.TrimSuffix(name, "()"))
+	case "":
+	default:
+		return nil, p.unexpected()
+	}
+
+	// A function definition: name() or name ().
+	if name, ok := p.word(); ok {
+		if fname, ok := strings.CutSuffix(name, "()"); ok && fname != "" {
+			p.pos++
+			return p.parseFunc(fname)
+		}
+		if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == tokenWord && p.tokens[p.pos+1].word.String() == "()" {
+			p.pos += 2
+			return p.parseFunc(name)
+		}
+	}
+
+	return p.parsePipelines()
+}
+
+// parsePipelines parses a list of pipelines, up to a newline or a reserved
+// word in command position.
+func (p *scriptParser) parsePipelines() (node, error) {
+	start := p.pos
+	// command reports whether the next word is in command position, and
+	// more whether the list expects another pipeline, after |, && or ||.
+	command, more := true, false
+	for ; p.pos < len(p.tokens); p.pos++ {
+		tok := p.tokens[p.pos]
+		switch {
+		case tok.kind == tokenNewline:
+			if more {
+				continue
+			}
+			return parseList(p.tokens[start:p.pos])
+		case tok.kind == tokenPipe || tok.kind == tokenControl:
+			command, more = true, tok.op != OpSeq
+			continue
+		case tok.kind == tokenRedirect, p.pos > start && p.tokens[p.pos-1].kind == tokenRedirect:
+			continue
+		}
+
+		if command && p.reservedWord() != "" {
+			if more {
+				return nil, p.unexpected()
+			}
+			return parseList(p.tokens[start:p.pos])
+		}
+		command, more = false, false
+	}
+
+	return parseList(p.tokens[start:])
+}
+
+func (p *scriptParser) parseIf() (node, error) {
+	n := new(ifNode)
+	p.pos++
+	for {
+		cond, err := p.parseCompoundBody("then")
+		if err != nil {
+			return nil, err
+		}
+		body, term, err := p.parseBody("elif", "else", "fi")
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			return nil, p.unexpected()
+		}
+		p.pos++
+		n.conds = append(n.conds, cond)
+		n.bodies = append(n.bodies, body)
+
+		switch term {
+		case "elif":
+			continue
+		case "else":
+			if n.elseBody, err = p.parseCompoundBody("fi"); err != nil {
+				return nil, err
+			}
+		}
+		return n, p.endCompound()
+	}
+}
+
+func (p *scriptParser) parseFor() (node, error) {
+	p.pos++
+	name, ok := p.word()
+	if !ok {
+		return nil, p.unexpected()
+	}
+	if !isValidName(name) {
+		return nil, &SyntaxError{Msg: fmt.Sprintf("`%s': not a valid identifier", name)}
+	}
+	p.pos++
+
+	n := &forNode{name: name}
+	p.skipNewlines()
+	if p.reservedWord() == "in" {
+		n.inWords = true
+		for p.pos++; p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenWord; p.pos++ {
+			n.words = append(n.words, p.tokens[p.pos].word)
+		}
+	}
+	if p.pos < len(p.tokens) && p.tokens[p.pos].op == OpSeq {
+		p.pos++
+	}
+	p.skipNewlines()
+	if p.reservedWord() != "do" {
+		return nil, p.unexpected()
+	}
+	p.pos++
+
+	body, err := p.parseCompoundBody("done")
+	if err != nil {
+		return nil, err
+	}
+	n.body = body
+	return n, p.endCompound()
+}
+
+func (p *scriptParser) parseLoop(until bool) (node, error) {
+	p.pos++
+	cond, err := p.parseCompoundBody("do")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.parseCompoundBody("done")
+	if err != nil {
+		return nil, err
+	}
+	return &loopNode{until: until, cond: cond, body: body}, p.endCompound()
+}
+
+// parseFunc parses the body of the function name, after its name.
+func (p *scriptParser) parseFunc(name string) (node, error) {
+	if !isValidName(name) {
+		return nil, &SyntaxError{Msg: fmt.Sprintf("`%s': not a valid identifier", name)}
+	}
+
+	p.skipNewlines()
+	if p.reservedWord() != "{" {
+		return nil, p.unexpected()
+	}
+	p.pos++
+	body, err := p.parseCompoundBody("}")
+	if err != nil {
+		return nil, err
+	}
+	return &funcNode{name: name, body: body}, p.endCompound()
+}
+
+// parseCompoundBody parses commands up to the reserved word term, which is
+// consumed. Bodies cannot be empty.
+func (p *scriptParser) parseCompoundBody(term string) ([]node, error) {
+	body, _, err := p.parseBody(term)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, p.unexpected()
+	}
+	p.pos++
+	return body, nil
+}
+
+// endCompound checks that a compound command is followed by the end of the
+// script, a separator, which is consumed, or a reserved word.
+func (p *scriptParser) endCompound() error {
+	if p.pos >= len(p.tokens) || p.reservedWord() != "" {
+		return nil
+	}
+	switch tok := p.tokens[p.pos]; {
+	case tok.kind == tokenNewline, tok.op == OpSeq:
+		p.pos++
+		return nil
+	default:
+		return p.unexpected()
+	}
+}
+
+// word returns the current token if it is a word, without its quotes.
+func (p *scriptParser) word() (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenWord {
+		return "", false
+	}
+	return p.tokens[p.pos].word.String(), true
+}
+
+// reservedWord returns the current token if it is an unquoted reserved
+// word, or "".
+func (p *scriptParser) reservedWord() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenWord {
+		return ""
+	}
+	word, ok := unquotedWord(p.tokens[p.pos].word)
+	if !ok || !slices.Contains(reservedWords, word) {
+		return ""
+	}
+	return word
+}
+
+func (p *scriptParser) skipNewlines() {
+	for p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenNewline {
+		p.pos++
+	}
+}
+
+// unexpected returns the syntax error of an unexpected current token.
+func (p *scriptParser) unexpected() error {
+	if p.pos >= len(p.tokens) {
+		return unexpectedEOFError()
+	}
+	tok := p.tokens[p.pos]
+	if tok.kind == tokenWord {
+		return unexpectedTokenError(tok.word.String())
+	}
+	return unexpectedTokenError(tok.op)
+}
+
+func unexpectedEOFError() error {
+	return &SyntaxError{Msg: "syntax error: unexpected end of file"}
+}
@@ -0,0 +1,343 @@
+package termui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseScript_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "unterminated if",
+			src:     "if true; then echo a",
+			wantErr: ErrSyntax,
+			wantMsg: "syntax error: unexpected end of file",
+		},
+		{
+			name:    "missing then",
+			src:     "if true; fi",
+			wantErr: ErrSyntax,
+			wantMsg: "syntax error near unexpected token `fi'",
+		},
+		{
+			name:    "empty body",
+			src:     "if true; then fi",
+			wantErr: ErrSyntax,
+			wantMsg: "syntax error near unexpected token `fi'",
+		},
+		{
+			name:    "stray done",
+			src:     "echo a; done",
+			wantErr: ErrSyntax,
+			wantMsg: "syntax error near unexpected token `done'",
+		},
+		{
+			name:    "compound command in pipeline",
+			src:     "ls | while true; do :; done",
+			wantErr: ErrSyntax,
+			wantMsg: "syntax error near unexpected token `while'",
+		},
+		{
+			name:    "missing separator after compound command",
+			src:     "{ ls; } pwd",
+			wantErr: ErrSyntax,
+			wantMsg: "syntax error near unexpected token `pwd'",
+		},
+		{
+			name:    "invalid for variable",
+			src:     "for 1 in a; do echo; done",
+			wantErr: ErrSyntax,
+			wantMsg: "`1': not a valid identifier",
+		},
+		{
+			name:    "unterminated quote",
+			src:     "for f in 'a; do echo; done",
+			wantErr: ErrUnterminatedQuote,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseScript(tt.src)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseScript(%q) error = %v, want %v", tt.src, err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && err.Error() != tt.wantMsg {
+				t.Errorf("parseScript(%q) error = %q, want %q", tt.src, err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestRegistry_exec_script(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+
+	err := tfs.WriteFile("home/zorcal/projects/greet.sh", []byte(`# greet greets each argument.
+greet() {
+	echo "hello, $1"
+}
+
+for name in "$@"; do
+	greet "$name"
+done
+cd ..
+GREETED=$#
+`))
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "if",
+			line:       "if [ -f app.js ]; then echo file; else echo none; fi",
+			wantOutput: "file\n",
+		},
+		{
+			name:       "elif",
+			line:       "if false; then echo a; elif true; then echo b; else echo c; fi",
+			wantOutput: "b\n",
+		},
+		{
+			name:       "if without a branch taken",
+			line:       "if false; then echo a; fi; echo $?",
+			wantOutput: "0\n",
+		},
+		{
+			name:       "for over words and globs",
+			line:       "for f in a 'b c' *.md; do echo \"[$f]\"; done",
+			wantOutput: "[a]\n[b c]\n[test-repo.md]\n",
+		},
+		{
+			name:       "while",
+			line:       "X=; while [ -z \"$X\" ]; do echo once; X=1; done",
+			wantOutput: "once\n",
+		},
+		{
+			name:       "until with break and continue",
+			line:       "until false; do for f in a b; do continue; echo no; done; echo $f; break; done",
+			wantOutput: "b\n",
+		},
+		{
+			name:       "function with return status",
+			line:       "f() { echo \"$# $1\"; return 3; echo no; }; f a b; echo $?",
+			wantOutput: "2 a\n3\n",
+		},
+		{
+			name:       "assignment for one command",
+			line:       "X=1; X=2 env | grep '^X='; echo $X",
+			wantOutput: "X=2\n1\n",
+		},
+		{
+			name:       "multi line script",
+			line:       "if true\nthen\n\techo a # comment\nfi",
+			wantOutput: "a\n",
+		},
+		{
+			name:       "sh runs in a subshell",
+			line:       "sh greet.sh you 'and you'; pwd; echo \"[$GREETED]\"",
+			wantOutput: "hello, you\nhello, and you\n/home/zorcal/projects\n[]\n",
+		},
+		{
+			name:       "script by path",
+			line:       "./greet.sh me",
+			wantOutput: "hello, me\n",
+		},
+		{
+			name:       "source runs in the current shell",
+			line:       "source greet.sh me; pwd; echo \"[$GREETED]\"; greet again",
+			wantOutput: "hello, me\n/home/zorcal\n[1]\nhello, again\n",
+		},
+		{
+			name:       "sh reads standard input",
+			line:       "echo 'echo $0 $#' | sh",
+			wantOutput: "sh 0\n",
+		},
+		{
+			name:       "exit stops the script",
+			line:       "echo 'echo a; exit 4; echo b' > exit.sh; sh exit.sh; echo $?",
+			wantOutput: "a\n4\n",
+		},
+		{
+			name:       "exit stops the command line",
+			line:       "exit 5; echo no",
+			wantStatus: 5,
+		},
+		{
+			name:       "shift",
+			line:       "f() { shift; echo \"$@\"; shift 3; echo $?; }; f a b c",
+			wantOutput: "b c\n1\n",
+		},
+		{
+			name:       "missing script",
+			line:       "sh missing.sh",
+			wantOutput: "sh: missing.sh: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "script with a syntax error",
+			line:       "echo 'if true' > bad.sh; ./bad.sh",
+			wantOutput: "./bad.sh: syntax error: unexpected end of file\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "script not found in PATH",
+			line:       "greet.sh",
+			wantOutput: "shell: greet.sh: command not found...\n",
+			wantStatus: 127,
+		},
+		{
+			name:       "script in PATH",
+			line:       "tour.sh | head -n 1",
+			wantOutput: "Welcome to the tour of Zorcal's terminal!\n",
+		},
+		{
+			name:       "step limit",
+			line:       "while true; do :; done; echo no",
+			wantOutput: "shell: step limit exceeded, stopped\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "step limit in a function",
+			line:       "f() { f; }; f; echo no",
+			wantOutput: "shell: step limit exceeded, stopped\n",
+			wantStatus: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+			sessMgr.SetLastStatus(sessionID, 0)
+			sessMgr.UnsetEnv(sessionID, "GREETED")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRegistry_exec_sourceFunctions(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: "session1"}
+	r := NewRegistry()
+
+	if err := tfs.WriteFile("home/guest/lib.sh", []byte("greet() { echo \"hello, $1\"; }\nLIB=1\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v, want nil", err)
+	}
+
+	// The functions a sourced script defines last for the rest of the
+	// command line, and its variables for the rest of the session.
+	lines := []struct {
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{line: "source lib.sh; greet a", wantOutput: "hello, a\n"},
+		{line: "echo $LIB; greet b", wantOutput: "1\nshell: greet: command not found...\n", wantStatus: 127},
+	}
+	for _, tt := range lines {
+		out, status := r.Exec(env, tt.line)
+		if status != tt.wantStatus {
+			t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+		}
+		if got := out.String(); got != tt.wantOutput {
+			t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+		}
+	}
+}
+
+func TestRegistry_exec_tour(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	env := &Env{FS: tfs, Sessions: sessMgr, SessionID: "session1"}
+
+	out, status := NewRegistry().Exec(env, "tour.sh")
+	if status != 0 {
+		t.Errorf("Exec(env, %q) status = %d, want 0, output:\n%s", "tour.sh", status, out.String())
+	}
+	for _, want := range []string{"== Projects ==\n  /home/zorcal/projects/test-repo.md\n", "$ head -n 5 /home/zorcal/projects/test-repo.md\n# test-repo\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Exec(env, %q) output = %q, want it to contain %q", "tour.sh", out.String(), want)
+		}
+	}
+}
+
+func TestRegistry_exec_limits(t *testing.T) {
+	// big.txt holds 10KiB, so that a loop writing it exceeds the output
+	// limit well before the step limit.
+	const bigFile = "x=0123456789; for i in 1 2 3 4 5 6 7 8 9 10; do x=$x$x; done; echo $x > big.txt; "
+
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+	}{
+		{
+			name:    "doubling variable",
+			line:    "x=a; while true; do x=$x$x; done; echo no",
+			wantErr: "shell: value limit exceeded, stopped\n",
+		},
+		{
+			name:    "doubling exported variable",
+			line:    "x=a; while true; do export x=$x$x; done; echo no",
+			wantErr: "shell: value limit exceeded, stopped\n",
+		},
+		{
+			name:    "doubling argument",
+			line:    "f() { f $1$1; }; f a; echo no",
+			wantErr: "shell: value limit exceeded, stopped\n",
+		},
+		{
+			name:    "output loop",
+			line:    bigFile + "while true; do cat big.txt; done; echo no",
+			wantErr: "shell: output limit exceeded, stopped\n",
+		},
+		{
+			name:    "output loop into a pipe",
+			line:    bigFile + "echo 'while true; do cat big.txt; done' > loop.sh; sh loop.sh | wc -c; echo no",
+			wantErr: "shell: output limit exceeded, stopped\n",
+		},
+		{
+			name:    "output loop into a file",
+			line:    bigFile + "echo 'while true; do cat big.txt; done' > loop.sh; sh loop.sh > out.txt; echo no",
+			wantErr: "shell: output limit exceeded, stopped\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: "session1"}
+
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != 1 {
+				t.Errorf("Exec(env, %q) status = %d, want 1", tt.line, status)
+			}
+			got := out.String()
+			if !strings.Contains(got, tt.wantErr) {
+				t.Errorf("Exec(env, %q) output does not contain %q", tt.line, tt.wantErr)
+			}
+			if strings.HasSuffix(got, "no\n") {
+				t.Errorf("Exec(env, %q) went on after the limit was exceeded", tt.line)
+			}
+			if len(got) > maxOutputSize+len(tt.wantErr)+64<<10 {
+				t.Errorf("Exec(env, %q) output is %d bytes, want at most about %d", tt.line, len(got), maxOutputSize)
+			}
+		})
+	}
+}
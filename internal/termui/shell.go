@@ -0,0 +1,553 @@
+package termui
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxSteps is the number of commands and loop iterations a command line may
+// run, counting those of the scripts and functions it calls, so that a loop
+// cannot run forever.
+const maxSteps = 10000
+
+// maxValueSize is the number of bytes a word may expand to, which bounds the
+// values of variables and arguments, so that a loop doubling a value cannot
+// exhaust memory. It is below EnvQuota, so that such a loop stops the command
+// line rather than failing on the quota every step.
+const maxValueSize = 16 << 10
+
+// flow tells a shell how to go on after a command. Commands leaving loops,
+// functions and scripts early change it from flowNext.
+type flow int
+
+const (
+	flowNext flow = iota
+	flowBreak
+	flowContinue
+	flowReturn
+	flowExit
+	// flowAbort stops every shell once a limit of the command line is
+	// exceeded.
+	flowAbort
+)
+
+// shell runs the commands of a command line or a script. The function or
+// script a command calls runs in a shell of its own, with its own
+// positional parameters and control flow, but the same step count.
+type shell struct {
+	r *Registry
+	// env is the environment the commands run in. Its Stdin is the standard
+	// input of the first command of each pipeline.
+	env *Env
+	// stdout is the standard output of the last command of each pipeline,
+	// or nil for the terminal.
+	stdout io.Writer
+	// params are the positional parameters, starting with $0.
+	params []string
+	// funcs are the bodies of the functions defined, by name.
+	funcs map[string][]node
+	// steps is the number of steps run, shared by the shells of a command
+	// line.
+	steps *int
+	// status is the exit status of the last command, the value of $?.
+	status int
+	flow   flow
+	// loops is the number of loops running, which break and continue leave.
+	loops int
+}
+
+// newShell returns a shell running commands in env.
+func (r *Registry) newShell(env *Env) *shell {
+	return &shell{
+		r:      r,
+		env:    env,
+		params: []string{"shell"},
+		funcs:  make(map[string][]node),
+		steps:  new(int),
+		status: env.Sessions.GetLastStatus(env.SessionID),
+	}
+}
+
+// call returns a shell running a function or a script called by a command
+// running in env, with the positional parameters params.
+func (sh *shell) call(env *Env, params []string) *shell {
+	child := &shell{
+		r:      sh.r,
+		env:    env,
+		params: params,
+		funcs:  sh.funcs,
+		steps:  sh.steps,
+		status: sh.status,
+	}
+	if !isTerminal(env.Stdout) {
+		child.stdout = env.Stdout
+	}
+	return child
+}
+
+// returnFrom ends the shell of a function or script called by sh, returning
+// its exit status as the error of the command that called it. An exit from
+// a function or a sourced script exits sh as well.
+func (sh *shell) returnFrom(child *shell) error {
+	if child.flow == flowExit || child.flow == flowAbort {
+		sh.flow = child.flow
+	}
+	return statusError(child.status)
+}
+
+// run runs nodes in order, until one changes the flow.
+func (sh *shell) run(nodes []node) {
+	for _, n := range nodes {
+		if sh.flow != flowNext {
+			return
+		}
+
+		switch n := n.(type) {
+		case List:
+			sh.runList(n)
+		case *ifNode:
+			sh.runIf(n)
+		case *forNode:
+			sh.runFor(n)
+		case *loopNode:
+			sh.runLoop(n)
+		case *funcNode:
+			sh.funcs[n.name] = n.body
+			sh.status = 0
+		case *groupNode:
+			sh.run(n.body)
+		}
+	}
+}
+
+// runList runs the pipelines of list in order, subject to the && and ||
+// operators joining them.
+func (sh *shell) runList(list List) {
+	for _, item := range list {
+		if (item.Op == OpAnd && sh.status != 0) || (item.Op == OpOr && sh.status == 0) {
+			continue
+		}
+		if !sh.step() {
+			return
+		}
+		sh.status = sh.execPipeline(item.Pipeline)
+		switch {
+		case sh.flow == flowAbort:
+			// A limit exceeded by a command of the pipeline fails it,
+			// whatever the status of its last command.
+			sh.status = 1
+		case sh.flow == flowNext && sh.env.Output.full():
+			sh.abort(ErrOutputLimit)
+		}
+	}
+}
+
+func (sh *shell) runIf(n *ifNode) {
+	for i, cond := range n.conds {
+		sh.run(cond)
+		if sh.flow != flowNext {
+			return
+		}
+		if sh.status == 0 {
+			sh.run(n.bodies[i])
+			return
+		}
+	}
+
+	sh.status = 0
+	sh.run(n.elseBody)
+}
+
+func (sh *shell) runFor(n *forNode) {
+	values := sh.params[1:]
+	if n.inWords {
+		exp := sh.expander()
+		values = nil
+		for _, w := range n.words {
+			values = append(values, exp.fields(w)...)
+		}
+		if err := checkValues(values); err != nil {
+			sh.abort(err)
+			return
+		}
+	}
+
+	sh.status = 0
+	for _, value := range values {
+		if !sh.step() {
+			return
+		}
+		if err := sh.env.Sessions.SetEnv(sh.env.SessionID, n.name, value); err != nil {
+			writeError(sh.env.Output.Writer(stderrClass), "shell", &ArgError{Arg: n.name, Err: err})
+			sh.status = 1
+			return
+		}
+		if !sh.runLoopBody(n.body) {
+			return
+		}
+	}
+}
+
+func (sh *shell) runLoop(n *loopNode) {
+	status := 0
+	for sh.step() {
+		sh.run(n.cond)
+		if sh.flow != flowNext || (sh.status == 0) == n.until {
+			break
+		}
+
+		goOn := sh.runLoopBody(n.body)
+		status = sh.status
+		if !goOn {
+			break
+		}
+	}
+
+	if sh.flow == flowNext {
+		sh.status = status
+	}
+}
+
+// runLoopBody runs the body of a loop and reports whether the loop goes on.
+func (sh *shell) runLoopBody(body []node) bool {
+	sh.loops++
+	sh.run(body)
+	sh.loops--
+
+	switch sh.flow {
+	case flowBreak:
+		sh.flow = flowNext
+		return false
+	case flowContinue:
+		sh.flow = flowNext
+	}
+	return sh.flow == flowNext
+}
+
+// step counts a step of the command line and reports whether it may go on.
+// The step that exceeds the step limit aborts it.
+func (sh *shell) step() bool {
+	if sh.flow != flowNext {
+		return false
+	}
+
+	*sh.steps++
+	if *sh.steps > maxSteps {
+		sh.abort(ErrStepLimit)
+		return false
+	}
+	return true
+}
+
+// abort stops every shell of the command line, reporting the limit err that
+// was exceeded.
+func (sh *shell) abort(err error) {
+	writeError(sh.env.Output.errorWriter(), "shell", err)
+	sh.flow, sh.status = flowAbort, 1
+}
+
+// checkValues returns ErrValueLimit if one of values is longer than
+// maxValueSize.
+func checkValues(values []string) error {
+	for _, v := range values {
+		if len(v) > maxValueSize {
+			return ErrValueLimit
+		}
+	}
+	return nil
+}
+
+// expander returns an expander of the words of the next command.
+func (sh *shell) expander() *expander {
+	return &expander{
+		vars:       sh.env.Sessions.GetEnv(sh.env.SessionID),
+		params:     sh.params,
+		lastStatus: sh.status,
+		fsys:       sh.env.FS,
+		dir:        sh.env.Sessions.GetCurrentDir(sh.env.SessionID),
+	}
+}
+
+// runCommand runs the command args[0] with the arguments args[1:] in env: a
+// function, a registered command, or else a script, found in $PATH unless
+// the name contains a slash.
+// Possible errors: ErrCommandNotFound, or any error returned by the command.
+func (sh *shell) runCommand(env *Env, args []string) error {
+	name := args[0]
+	if body, ok := sh.funcs[name]; ok {
+		child := sh.call(env, append([]string{sh.params[0]}, args[1:]...))
+		child.run(body)
+		return sh.returnFrom(child)
+	}
+
+	if _, ok := sh.r.Lookup(name); ok || name == "" {
+		return sh.r.Run(env, name, args[1:])
+	}
+
+	if !strings.Contains(name, "/") {
+		var found bool
+		if name, found = lookPath(env, name); !found {
+			return &ArgError{Arg: args[0], Err: ErrCommandNotFound}
+		}
+	}
+	return runScript(env, name, args[1:])
+}
+
+// lookPath returns the path of the file name in the directories of $PATH,
+// and reports whether there is one.
+func lookPath(env *Env, name string) (string, bool) {
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+	for _, dir := range strings.Split(env.Sessions.GetEnv(env.SessionID)["PATH"], ":") {
+		if dir == "" {
+			continue
+		}
+		p := path.Join(dir, name)
+		if info, err := fs.Stat(env.FS, fsPath(resolvePath(currDir, p))); err == nil && !info.IsDir() {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// readScript reads and parses the script in the file name, or standard input
+// if name is "-". A script that does not parse is reported to standard error
+// and returned as the exit status 2.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrAccessDenied.
+func readScript(env *Env, name string) ([]node, error) {
+	src, err := readInput(env, name)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := parseScript(src)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, FormatError(displayName(name), err))
+		return nil, ExitStatus(2)
+	}
+	return nodes, nil
+}
+
+// runScript runs the script in the file name, or standard input if name is
+// "-", with the positional parameters args in a subshell: the changes the
+// script makes to the session, such as its variables and directory, and the
+// functions it defines, end with it.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrAccessDenied.
+func runScript(env *Env, name string, args []string) error {
+	nodes, err := readScript(env, name)
+	if err != nil {
+		return err
+	}
+
+	arg0 := name
+	if name == "-" {
+		arg0 = "sh"
+	}
+
+	subEnv := *env
+	subEnv.Sessions = newSubshellSessions(env)
+	sh := env.shell.call(&subEnv, append([]string{arg0}, args...))
+	sh.funcs = make(map[string][]node)
+	sh.run(nodes)
+
+	if sh.flow == flowAbort {
+		env.shell.flow = flowAbort
+	}
+	return statusError(sh.status)
+}
+
+// Sh runs the shell script in a file, or read from standard input if no file
+// is given, with the arguments after the file as positional parameters. The
+// script runs in a subshell, so that the variables it sets and the
+// directory it changes to do not outlast it. The exit status is that of the
+// last command the script ran.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrAccessDenied.
+func Sh(env *Env, args []string) error {
+	if len(args) == 0 {
+		return runScript(env, "-", nil)
+	}
+	return runScript(env, args[0], args[1:])
+}
+
+// Source runs the shell script in a file in the current shell, so that the
+// variables and aliases it defines, and the directory it changes to, stay in
+// effect. The functions it defines only last until the end of the command
+// line, like every function. Arguments after the file are the positional
+// parameters while it runs. The exit status is that of the last command the
+// script ran.
+// Possible errors: ErrMissingArgument, ErrFileNotFound, ErrIsDirectory,
+// ErrAccessDenied.
+func Source(env *Env, args []string) error {
+	if len(args) == 0 {
+		return ErrMissingArgument
+	}
+
+	nodes, err := readScript(env, args[0])
+	if err != nil {
+		return err
+	}
+
+	sh := env.shell
+	params := sh.params
+	if len(args) > 1 {
+		params = append([]string{sh.params[0]}, args[1:]...)
+	}
+	child := sh.call(env, params)
+	child.run(nodes)
+	return sh.returnFrom(child)
+}
+
+// Return leaves the function or script running, with the exit status given,
+// or else that of the last command.
+// Possible errors: ErrNotNumeric, ErrTooManyArguments.
+func Return(env *Env, args []string) error {
+	return leave(env, args, flowReturn)
+}
+
+// Exit leaves the script running, or stops the command line, with the exit
+// status given, or else that of the last command.
+// Possible errors: ErrNotNumeric, ErrTooManyArguments.
+func Exit(env *Env, args []string) error {
+	return leave(env, args, flowExit)
+}
+
+// leave changes the flow of the shell running the command to f, returning
+// the exit status given by args.
+func leave(env *Env, args []string, f flow) error {
+	status := env.shell.status
+	switch len(args) {
+	case 0:
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return &ArgError{Arg: args[0], Err: ErrNotNumeric}
+		}
+		status = n & 0xff
+	default:
+		return ErrTooManyArguments
+	}
+
+	env.shell.flow = f
+	return statusError(status)
+}
+
+// Shift removes the first N positional parameters, or the first one, so that
+// $1 is the next. It fails without changing them if there are fewer than N.
+// Possible errors: ErrNotNumeric, ErrTooManyArguments.
+func Shift(env *Env, args []string) error {
+	n := 1
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 0 {
+			return &ArgError{Arg: args[0], Err: ErrNotNumeric}
+		}
+	default:
+		return ErrTooManyArguments
+	}
+
+	sh := env.shell
+	if n > len(sh.params)-1 {
+		return ExitStatus(1)
+	}
+	sh.params = slices.Delete(slices.Clone(sh.params), 1, 1+n)
+	return nil
+}
+
+// Break leaves the innermost loop running. Outside loops it does nothing.
+// Possible errors: ErrTooManyArguments.
+func Break(env *Env, args []string) error {
+	return leaveLoop(env, args, flowBreak)
+}
+
+// Continue goes on with the next iteration of the innermost loop running.
+// Outside loops it does nothing.
+// Possible errors: ErrTooManyArguments.
+func Continue(env *Env, args []string) error {
+	return leaveLoop(env, args, flowContinue)
+}
+
+func leaveLoop(env *Env, args []string, f flow) error {
+	if len(args) > 0 {
+		return ErrTooManyArguments
+	}
+	if env.shell.loops > 0 {
+		env.shell.flow = f
+	}
+	return nil
+}
+
+// statusError returns the error of a command exiting with status.
+func statusError(status int) error {
+	if status == 0 {
+		return nil
+	}
+	return ExitStatus(status)
+}
+
+// subshellSessions is the session of a subshell, such as the shell running
+// a script with sh. It starts with a copy of the current directory,
+// environment and aliases of the session, and changes them for the subshell
+// only. The history is the session's.
+type subshellSessions struct {
+	SessionManager
+	dir     string
+	status  int
+	vars    *Vars
+	aliases *Vars
+}
+
+func newSubshellSessions(env *Env) *subshellSessions {
+	return &subshellSessions{
+		SessionManager: env.Sessions,
+		dir:            env.Sessions.GetCurrentDir(env.SessionID),
+		status:         env.Sessions.GetLastStatus(env.SessionID),
+		vars:           NewVars(env.Sessions.GetEnv(env.SessionID), EnvQuota),
+		aliases:        NewVars(env.Sessions.GetAliases(env.SessionID), AliasQuota),
+	}
+}
+
+func (s *subshellSessions) GetCurrentDir(string) string {
+	return s.dir
+}
+
+func (s *subshellSessions) SetCurrentDir(_, dir string) {
+	s.dir = dir
+}
+
+func (s *subshellSessions) GetLastStatus(string) int {
+	return s.status
+}
+
+func (s *subshellSessions) SetLastStatus(_ string, status int) {
+	s.status = status
+}
+
+func (s *subshellSessions) GetEnv(string) map[string]string {
+	return s.vars.Map()
+}
+
+func (s *subshellSessions) SetEnv(_, name, value string) error {
+	return s.vars.Set(name, value)
+}
+
+func (s *subshellSessions) UnsetEnv(_, name string) {
+	s.vars.Unset(name)
+}
+
+func (s *subshellSessions) GetAliases(string) map[string]string {
+	return s.aliases.Map()
+}
+
+func (s *subshellSessions) SetAlias(_, name, value string) error {
+	return s.aliases.Set(name, value)
+}
+
+func (s *subshellSessions) UnsetAlias(_, name string) {
+	s.aliases.Unset(name)
+}
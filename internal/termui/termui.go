@@ -4,9 +4,10 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"net/url"
 	"path"
-	"slices"
 	"strings"
 
 	"github.com/zorcal/its-a-me-zorcal/internal/termfs"
@@ -15,37 +16,99 @@ import (
 
 // Terminal command errors.
 var (
-	ErrFileNotFound     = errors.New("file not found")
-	ErrNotDirectory     = errors.New("not a directory")
-	ErrIsDirectory      = errors.New("is a directory")
-	ErrMissingArgument  = errors.New("missing argument")
-	ErrTooManyArguments = errors.New("too many arguments")
-	ErrAccessDenied     = errors.New("access denied")
-	ErrInvalidFlag      = errors.New("invalid flag")
-	ErrNotOpenable      = errors.New("not openable")
+	ErrFileNotFound      = errors.New("file not found")
+	ErrNotDirectory      = errors.New("not a directory")
+	ErrIsDirectory       = errors.New("is a directory")
+	ErrMissingArgument   = errors.New("missing argument")
+	ErrTooManyArguments  = errors.New("too many arguments")
+	ErrAccessDenied      = errors.New("access denied")
+	ErrInvalidFlag       = errors.New("invalid flag")
+	ErrNotOpenable       = errors.New("not openable")
+	ErrQuotaExceeded     = errors.New("quota exceeded")
+	ErrAmbiguousRedirect = errors.New("ambiguous redirect")
+	ErrFileExists        = errors.New("file exists")
+	ErrDirectoryNotEmpty = errors.New("directory not empty")
+	ErrIntoItself        = errors.New("cannot move or copy a directory into itself")
+	ErrSameFile          = errors.New("source and destination are the same file")
+	ErrInvalidIdentifier = errors.New("not a valid identifier")
+	ErrVarNotSet         = errors.New("variable not set")
+	ErrMissingPattern    = errors.New("missing pattern")
+	ErrInvalidPattern    = errors.New("invalid pattern")
+	ErrInvalidExpression = errors.New("invalid expression")
+	ErrUnknownPredicate  = errors.New("unknown predicate")
+	ErrNoManualEntry     = errors.New("no manual entry")
+	ErrNoManualMatch     = errors.New("nothing appropriate")
+	ErrEventNotFound     = errors.New("event not found")
+	ErrBadSubstitution   = errors.New("bad substitution")
+	ErrNotNumeric        = errors.New("not numeric")
+	ErrAliasNotFound     = errors.New("alias not found")
+	ErrInvalidAliasName  = errors.New("invalid alias name")
+	ErrAliasTooDeep      = errors.New("alias expansion too deep")
+	ErrAliasTooLong      = errors.New("alias expansion too long")
+	ErrIntegerExpected   = errors.New("integer expected")
+	ErrUnaryExpected     = errors.New("unary operator expected")
+	ErrBinaryExpected    = errors.New("binary operator expected")
+	ErrMissingBracket    = errors.New("missing ]")
+	ErrStepLimit         = errors.New("step limit exceeded")
+	ErrOutputLimit       = errors.New("output limit exceeded")
+	ErrValueLimit        = errors.New("value limit exceeded")
 )
 
 // SessionManager defines the interface for managing terminal sessions.
 type SessionManager interface {
 	GetCurrentDir(sessionID string) string
 	SetCurrentDir(sessionID string, dir string)
+	GetLastStatus(sessionID string) int
+	SetLastStatus(sessionID string, status int)
+	// GetEnv returns a copy of the environment variables of a session.
+	// Sessions start with DefaultEnv.
+	GetEnv(sessionID string) map[string]string
+	// SetEnv sets an environment variable of a session, unless the
+	// variables would then exceed EnvQuota.
+	// Possible errors: ErrQuotaExceeded.
+	SetEnv(sessionID, name, value string) error
+	UnsetEnv(sessionID, name string)
+	// GetHistory returns the command lines run in a session, oldest first.
+	GetHistory(sessionID string) []string
+	ClearHistory(sessionID string)
+	// GetAliases returns a copy of the aliases of a session, by name.
+	// Sessions start with DefaultAliases.
+	GetAliases(sessionID string) map[string]string
+	// SetAlias sets an alias of a session, unless the aliases would then
+	// exceed AliasQuota.
+	// Possible errors: ErrQuotaExceeded.
+	SetAlias(sessionID, name, value string) error
+	UnsetAlias(sessionID, name string)
 }
 
 // ChangeDirectory changes the current working directory for a session.
-// Returns the target path and error. On success, returns ("", nil).
-// On error, returns (targetPath, error) where targetPath is the path the user
-// attempted to access, allowing the caller to format contextual error messages.
-// Possible errors: ErrFileNotFound, ErrNotDirectory, ErrAccessDenied.
-func ChangeDirectory(tfs *termfs.FS, sessMgr SessionManager, sessionID string, args []string) (string, error) {
+// Without arguments it changes to $HOME, and the argument "-" changes to
+// $OLDPWD and writes the new directory to standard output. PWD and OLDPWD are
+// updated on success. Errors are returned as *ArgError carrying the path the
+// user attempted to access, allowing the caller to format contextual error
+// messages.
+// Possible errors: ErrFileNotFound, ErrNotDirectory, ErrAccessDenied,
+// ErrVarNotSet.
+func ChangeDirectory(env *Env, args []string) error {
+	vars := env.Sessions.GetEnv(env.SessionID)
+
 	var targetPath string
-	if len(args) == 0 {
-		// No arguments goes to home
-		targetPath = "~"
-	} else {
+	switch {
+	case len(args) == 0:
+		targetPath = vars["HOME"]
+		if targetPath == "" {
+			return &ArgError{Arg: "HOME", Err: ErrVarNotSet}
+		}
+	case args[0] == "-":
+		targetPath = vars["OLDPWD"]
+		if targetPath == "" {
+			return &ArgError{Arg: "OLDPWD", Err: ErrVarNotSet}
+		}
+	default:
 		targetPath = args[0]
 	}
 
-	currDir := sessMgr.GetCurrentDir(sessionID)
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
 	newDir := resolvePath(currDir, targetPath)
 
 	openPath := newDir
@@ -53,194 +116,150 @@ func ChangeDirectory(tfs *termfs.FS, sessMgr SessionManager, sessionID string, a
 		openPath = "."
 	}
 
-	info, err := fs.Stat(tfs, openPath)
+	info, err := fs.Stat(env.FS, openPath)
 	if err != nil {
-		return targetPath, fmt.Errorf("stat directory %q: %w", openPath, mapFSErr(err))
+		return &ArgError{Arg: targetPath, Err: fmt.Errorf("stat directory %q: %w", openPath, mapFSErr(err))}
 	}
 
 	if !info.IsDir() {
-		return targetPath, ErrNotDirectory
+		return &ArgError{Arg: targetPath, Err: ErrNotDirectory}
 	}
 
-	sessMgr.SetCurrentDir(sessionID, newDir)
-
-	return "", nil
-}
-
-// ListDirectoryContents lists the contents of a directory.
-// Returns directory listing and error. On success, returns (output, nil) where output
-// contains the formatted directory listing. On error, returns (contextInfo, error)
-// where contextInfo is the path/argument that caused the error.
-// Possible errors: ErrFileNotFound, ErrTooManyArguments, ErrAccessDenied.
-func ListDirectoryContents(tfs *termfs.FS, sessMgr SessionManager, sessionID string, args []string) (string, error) {
-	currDir := sessMgr.GetCurrentDir(sessionID)
-
-	flagSet := posixflag.NewFlagSet()
-
-	var showAll, longList bool
-	flagSet.BoolVar(&showAll, "all", 'a', false, "show hidden files")
-	flagSet.BoolVar(&longList, "long", 'l', false, "long listing format")
-
-	if err := flagSet.Parse(args); err != nil {
-		return "", fmt.Errorf("%w: %v", ErrInvalidFlag, err)
-	}
-
-	remaining := flagSet.Args()
-	if len(remaining) > 1 {
-		return "", ErrTooManyArguments
+	if err := env.Sessions.SetEnv(env.SessionID, "OLDPWD", "/"+currDir); err != nil {
+		return &ArgError{Arg: targetPath, Err: err}
 	}
-
-	targetPath := currDir
-	if len(remaining) == 1 {
-		// For ls command, the single remaining argument should be a path.
-		// Validate it looks like a reasonable path before using it.
-		pathArg := remaining[0]
-		if !isValidPathArgument(pathArg) {
-			return "", fmt.Errorf("%w: invalid path argument", ErrInvalidFlag)
-		}
-		targetPath = resolvePath(currDir, pathArg)
-	}
-
-	openPath := targetPath
-	if openPath == "" {
-		openPath = "."
+	if err := env.Sessions.SetEnv(env.SessionID, "PWD", "/"+newDir); err != nil {
+		return &ArgError{Arg: targetPath, Err: err}
 	}
+	env.Sessions.SetCurrentDir(env.SessionID, newDir)
 
-	info, err := fs.Stat(tfs, openPath)
-	if err != nil {
-		// We know exactly what path argument was provided since we validated it.
-		remaining := flagSet.Args()
-		wrappedErr := fmt.Errorf("stat path %q: %w", openPath, mapFSErr(err))
-		if len(remaining) == 1 {
-			return remaining[0], wrappedErr
-		}
-		// If no path argument, return the current directory for context.
-		return ".", wrappedErr
+	if len(args) > 0 && args[0] == "-" {
+		fmt.Fprintln(env.Stdout, "/"+newDir)
 	}
 
-	if !info.IsDir() {
-		return info.Name(), nil
-	}
+	return nil
+}
 
-	entries, err := fs.ReadDir(tfs, openPath)
-	if err != nil {
-		return "", fmt.Errorf("read directory %q: %w", openPath, mapFSErr(err))
-	}
+// PrintWorkingDirectory writes the current working directory path to
+// standard output.
+func PrintWorkingDirectory(env *Env, args []string) error {
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+	fmt.Fprintln(env.Stdout, "/"+currDir)
+	return nil
+}
 
-	// Filter hidden files unless -a is used.
-	if !showAll {
-		entries = slices.DeleteFunc(entries, func(entry fs.DirEntry) bool {
-			return strings.HasPrefix(entry.Name(), ".")
-		})
-	}
+// lineNumberClass is the CSS class of the line numbers written by cat -n.
+const lineNumberClass = "line-number"
+
+// CatFile writes the contents of the files given as arguments to standard
+// output, in order. Without arguments, or for the argument "-", standard
+// input is copied instead. Markdown files written to the terminal are
+// rendered and source files in a known language highlighted, unless -r asks
+// for them as they are. -n numbers the lines written. A file that cannot be
+// read does not stop the remaining files from being written; all errors are
+// returned joined, each as *ArgError carrying the file the user attempted to
+// access.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrAccessDenied,
+// ErrInvalidFlag.
+func CatFile(env *Env, args []string) error {
+	var opts catOptions
+	flagSet := newCatFlagSet(&opts)
 
-	if len(entries) == 0 {
-		return "", nil
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
 	}
 
-	var output strings.Builder
-	for i, entry := range entries {
-		if i > 0 {
-			if longList {
-				output.WriteString("\n")
-			} else {
-				output.WriteString("  ")
-			}
-		}
-
-		name := entry.Name()
-
-		// Long format: show 3-character type indicator (directory/catable/openable).
-		if longList {
-			var typeIndicator string
-			if entry.IsDir() {
-				typeIndicator = "d--"
-				name = name + "/"
-			} else {
-				// All files are catable, determine if also openable.
-				// Files are openable if they contain a valid URL.
-				entryPath := targetPath + "/" + entry.Name()
-				if targetPath == "" {
-					entryPath = entry.Name()
-				}
-				isOpenable := extractURLFromContents(tfs, entryPath) != ""
-				if isOpenable {
-					typeIndicator = "-co"
-				} else {
-					typeIndicator = "-c-"
-				}
-			}
-			output.WriteString(fmt.Sprintf("%s  %s", typeIndicator, name))
-			continue
-		}
+	c := &catter{env: env, opts: opts}
 
-		// Short format: just add / for directories.
-		if entry.IsDir() {
-			name = name + "/"
+	var errs []error
+	for _, name := range inputNames(flagSet.Args()) {
+		if err := c.cat(name); err != nil {
+			errs = append(errs, err)
 		}
-		output.WriteString(name)
 	}
 
-	return output.String(), nil
+	return errors.Join(errs...)
 }
 
-// PrintWorkingDirectory returns the current working directory path.
-// Returns current working directory path and error. On success, returns (path, nil).
-// This function typically does not error, but follows the same pattern for consistency.
-func PrintWorkingDirectory(sessMgr SessionManager, sessionID string) (string, error) {
-	currDir := sessMgr.GetCurrentDir(sessionID)
-	if currDir == "" {
-		return "/", nil
-	}
-	return "/" + currDir, nil
+// catOptions holds the flags accepted by cat.
+type catOptions struct {
+	raw    bool
+	number bool
 }
 
-// CatFile reads and returns the contents of a file.
-// Returns file content and error. On success, returns (content, nil).
-// On error, returns (filename, error) where filename is the file the user
-// attempted to access, allowing the caller to format contextual error messages.
-// Possible errors: ErrMissingArgument, ErrFileNotFound, ErrIsDirectory, ErrAccessDenied.
-func CatFile(tfs *termfs.FS, sessMgr SessionManager, sessionID string, args []string) (string, error) {
-	if len(args) < 1 {
-		return "", ErrMissingArgument
-	}
-
-	currDir := sessMgr.GetCurrentDir(sessionID)
-	targetPath := resolvePath(currDir, args[0])
+func newCatFlagSet(opts *catOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.raw, "raw", 'r', false, "print files as they are, without rendering or highlighting")
+	flagSet.BoolVar(&opts.number, "number", 'n', false, "number all output lines")
+	return flagSet
+}
 
-	openPath := targetPath
-	if openPath == "" {
-		openPath = "."
-	}
+// catter writes the files of a cat invocation.
+type catter struct {
+	env  *Env
+	opts catOptions
+	// line is the number of the last line written, as lines are numbered
+	// across files.
+	line int
+	// midLine reports whether the last line written did not end, so that a
+	// file continuing it does not start with a number.
+	midLine bool
+}
 
-	info, err := fs.Stat(tfs, openPath)
+// cat writes the file name, or standard input if name is "-".
+func (c *catter) cat(name string) error {
+	content, err := readInput(c.env, name)
 	if err != nil {
-		return args[0], fmt.Errorf("stat file %q: %w", openPath, mapFSErr(err))
+		return err
+	}
+
+	// Only the terminal shows rendered and highlighted files, so that pipes
+	// receive the source.
+	out := new(styledText)
+	switch lang := languageOf(name); {
+	case c.opts.raw || name == "-" || !isTerminal(c.env.Stdout):
+		out.WriteString(content)
+	case isMarkdown(name):
+		out = renderMarkdown(content)
+	case lang != nil:
+		out = highlight(lang, content)
+	default:
+		out.WriteString(content)
 	}
 
-	if info.IsDir() {
-		return args[0], ErrIsDirectory
+	if c.opts.number {
+		out = c.numberLines(out)
 	}
 
-	content, err := fs.ReadFile(tfs, openPath)
-	if err != nil {
-		return args[0], fmt.Errorf("read file %q: %w", openPath, mapFSErr(err))
-	}
+	return writeStyled(c.env.Stdout, out)
+}
 
-	return string(content), nil
+// numberLines returns s with its lines numbered, continuing from the lines
+// already written.
+func (c *catter) numberLines(s *styledText) *styledText {
+	numbered := new(styledText)
+	for _, line := range s.Lines() {
+		if !c.midLine {
+			c.line++
+			numbered.Element("span", lineNumberClass, fmt.Sprintf("%6d", c.line))
+			numbered.WriteString("\t")
+		}
+		numbered.Append(line)
+		c.midLine = !strings.HasSuffix(line.String(), "\n")
+	}
+	return numbered
 }
 
-// OpenFile opens a file and extracts its URL.
-// Returns the URL and error. On success, returns (url, nil).
-// On error, returns (filename, error) where filename is the file the user
-// attempted to access, allowing the caller to format contextual error messages.
+// OpenFile extracts the URL of a file and asks the client terminal to open
+// it. Errors are returned as *ArgError carrying the file the user attempted
+// to access, allowing the caller to format contextual error messages.
 // Possible errors: ErrMissingArgument, ErrFileNotFound, ErrIsDirectory, ErrNotOpenable.
-func OpenFile(tfs *termfs.FS, sessMgr SessionManager, sessionID string, args []string) (string, error) {
+func OpenFile(env *Env, args []string) error {
 	if len(args) < 1 {
-		return "", ErrMissingArgument
+		return ErrMissingArgument
 	}
 
-	currDir := sessMgr.GetCurrentDir(sessionID)
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
 	filename := args[0]
 	targetPath := resolvePath(currDir, filename)
 
@@ -249,21 +268,44 @@ func OpenFile(tfs *termfs.FS, sessMgr SessionManager, sessionID string, args []s
 		openPath = "."
 	}
 
-	info, err := fs.Stat(tfs, openPath)
+	info, err := fs.Stat(env.FS, openPath)
 	if err != nil {
-		return filename, fmt.Errorf("stat file %q: %w", openPath, mapFSErr(err))
+		return &ArgError{Arg: filename, Err: fmt.Errorf("stat file %q: %w", openPath, mapFSErr(err))}
 	}
 
 	if info.IsDir() {
-		return filename, ErrIsDirectory
+		return &ArgError{Arg: filename, Err: ErrIsDirectory}
 	}
 
-	url := extractURLFromContents(tfs, openPath)
+	url := extractURLFromContents(env.FS, openPath)
 	if url == "" {
-		return filename, ErrNotOpenable
+		return &ArgError{Arg: filename, Err: ErrNotOpenable}
+	}
+
+	env.Output.OpenURL = url
+	fmt.Fprintf(env.Stdout, "Opening %s in browser...\n", filename)
+
+	return nil
+}
+
+// Echo writes its arguments to standard output, separated by spaces and
+// followed by a newline. Like echo in POSIX shells, a leading -n suppresses
+// the newline and any other argument is written as is, even if it starts
+// with a dash.
+func Echo(env *Env, args []string) error {
+	newline := true
+	for len(args) > 0 && args[0] == "-n" {
+		newline = false
+		args = args[1:]
+	}
+
+	line := strings.Join(args, " ")
+	if newline {
+		line += "\n"
 	}
 
-	return url, nil
+	_, err := io.WriteString(env.Stdout, line)
+	return err
 }
 
 // GeneratePrompt generates a terminal prompt based on the current directory.
@@ -293,16 +335,28 @@ func mapFSErr(err error) error {
 	if errors.Is(err, fs.ErrInvalid) {
 		return ErrAccessDenied
 	}
+	if errors.Is(err, fs.ErrExist) {
+		return ErrFileExists
+	}
+	if errors.Is(err, termfs.ErrNotEmpty) {
+		return ErrDirectoryNotEmpty
+	}
+	if errors.Is(err, termfs.ErrIsDir) {
+		return ErrIsDirectory
+	}
+	if errors.Is(err, termfs.ErrNotDir) {
+		return ErrNotDirectory
+	}
+	if errors.Is(err, termfs.ErrQuotaExceeded) {
+		return ErrQuotaExceeded
+	}
 
 	return err
 }
 
 // resolvePath resolves a target path relative to the current directory.
 func resolvePath(currDir, targetPath string) string {
-	switch targetPath {
-	case "~", "$HOME":
-		return "home/guest"
-	case ".":
+	if targetPath == "." {
 		return currDir
 	}
 
@@ -353,29 +407,46 @@ func isValidPathArgument(arg string) bool {
 }
 
 // extractURLFromContents extracts the URL from the files contents.
-// URLs are extracted from the first occurence of the **URL:** prefix.
-func extractURLFromContents(tfs *termfs.FS, filePath string) string {
-	info, err := fs.Stat(tfs, filePath)
+// URLs are extracted from the first occurence of the **URL:** prefix, or
+// if there is none, from the first markdown link such as [site](https://…).
+func extractURLFromContents(fsys fs.FS, filePath string) string {
+	info, err := fs.Stat(fsys, filePath)
 	if err != nil || info.IsDir() {
 		return ""
 	}
 
-	f, err := tfs.Open(filePath)
+	f, err := fsys.Open(filePath)
 	if err != nil {
 		return ""
 	}
 	defer f.Close()
 
+	var linkURL string
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if after, ok := strings.CutPrefix(line, "**URL:**"); ok {
-			url := strings.TrimSpace(after)
-			if url != "" {
-				return url
+			rawURL := strings.TrimSpace(after)
+			// Files can be written by visitors, so only web URLs are opened.
+			if isWebURL(rawURL) {
+				return rawURL
+			}
+		}
+		if linkURL == "" {
+			for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+				if isWebURL(m[2]) {
+					linkURL = m[2]
+					break
+				}
 			}
 		}
 	}
 
-	return ""
+	return linkURL
+}
+
+// isWebURL reports whether rawURL is an absolute http or https URL.
+func isWebURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
@@ -1,6 +1,7 @@
 package termui
 
 import (
+	"bytes"
 	"errors"
 	"strconv"
 	"strings"
@@ -10,7 +11,7 @@ import (
 	"github.com/zorcal/its-a-me-zorcal/pkg/github"
 )
 
-func setupTest() (*termfs.FS, *mockSessionManager) {
+func setupTest() (*termfs.Overlay, *mockSessionManager) {
 	repos := []github.Repository{
 		{
 			Name:        "test-repo",
@@ -28,7 +29,36 @@ func setupTest() (*termfs.FS, *mockSessionManager) {
 
 	sessMgr := newMockSessionManager()
 
-	return tfs, sessMgr
+	return termfs.NewOverlay(tfs, 1<<16), sessMgr
+}
+
+func newTestEnv(tfs *termfs.Overlay, sessMgr SessionManager, sessionID string) *Env {
+	return &Env{
+		FS:        tfs,
+		Sessions:  sessMgr,
+		SessionID: sessionID,
+		Stdin:     strings.NewReader(""),
+		Stdout:    new(bytes.Buffer),
+		Stderr:    new(bytes.Buffer),
+		Output:    &Output{},
+	}
+}
+
+// runCommand runs fn with args in a test environment and returns what it
+// wrote to standard output.
+func runCommand(tfs *termfs.Overlay, sessMgr SessionManager, sessionID string, fn func(*Env, []string) error, args []string) (string, error) {
+	env := newTestEnv(tfs, sessMgr, sessionID)
+	err := fn(env, args)
+	return env.Stdout.(*bytes.Buffer).String(), err
+}
+
+// argContext returns the argument recorded by an *ArgError in err, if any.
+func argContext(err error) string {
+	var argErr *ArgError
+	if errors.As(err, &argErr) {
+		return argErr.Arg
+	}
+	return ""
 }
 
 func TestChangeDirectory(t *testing.T) {
@@ -42,9 +72,9 @@ func TestChangeDirectory(t *testing.T) {
 		wantDir    string
 	}{
 		{
-			name:       "to home directory with tilde",
-			startDir:   "home/guest", // default start
-			targetPath: "~",
+			name:       "to home directory by absolute path",
+			startDir:   "home/zorcal",
+			targetPath: "/home/guest",
 			wantDir:    "home/guest",
 		},
 		{
@@ -111,8 +141,8 @@ func TestChangeDirectory(t *testing.T) {
 				args = []string{tt.targetPath}
 			}
 
-			if _, err := ChangeDirectory(tfs, sessMgr, sessionID, args); err != nil {
-				t.Fatalf("ChangeDirectory(tfs, sessMgr, %q, %v) error = %v, want nil", sessionID, args, err)
+			if _, err := runCommand(tfs, sessMgr, sessionID, ChangeDirectory, args); err != nil {
+				t.Fatalf("ChangeDirectory(env, %v) error = %v, want nil", args, err)
 			}
 
 			got := sessMgr.GetCurrentDir(sessionID)
@@ -129,12 +159,12 @@ func TestChangeDirectory_error(t *testing.T) {
 
 	t.Run("nonexistent directory", func(t *testing.T) {
 		in := "nonexistent"
-		_, gotErr := ChangeDirectory(tfs, sessMgr, sessionID, []string{in})
+		_, gotErr := runCommand(tfs, sessMgr, sessionID, ChangeDirectory, []string{in})
 		if gotErr == nil {
-			t.Fatalf("ChangeDirectory(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{in})
+			t.Fatalf("ChangeDirectory(env, %v) error = nil, want error", []string{in})
 		}
 		if !errors.Is(gotErr, ErrFileNotFound) {
-			t.Errorf("ChangeDirectory(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{in}, gotErr, ErrFileNotFound)
+			t.Errorf("ChangeDirectory(env, %v) error = %v, want %v", []string{in}, gotErr, ErrFileNotFound)
 		}
 	})
 
@@ -142,20 +172,21 @@ func TestChangeDirectory_error(t *testing.T) {
 		sessMgr.SetCurrentDir(sessionID, "")
 
 		in := "home/zorcal/projects"
-		if _, err := ChangeDirectory(tfs, sessMgr, sessionID, []string{in}); err != nil {
-			t.Fatalf("setup: ChangeDirectory(tfs, sessMgr, %q, %v) error = %v, want nil", sessionID, []string{in}, err)
+		if _, err := runCommand(tfs, sessMgr, sessionID, ChangeDirectory, []string{in}); err != nil {
+			t.Fatalf("setup: ChangeDirectory(env, %v) error = %v, want nil", []string{in}, err)
 		}
 
 		in = "test-repo.md"
-		gotContext, gotErr := ChangeDirectory(tfs, sessMgr, sessionID, []string{in})
+		_, gotErr := runCommand(tfs, sessMgr, sessionID, ChangeDirectory, []string{in})
+		gotContext := argContext(gotErr)
 		if gotErr == nil {
-			t.Fatalf("ChangeDirectory(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{in})
+			t.Fatalf("ChangeDirectory(env, %v) error = nil, want error", []string{in})
 		}
 		if !errors.Is(gotErr, ErrNotDirectory) {
-			t.Errorf("ChangeDirectory(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{in}, gotErr, ErrNotDirectory)
+			t.Errorf("ChangeDirectory(env, %v) error = %v, want %v", []string{in}, gotErr, ErrNotDirectory)
 		}
 		if wantContext := "test-repo.md"; gotContext != wantContext {
-			t.Errorf("ChangeDirectory(tfs, sessMgr, %q, %v) context = %q, want %q", sessionID, []string{in}, gotContext, wantContext)
+			t.Errorf("ChangeDirectory(env, %v) context = %q, want %q", []string{in}, gotContext, wantContext)
 		}
 	})
 }
@@ -204,37 +235,37 @@ func TestListDirectoryContents(t *testing.T) {
 			name:        "combined flags",
 			startDir:    "home/zorcal",
 			args:        []string{"-la"},
-			wantSubstrs: []string{"-c-  .secret.txt", "d--  projects/"},
+			wantSubstrs: []string{"-rw-r--r-- zorcal zorcal 380 ", " .secret.txt\n", "drwxr-xr-x zorcal zorcal   0 ", " projects/\n"},
 		},
 		{
 			name:        "combined flags in reverse order",
 			startDir:    "home/zorcal",
 			args:        []string{"-al"},
-			wantSubstrs: []string{"-c-  .secret.txt", "d--  projects/"},
+			wantSubstrs: []string{"-rw-r--r-- zorcal zorcal 380 ", " .secret.txt\n", "drwxr-xr-x zorcal zorcal   0 ", " projects/\n"},
 		},
 		{
 			name:        "path before flags",
 			startDir:    "home",
 			args:        []string{"zorcal", "-l"},
-			wantSubstrs: []string{"d--  projects/"},
+			wantSubstrs: []string{"drwxr-xr-x zorcal zorcal 0 ", " projects/\n"},
 		},
 		{
 			name:        "path between flags",
 			startDir:    "home",
 			args:        []string{"-a", "zorcal", "-l"},
-			wantSubstrs: []string{"-c-  .secret.txt", "d--  projects/"},
+			wantSubstrs: []string{"-rw-r--r-- zorcal zorcal 380 ", " .secret.txt\n", "drwxr-xr-x zorcal zorcal   0 ", " projects/\n"},
 		},
 		{
 			name:        "flags after path",
 			startDir:    "home",
 			args:        []string{"zorcal", "-al"},
-			wantSubstrs: []string{"-c-  .secret.txt", "d--  projects/"},
+			wantSubstrs: []string{"-rw-r--r-- zorcal zorcal 380 ", " .secret.txt\n", "drwxr-xr-x zorcal zorcal   0 ", " projects/\n"},
 		},
 		{
 			name:        "with path and flags",
 			startDir:    "home",
 			args:        []string{"-l", "zorcal"},
-			wantSubstrs: []string{"d--  projects/"},
+			wantSubstrs: []string{"drwxr-xr-x zorcal zorcal 0 ", " projects/\n"},
 		},
 		{
 			name:        "list parent directory from projects",
@@ -273,25 +304,25 @@ func TestListDirectoryContents(t *testing.T) {
 			wantSubstrs: []string{},
 		},
 		{
-			name:        "filetype indicators in projects directory",
+			name:        "openable files in projects directory",
 			startDir:    "home/zorcal/projects",
-			args:        []string{"-l"},
-			wantSubstrs: []string{"-co  test-repo.md", "-co  app.js"},
+			args:        []string{"-F"},
+			wantSubstrs: []string{"app.js*\ntest-repo.md*\n"},
 		},
 		{
-			name:        "filetype indicators outside projects directory",
+			name:        "no openable files outside projects directory",
 			startDir:    "home/zorcal",
-			args:        []string{"-al"},
-			wantSubstrs: []string{"-c-  .secret.txt", "d--  projects/"},
+			args:        []string{"-aF"},
+			wantSubstrs: []string{".secret.txt\nprojects/\n"},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			sessMgr.SetCurrentDir(sessionID, tt.startDir)
 
-			got, err := ListDirectoryContents(tfs, sessMgr, sessionID, tt.args)
+			got, err := runCommand(tfs, sessMgr, sessionID, ListDirectoryContents, tt.args)
 			if err != nil {
-				t.Fatalf("ListDirectoryContents(tfs, sessMgr, %q, %v) error = %v, want nil", sessionID, tt.args, err)
+				t.Fatalf("ListDirectoryContents(env, %v) error = %v, want nil", tt.args, err)
 			}
 
 			var missingSubstrs []string
@@ -301,7 +332,7 @@ func TestListDirectoryContents(t *testing.T) {
 				}
 			}
 			if len(missingSubstrs) > 0 {
-				t.Errorf("ListDirectoryContents(tfs, sessMgr, %q, %v) output = %q, want to contain sub strings %q", sessionID, tt.args, got, tt.wantSubstrs)
+				t.Errorf("ListDirectoryContents(env, %v) output = %q, want to contain sub strings %q", tt.args, got, tt.wantSubstrs)
 			}
 		})
 	}
@@ -327,18 +358,18 @@ func TestListDirectoryContents(t *testing.T) {
 			t.Run(tt.name, func(t *testing.T) {
 				sessMgr.SetCurrentDir(sessionID, "home/zorcal")
 
-				got, err := ListDirectoryContents(tfs, sessMgr, sessionID, tt.args)
+				got, err := runCommand(tfs, sessMgr, sessionID, ListDirectoryContents, tt.args)
 				if err != nil {
-					t.Fatalf("ListDirectoryContents(tfs, sessMgr, %q, %v) error = %v, want nil", sessionID, tt.args, err)
+					t.Fatalf("ListDirectoryContents(env, %v) error = %v, want nil", tt.args, err)
 				}
 
 				hiddenFilePresent := strings.Contains(got, ".secret.txt")
 				if tt.wantHidden != hiddenFilePresent {
-					t.Errorf("ListDirectoryContents(tfs, sessMgr, %q, %v) output = %q, want to contain hidden file .secret.txt is %v", sessionID, tt.args, got, tt.wantHidden)
+					t.Errorf("ListDirectoryContents(env, %v) output = %q, want to contain hidden file .secret.txt is %v", tt.args, got, tt.wantHidden)
 				}
 
 				if !strings.Contains(got, "projects") {
-					t.Errorf("ListDirectoryContents(tfs, sessMgr, %q, %v) output = %q, want to contain visible file projects", sessionID, tt.args, got)
+					t.Errorf("ListDirectoryContents(env, %v) output = %q, want to contain visible file projects", tt.args, got)
 				}
 			})
 		}
@@ -347,19 +378,19 @@ func TestListDirectoryContents(t *testing.T) {
 	t.Run("newline formatting", func(t *testing.T) {
 		sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
 
-		got, err := ListDirectoryContents(tfs, sessMgr, sessionID, []string{"-l"})
+		got, err := runCommand(tfs, sessMgr, sessionID, ListDirectoryContents, []string{"-l"})
 		if err != nil {
-			t.Fatalf("ListDirectoryContents(tfs, sessMgr, %q, %v) error = %v, want nil", sessionID, []string{"-l"}, err)
+			t.Fatalf("ListDirectoryContents(env, %v) error = %v, want nil", []string{"-l"}, err)
 		}
 
 		// Should contain newlines between entries
 		if !strings.Contains(got, "\n") {
-			t.Errorf("ListDirectoryContents(tfs, sessMgr, %q, %v) output = %q, should contain newlines between entries", sessionID, []string{"-l"}, got)
+			t.Errorf("ListDirectoryContents(env, %v) output = %q, should contain newlines between entries", []string{"-l"}, got)
 		}
 
 		// Should not have entries separated by spaces
-		if strings.Contains(got, "app.js  -c-") || strings.Contains(got, "test-repo.md  -co") {
-			t.Errorf("ListDirectoryContents(tfs, sessMgr, %q, %v) output = %q, should separate entries with newlines, not spaces", sessionID, []string{"-l"}, got)
+		if strings.Count(got, "\n") != 2 {
+			t.Errorf("ListDirectoryContents(env, %v) output = %q, should separate entries with newlines, not spaces", []string{"-l"}, got)
 		}
 	})
 }
@@ -369,45 +400,26 @@ func TestListDirectory_error(t *testing.T) {
 	sessionID := "session1"
 
 	t.Run("nonexistent directory", func(t *testing.T) {
-		gotContext, gotErr := ListDirectoryContents(tfs, sessMgr, sessionID, []string{"nonexistent"})
+		_, gotErr := runCommand(tfs, sessMgr, sessionID, ListDirectoryContents, []string{"nonexistent"})
+		gotContext := argContext(gotErr)
 		if gotErr == nil {
-			t.Fatalf("ListDirectory(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{"nonexistent"})
+			t.Fatalf("ListDirectory(env, %v) error = nil, want error", []string{"nonexistent"})
 		}
 		if !errors.Is(gotErr, ErrFileNotFound) {
-			t.Errorf("ListDirectory(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{"nonexistent"}, gotErr, ErrFileNotFound)
+			t.Errorf("ListDirectory(env, %v) error = %v, want %v", []string{"nonexistent"}, gotErr, ErrFileNotFound)
 		}
 		if wantContext := "nonexistent"; gotContext != wantContext {
-			t.Errorf("ListDirectory(tfs, sessMgr, %q, %v) context = %q, want %q", sessionID, []string{"nonexistent"}, gotContext, wantContext)
-		}
-	})
-
-	t.Run("multiple paths", func(t *testing.T) {
-		_, gotErr := ListDirectoryContents(tfs, sessMgr, sessionID, []string{"home", "zorcal"})
-		if gotErr == nil {
-			t.Fatalf("ListDirectory(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{"home", "zorcal"})
-		}
-		if !errors.Is(gotErr, ErrTooManyArguments) {
-			t.Errorf("ListDirectory(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{"home", "zorcal"}, gotErr, ErrTooManyArguments)
-		}
-	})
-
-	t.Run("multiple paths with flags", func(t *testing.T) {
-		_, gotErr := ListDirectoryContents(tfs, sessMgr, sessionID, []string{"-l", "home", "zorcal"})
-		if gotErr == nil {
-			t.Fatalf("ListDirectory(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{"-l", "home", "zorcal"})
-		}
-		if !errors.Is(gotErr, ErrTooManyArguments) {
-			t.Errorf("ListDirectory(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{"-l", "home", "zorcal"}, gotErr, ErrTooManyArguments)
+			t.Errorf("ListDirectory(env, %v) context = %q, want %q", []string{"nonexistent"}, gotContext, wantContext)
 		}
 	})
 
 	t.Run("unknown flag error", func(t *testing.T) {
-		_, gotErr := ListDirectoryContents(tfs, sessMgr, sessionID, []string{"-x"})
+		_, gotErr := runCommand(tfs, sessMgr, sessionID, ListDirectoryContents, []string{"-x"})
 		if gotErr == nil {
-			t.Fatalf("ListDirectory(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{"-x"})
+			t.Fatalf("ListDirectory(env, %v) error = nil, want error", []string{"-x"})
 		}
 		if !errors.Is(gotErr, ErrInvalidFlag) {
-			t.Errorf("ListDirectory(tfs, sessMgr, %q, %v) error = %v, want ErrInvalidFlag", sessionID, []string{"-x"}, gotErr)
+			t.Errorf("ListDirectory(env, %v) error = %v, want ErrInvalidFlag", []string{"-x"}, gotErr)
 		}
 	})
 
@@ -435,12 +447,12 @@ func TestListDirectory_error(t *testing.T) {
 		}
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
-				_, gotErr := ListDirectoryContents(tfs, sessMgr, sessionID, tt.args)
+				_, gotErr := runCommand(tfs, sessMgr, sessionID, ListDirectoryContents, tt.args)
 				if gotErr == nil {
-					t.Fatalf("ListDirectory(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, tt.args)
+					t.Fatalf("ListDirectory(env, %v) error = nil, want error", tt.args)
 				}
 				if !errors.Is(gotErr, ErrInvalidFlag) {
-					t.Errorf("ListDirectory(tfs, sessMgr, %q, %v) error = %v, want ErrInvalidFlag", sessionID, tt.args, gotErr)
+					t.Errorf("ListDirectory(env, %v) error = %v, want ErrInvalidFlag", tt.args, gotErr)
 				}
 			})
 		}
@@ -454,24 +466,24 @@ func TestPrintWorkingDirectory(t *testing.T) {
 	t.Run("at root", func(t *testing.T) {
 		sessMgr.SetCurrentDir(sessionID, "")
 
-		got, err := PrintWorkingDirectory(sessMgr, sessionID)
+		got, err := runCommand(nil, sessMgr, sessionID, PrintWorkingDirectory, nil)
 		if err != nil {
-			t.Fatalf("PrintWorkingDirectory(sessMgr, %q) error = %v, want nil", sessionID, err)
+			t.Fatalf("PrintWorkingDirectory(env, nil) error = %v, want nil", err)
 		}
-		if want := "/"; got != want {
-			t.Errorf("PrintWorkingDirectory(sessMgr, %q) path = %q, want %q", sessionID, got, want)
+		if want := "/\n"; got != want {
+			t.Errorf("PrintWorkingDirectory(env, nil) path = %q, want %q", got, want)
 		}
 	})
 
 	t.Run("in subdirectory", func(t *testing.T) {
 		sessMgr.SetCurrentDir(sessionID, "home/zorcal")
 
-		got, err := PrintWorkingDirectory(sessMgr, sessionID)
+		got, err := runCommand(nil, sessMgr, sessionID, PrintWorkingDirectory, nil)
 		if err != nil {
-			t.Fatalf("PrintWorkingDirectory(sessMgr, %q) error = %v, want nil", sessionID, err)
+			t.Fatalf("PrintWorkingDirectory(env, nil) error = %v, want nil", err)
 		}
-		if want := "/home/zorcal"; got != want {
-			t.Errorf("PrintWorkingDirectory(sessMgr, %q) path = %q, want %q", sessionID, got, want)
+		if want := "/home/zorcal\n"; got != want {
+			t.Errorf("PrintWorkingDirectory(env, nil) path = %q, want %q", got, want)
 		}
 	})
 }
@@ -483,71 +495,120 @@ func TestCatFile(t *testing.T) {
 	t.Run("existing file", func(t *testing.T) {
 		sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
 
-		got, err := CatFile(tfs, sessMgr, sessionID, []string{"test-repo.md"})
+		got, err := runCommand(tfs, sessMgr, sessionID, CatFile, []string{"test-repo.md"})
 		if err != nil {
-			t.Fatalf("CatFile(tfs, sessMgr, %q, %v) error = %v, want nil", sessionID, []string{"test-repo.md"}, err)
+			t.Fatalf("CatFile(env, %v) error = %v, want nil", []string{"test-repo.md"}, err)
 		}
 		if want := "test-repo"; !strings.Contains(got, want) {
-			t.Errorf("CatFile(tfs, sessMgr, %q, %v) content = %q, want to contain %q", sessionID, []string{"test-repo.md"}, got, want)
+			t.Errorf("CatFile(env, %v) content = %q, want to contain %q", []string{"test-repo.md"}, got, want)
 		}
 		if want := "A test repository"; !strings.Contains(got, want) {
-			t.Errorf("CatFile(tfs, sessMgr, %q, %v) content = %q, want to contain %q", sessionID, []string{"test-repo.md"}, got, want)
+			t.Errorf("CatFile(env, %v) content = %q, want to contain %q", []string{"test-repo.md"}, got, want)
 		}
 	})
 }
 
+func TestCatFile_stdin(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "no arguments",
+			args: []string{},
+			want: "piped input",
+		},
+		{
+			name: "dash argument",
+			args: []string{"-"},
+			want: "piped input",
+		},
+		{
+			name: "dash between files",
+			args: []string{"welcome.txt", "-"},
+			want: "- Zorcalpiped input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessMgr.SetCurrentDir(sessionID, "home/guest")
+
+			env := newTestEnv(tfs, sessMgr, sessionID)
+			env.Stdin = strings.NewReader("piped input")
+
+			if err := CatFile(env, tt.args); err != nil {
+				t.Fatalf("CatFile(env, %v) error = %v, want nil", tt.args, err)
+			}
+			if got := env.Stdout.(*bytes.Buffer).String(); !strings.HasSuffix(got, tt.want) {
+				t.Errorf("CatFile(env, %v) content = %q, want suffix %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCatFile_error(t *testing.T) {
 	tfs, sessMgr := setupTest()
 	sessionID := "session1"
 
 	t.Run("nonexistent file", func(t *testing.T) {
-		gotContext, gotErr := CatFile(tfs, sessMgr, sessionID, []string{"nonexistent.txt"})
+		_, gotErr := runCommand(tfs, sessMgr, sessionID, CatFile, []string{"nonexistent.txt"})
+		gotContext := argContext(gotErr)
 		if gotErr == nil {
-			t.Fatalf("CatFile(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{"nonexistent.txt"})
+			t.Fatalf("CatFile(env, %v) error = nil, want error", []string{"nonexistent.txt"})
 		}
 		if !errors.Is(gotErr, ErrFileNotFound) {
-			t.Errorf("CatFile(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{"nonexistent.txt"}, gotErr, ErrFileNotFound)
+			t.Errorf("CatFile(env, %v) error = %v, want %v", []string{"nonexistent.txt"}, gotErr, ErrFileNotFound)
 		}
 		if wantContext := "nonexistent.txt"; gotContext != wantContext {
-			t.Errorf("CatFile(tfs, sessMgr, %q, %v) context = %q, want %q", sessionID, []string{"nonexistent.txt"}, gotContext, wantContext)
+			t.Errorf("CatFile(env, %v) context = %q, want %q", []string{"nonexistent.txt"}, gotContext, wantContext)
 		}
 	})
 
 	t.Run("target is directory", func(t *testing.T) {
 		sessMgr.SetCurrentDir(sessionID, "")
 
-		gotContext, gotErr := CatFile(tfs, sessMgr, sessionID, []string{"home"})
+		_, gotErr := runCommand(tfs, sessMgr, sessionID, CatFile, []string{"home"})
+		gotContext := argContext(gotErr)
 		if gotErr == nil {
-			t.Fatalf("CatFile(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{"home"})
+			t.Fatalf("CatFile(env, %v) error = nil, want error", []string{"home"})
 		}
 		if !errors.Is(gotErr, ErrIsDirectory) {
-			t.Errorf("CatFile(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{"home"}, gotErr, ErrIsDirectory)
+			t.Errorf("CatFile(env, %v) error = %v, want %v", []string{"home"}, gotErr, ErrIsDirectory)
 		}
 		if wantContext := "home"; gotContext != wantContext {
-			t.Errorf("CatFile(tfs, sessMgr, %q, %v) context = %q, want %q", sessionID, []string{"home"}, gotContext, wantContext)
+			t.Errorf("CatFile(env, %v) context = %q, want %q", []string{"home"}, gotContext, wantContext)
 		}
 	})
 
-	t.Run("no argument provided", func(t *testing.T) {
-		_, gotErr := CatFile(tfs, sessMgr, sessionID, []string{})
-		if gotErr == nil {
-			t.Fatalf("CatFile(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, []string{})
+	t.Run("missing file among existing files", func(t *testing.T) {
+		sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+		args := []string{"nonexistent.txt", "test-repo.md", "app.js"}
+		got, gotErr := runCommand(tfs, sessMgr, sessionID, CatFile, args)
+		if !errors.Is(gotErr, ErrFileNotFound) {
+			t.Errorf("CatFile(env, %v) error = %v, want %v", args, gotErr, ErrFileNotFound)
 		}
-		if !errors.Is(gotErr, ErrMissingArgument) {
-			t.Errorf("CatFile(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, []string{}, gotErr, ErrMissingArgument)
+		if wantContext := "nonexistent.txt"; argContext(gotErr) != wantContext {
+			t.Errorf("CatFile(env, %v) context = %q, want %q", args, argContext(gotErr), wantContext)
+		}
+		if want := "console.log"; !strings.Contains(got, want) {
+			t.Errorf("CatFile(env, %v) content = %q, want to contain %q", args, got, want)
 		}
 	})
 }
 
 func TestResolvePath(t *testing.T) {
-	t.Run("home directory shortcuts", func(t *testing.T) {
+	t.Run("relative shortcuts", func(t *testing.T) {
 		tests := []struct {
 			currentDir string
 			targetPath string
 			want       string
 		}{
-			{"home/zorcal", "~", "home/guest"},
-			{"home/zorcal", "$HOME", "home/guest"},
+			{"home/zorcal", "~", "home/zorcal/~"}, // tilde is expanded by the shell
 			{"home/zorcal", ".", "home/zorcal"},
 			{"home/zorcal", "..", "home"},
 			{"", "..", ""},
@@ -650,6 +711,10 @@ func TestOpenFile(t *testing.T) {
 	tfs, sessMgr := setupTest()
 	sessionID := "session1"
 
+	if err := tfs.WriteFile("home/guest/links.md", []byte("[mail](mailto:a@example.com) and [site](https://example.com/site)\n[other](https://example.com/other)\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
 	tests := []struct {
 		name     string
 		startDir string
@@ -680,17 +745,23 @@ func TestOpenFile(t *testing.T) {
 			args:     []string{"../zorcal/projects/test-repo.md"},
 			wantURL:  "https://github.com/test/test-repo",
 		},
+		{
+			name:     "file with markdown links",
+			startDir: "home/guest",
+			args:     []string{"links.md"},
+			wantURL:  "https://example.com/site",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			sessMgr.SetCurrentDir(sessionID, tt.startDir)
 
-			got, err := OpenFile(tfs, sessMgr, sessionID, tt.args)
-			if err != nil {
-				t.Fatalf("OpenFile(tfs, sessMgr, %q, %v) error = %v, want nil", sessionID, tt.args, err)
+			env := newTestEnv(tfs, sessMgr, sessionID)
+			if err := OpenFile(env, tt.args); err != nil {
+				t.Fatalf("OpenFile(env, %v) error = %v, want nil", tt.args, err)
 			}
-			if got != tt.wantURL {
-				t.Errorf("OpenFile(tfs, sessMgr, %q, %v) url = %q, want %q", sessionID, tt.args, got, tt.wantURL)
+			if got := env.Output.OpenURL; got != tt.wantURL {
+				t.Errorf("OpenFile(env, %v) url = %q, want %q", tt.args, got, tt.wantURL)
 			}
 		})
 	}
@@ -700,6 +771,10 @@ func TestOpenFile_error(t *testing.T) {
 	tfs, sessMgr := setupTest()
 	sessionID := "session1"
 
+	if err := tfs.WriteFile("home/guest/script.md", []byte("**URL:** javascript:alert(1)\n")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
 	tests := []struct {
 		name        string
 		startDir    string
@@ -721,6 +796,13 @@ func TestOpenFile_error(t *testing.T) {
 			wantErr:     ErrNotOpenable,
 			wantContext: ".secret.txt",
 		},
+		{
+			name:        "file with script URL",
+			startDir:    "home/guest",
+			args:        []string{"script.md"},
+			wantErr:     ErrNotOpenable,
+			wantContext: "script.md",
+		},
 		{
 			name:        "directory instead of file",
 			startDir:    "",
@@ -740,15 +822,16 @@ func TestOpenFile_error(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			sessMgr.SetCurrentDir(sessionID, tt.startDir)
 
-			gotContext, gotErr := OpenFile(tfs, sessMgr, sessionID, tt.args)
+			_, gotErr := runCommand(tfs, sessMgr, sessionID, OpenFile, tt.args)
+			gotContext := argContext(gotErr)
 			if gotErr == nil {
-				t.Fatalf("OpenFile(tfs, sessMgr, %q, %v) error = nil, want error", sessionID, tt.args)
+				t.Fatalf("OpenFile(env, %v) error = nil, want error", tt.args)
 			}
 			if !errors.Is(gotErr, tt.wantErr) {
-				t.Errorf("OpenFile(tfs, sessMgr, %q, %v) error = %v, want %v", sessionID, tt.args, gotErr, tt.wantErr)
+				t.Errorf("OpenFile(env, %v) error = %v, want %v", tt.args, gotErr, tt.wantErr)
 			}
 			if gotContext != tt.wantContext {
-				t.Errorf("OpenFile(tfs, sessMgr, %q, %v) context = %q, want %q", sessionID, tt.args, gotContext, tt.wantContext)
+				t.Errorf("OpenFile(env, %v) context = %q, want %q", tt.args, gotContext, tt.wantContext)
 			}
 		})
 	}
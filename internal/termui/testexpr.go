@@ -0,0 +1,161 @@
+package termui
+
+import (
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// unaryTests are the unary operators of test, checking a string or a file.
+var unaryTests = []string{"-n", "-z", "-e", "-f", "-d", "-s"}
+
+// binaryTests are the binary operators of test, comparing strings or
+// integers.
+var binaryTests = []string{"=", "==", "!=", "-eq", "-ne", "-lt", "-le", "-gt", "-ge"}
+
+// Test evaluates the conditional expression given by its arguments and exits
+// with status 0 if it is true, or 1 if it is false. An expression is one of:
+//
+//	string            string is not empty
+//	-n string         string is not empty
+//	-z string         string is empty
+//	-e file           file exists
+//	-f file           file exists and is a regular file
+//	-d file           file exists and is a directory
+//	-s file           file exists and is not empty
+//	s1 = s2           the strings are equal, also ==
+//	s1 != s2          the strings are not equal
+//	n1 -eq n2         the integers are equal, also -ne, -lt, -le, -gt, -ge
+//	! expr            expr is false
+//	( expr )          expr
+//
+// Like POSIX test, the expression is read according to the number of
+// arguments, so that an operator may also be compared as a string. Errors
+// are returned as *ArgError carrying the offending argument.
+// Possible errors: ErrIntegerExpected, ErrUnaryExpected, ErrBinaryExpected,
+// ErrTooManyArguments.
+func Test(env *Env, args []string) error {
+	ok, err := evalTest(env, args)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ExitStatus(1)
+	}
+	return nil
+}
+
+// Bracket is the [ form of Test, whose last argument must be ].
+// Possible errors: ErrMissingBracket, or any error returned by Test.
+func Bracket(env *Env, args []string) error {
+	if len(args) == 0 || args[len(args)-1] != "]" {
+		return ErrMissingBracket
+	}
+	return Test(env, args[:len(args)-1])
+}
+
+// evalTest evaluates the expression args of test.
+func evalTest(env *Env, args []string) (bool, error) {
+	switch len(args) {
+	case 0:
+		return false, nil
+
+	case 1:
+		return args[0] != "", nil
+
+	case 2:
+		if args[0] == "!" {
+			ok, err := evalTest(env, args[1:])
+			return !ok, err
+		}
+		if !slices.Contains(unaryTests, args[0]) {
+			return false, &ArgError{Arg: args[0], Err: ErrUnaryExpected}
+		}
+		return evalUnaryTest(env, args[0], args[1]), nil
+
+	case 3:
+		if slices.Contains(binaryTests, args[1]) {
+			return evalBinaryTest(args[0], args[1], args[2])
+		}
+		if args[0] == "!" {
+			ok, err := evalTest(env, args[1:])
+			return !ok, err
+		}
+		if args[0] == "(" && args[2] == ")" {
+			return evalTest(env, args[1:2])
+		}
+		return false, &ArgError{Arg: args[1], Err: ErrBinaryExpected}
+
+	default:
+		if args[0] == "!" {
+			ok, err := evalTest(env, args[1:])
+			return !ok, err
+		}
+		if args[0] == "(" && args[len(args)-1] == ")" {
+			return evalTest(env, args[1:len(args)-1])
+		}
+		return false, ErrTooManyArguments
+	}
+}
+
+// evalUnaryTest evaluates the unary expression op arg.
+func evalUnaryTest(env *Env, op, arg string) bool {
+	switch op {
+	case "-n":
+		return arg != ""
+	case "-z":
+		return arg == ""
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+	info, err := fs.Stat(env.FS, fsPath(resolvePath(currDir, arg)))
+	if arg == "" || err != nil {
+		return false
+	}
+
+	switch op {
+	case "-f":
+		return info.Mode().IsRegular()
+	case "-d":
+		return info.IsDir()
+	case "-s":
+		return info.Size() > 0
+	default:
+		return true
+	}
+}
+
+// evalBinaryTest evaluates the binary expression x op y.
+func evalBinaryTest(x, op, y string) (bool, error) {
+	switch op {
+	case "=", "==":
+		return x == y, nil
+	case "!=":
+		return x != y, nil
+	}
+
+	a, err := strconv.Atoi(strings.TrimSpace(x))
+	if err != nil {
+		return false, &ArgError{Arg: x, Err: ErrIntegerExpected}
+	}
+	b, err := strconv.Atoi(strings.TrimSpace(y))
+	if err != nil {
+		return false, &ArgError{Arg: y, Err: ErrIntegerExpected}
+	}
+
+	switch op {
+	case "-eq":
+		return a == b, nil
+	case "-ne":
+		return a != b, nil
+	case "-lt":
+		return a < b, nil
+	case "-le":
+		return a <= b, nil
+	case "-gt":
+		return a > b, nil
+	default:
+		return a >= b, nil
+	}
+}
@@ -0,0 +1,57 @@
+package termui
+
+import "testing"
+
+func TestTest(t *testing.T) {
+	tfs, sessMgr := setupTest()
+	sessionID := "session1"
+	sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{"no arguments", "test", "", 1},
+		{"non-empty string", "test a", "", 0},
+		{"empty string", "test ''", "", 1},
+		{"operator as string", "test -n", "", 0},
+		{"-n", "test -n a", "", 0},
+		{"-z", "test -z ''", "", 0},
+		{"-e", "test -e /home", "", 0},
+		{"-e missing", "test -e missing", "", 1},
+		{"-f file", "test -f app.js", "", 0},
+		{"-f directory", "test -f /home", "", 1},
+		{"-d", "test -d ..", "", 0},
+		{"-s", "test -s app.js", "", 0},
+		{"string equal", "test a = a", "", 0},
+		{"string equal with ==", "test a == b", "", 1},
+		{"string not equal", "test a != b", "", 0},
+		{"-eq", "test 01 -eq 1", "", 0},
+		{"-lt", "test 2 -lt 10", "", 0},
+		{"-ge", "test 2 -ge 10", "", 1},
+		{"negation", "test ! -d app.js", "", 0},
+		{"double negation", "test ! ! a = a", "", 0},
+		{"parentheses", "test '(' a ')'", "", 0},
+		{"bracket", "[ a = a ]", "", 0},
+		{"bracket false", "[ -z a ]", "", 1},
+		{"missing bracket", "[ a = a", "[: missing `]'\n", 2},
+		{"integer expected", "test a -lt 1", "test: a: integer expression expected\n", 2},
+		{"unary expected", "test -x a", "test: -x: unary operator expected\n", 2},
+		{"binary expected", "test a -x b", "test: -x: binary operator expected\n", 2},
+		{"too many arguments", "test a b c d", "test: too many arguments\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,532 @@
+package termui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// Head writes the first lines of the files given as arguments, or of
+// standard input if there are none. -n sets the number of lines, 10 by
+// default. With more than one file, each is preceded by a header naming it.
+// Errors are returned joined, each as *ArgError carrying the file the user
+// attempted to read.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrInvalidFlag.
+func Head(env *Env, args []string) error {
+	return headTail(env, args, func(lines []string, count lineCount) []string {
+		return lines[:min(count.n, len(lines))]
+	})
+}
+
+// Tail writes the last lines of the files given as arguments, or of
+// standard input if there are none. -n sets the number of lines, 10 by
+// default, or with a leading +, as in -n +2, the line to start at. With more
+// than one file, each is preceded by a header naming it. Errors are returned
+// joined, each as *ArgError carrying the file the user attempted to read.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrInvalidFlag.
+func Tail(env *Env, args []string) error {
+	return headTail(env, args, func(lines []string, count lineCount) []string {
+		if count.fromStart {
+			return lines[min(max(count.n-1, 0), len(lines)):]
+		}
+		return lines[max(len(lines)-count.n, 0):]
+	})
+}
+
+// headTail implements head and tail, which write the lines selected from
+// each input by selectLines.
+func headTail(env *Env, args []string, selectLines func(lines []string, count lineCount) []string) error {
+	var opts headTailOptions
+	flagSet := newHeadTailFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	names := inputNames(flagSet.Args())
+
+	var errs []error
+	for i, name := range names {
+		content, err := readInput(env, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if len(names) > 1 {
+			if i > 0 {
+				fmt.Fprintln(env.Stdout)
+			}
+			fmt.Fprintf(env.Stdout, "==> %s <==\n", displayName(name))
+		}
+
+		for _, line := range selectLines(splitLines(content), opts.lines) {
+			fmt.Fprintln(env.Stdout, line)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// headTailOptions holds the flags accepted by head and tail.
+type headTailOptions struct {
+	lines lineCount
+}
+
+func newHeadTailFlagSet(opts *headTailOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	opts.lines = lineCount{n: 10}
+	flagSet.Var(&opts.lines, "lines", 'n', "number of lines to print, or with tail, +number to start at that line")
+	return flagSet
+}
+
+// lineCount is the value of -n of head and tail: a number of lines, or with a
+// leading +, the line tail starts at. Signs other than a leading + are
+// invalid.
+type lineCount struct {
+	n         int
+	fromStart bool
+}
+
+func (c *lineCount) String() string {
+	if c.fromStart {
+		return "+" + strconv.Itoa(c.n)
+	}
+	return strconv.Itoa(c.n)
+}
+
+func (c *lineCount) Set(s string) error {
+	digits, fromStart := strings.CutPrefix(s, "+")
+	n, err := strconv.ParseUint(digits, 10, 31)
+	if err != nil {
+		return err
+	}
+	c.n, c.fromStart = int(n), fromStart
+	return nil
+}
+
+// WordCount writes the number of lines, words and bytes of the files given
+// as arguments, or of standard input if there are none, followed by a total
+// if there is more than one file. -l, -w and -c select the counts written;
+// all of them are written if none is selected. Errors are returned joined,
+// each as *ArgError carrying the file the user attempted to read.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrInvalidFlag.
+func WordCount(env *Env, args []string) error {
+	var opts wcOptions
+	flagSet := newWcFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+	if !opts.lines && !opts.words && !opts.bytes {
+		opts.lines, opts.words, opts.bytes = true, true, true
+	}
+
+	type counts struct {
+		name                string
+		lines, words, bytes int
+	}
+
+	var (
+		rows  []counts
+		total = counts{name: "total"}
+		errs  []error
+	)
+	names := inputNames(flagSet.Args())
+	for _, name := range names {
+		content, err := readInput(env, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		c := counts{
+			lines: strings.Count(content, "\n"),
+			words: len(strings.Fields(content)),
+			bytes: len(content),
+		}
+		if name != "-" || len(names) > 1 {
+			c.name = displayName(name)
+		}
+		rows = append(rows, c)
+
+		total.lines += c.lines
+		total.words += c.words
+		total.bytes += c.bytes
+	}
+	if len(names) > 1 {
+		rows = append(rows, total)
+	}
+
+	// Counts are aligned to the width of the largest one written, a count
+	// of the total.
+	largest := 0
+	for _, c := range []struct {
+		selected bool
+		n        int
+	}{{opts.lines, total.lines}, {opts.words, total.words}, {opts.bytes, total.bytes}} {
+		if c.selected {
+			largest = max(largest, c.n)
+		}
+	}
+	width := len(strconv.Itoa(largest))
+	for _, row := range rows {
+		var fields []string
+		if opts.lines {
+			fields = append(fields, fmt.Sprintf("%*d", width, row.lines))
+		}
+		if opts.words {
+			fields = append(fields, fmt.Sprintf("%*d", width, row.words))
+		}
+		if opts.bytes {
+			fields = append(fields, fmt.Sprintf("%*d", width, row.bytes))
+		}
+		if row.name != "" {
+			fields = append(fields, row.name)
+		}
+		fmt.Fprintln(env.Stdout, strings.Join(fields, " "))
+	}
+
+	return errors.Join(errs...)
+}
+
+// wcOptions holds the flags accepted by wc.
+type wcOptions struct {
+	lines bool
+	words bool
+	bytes bool
+}
+
+func newWcFlagSet(opts *wcOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.lines, "lines", 'l', false, "print the line counts")
+	flagSet.BoolVar(&opts.words, "words", 'w', false, "print the word counts")
+	flagSet.BoolVar(&opts.bytes, "bytes", 'c', false, "print the byte counts")
+	return flagSet
+}
+
+// Sort writes the lines of the files given as arguments, or of standard
+// input if there are none, sorted together. -n compares the numbers the lines
+// start with, -r reverses the order and -u writes only the first of lines
+// that compare equal. Errors are returned joined, each as *ArgError carrying
+// the file the user attempted to read.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrInvalidFlag.
+func Sort(env *Env, args []string) error {
+	var opts sortOptions
+	flagSet := newSortFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	var (
+		lines []string
+		errs  []error
+	)
+	for _, name := range inputNames(flagSet.Args()) {
+		content, err := readInput(env, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		lines = append(lines, splitLines(content)...)
+	}
+
+	compare := strings.Compare
+	if opts.numeric {
+		compare = func(a, b string) int {
+			return cmp.Compare(leadingNumber(a), leadingNumber(b))
+		}
+	}
+
+	slices.SortStableFunc(lines, func(a, b string) int {
+		c := compare(a, b)
+		if c == 0 && !opts.unique {
+			// Like GNU sort, lines with equal keys are ordered by their
+			// whole text.
+			c = strings.Compare(a, b)
+		}
+		if opts.reverse {
+			c = -c
+		}
+		return c
+	})
+
+	if opts.unique {
+		lines = slices.CompactFunc(lines, func(a, b string) bool {
+			return compare(a, b) == 0
+		})
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(env.Stdout, line)
+	}
+
+	return errors.Join(errs...)
+}
+
+// sortOptions holds the flags accepted by sort.
+type sortOptions struct {
+	reverse bool
+	numeric bool
+	unique  bool
+}
+
+func newSortFlagSet(opts *sortOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.reverse, "reverse", 'r', false, "reverse the result of comparisons")
+	flagSet.BoolVar(&opts.numeric, "numeric-sort", 'n', false, "compare according to the leading number")
+	flagSet.BoolVar(&opts.unique, "unique", 'u', false, "output only the first of equal lines")
+	return flagSet
+}
+
+// leadingNumber returns the number s starts with, after leading blanks, or 0
+// if it does not start with one.
+func leadingNumber(s string) float64 {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+
+	end := 0
+	if end < len(s) && s[end] == '-' {
+		end++
+	}
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+		end++
+	}
+
+	// The longest prefix that parses, so that e.g. "1.2.3" is 1.2.
+	for ; end > 0; end-- {
+		if n, err := strconv.ParseFloat(s[:end], 64); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// Uniq writes the lines of a file, or of standard input if no file is given,
+// with adjacent identical lines written once. -c prefixes each line with the
+// number of times it occurred. Errors are returned as *ArgError carrying the
+// file the user attempted to read.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrTooManyArguments,
+// ErrInvalidFlag.
+func Uniq(env *Env, args []string) error {
+	var opts uniqOptions
+	flagSet := newUniqFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	names := inputNames(flagSet.Args())
+	if len(names) > 1 {
+		return ErrTooManyArguments
+	}
+
+	content, err := readInput(env, names[0])
+	if err != nil {
+		return err
+	}
+
+	lines := splitLines(content)
+	for i := 0; i < len(lines); {
+		n := 1
+		for i+n < len(lines) && lines[i+n] == lines[i] {
+			n++
+		}
+
+		if opts.count {
+			fmt.Fprintf(env.Stdout, "%7d %s\n", n, lines[i])
+		} else {
+			fmt.Fprintln(env.Stdout, lines[i])
+		}
+		i += n
+	}
+
+	return nil
+}
+
+// uniqOptions holds the flags accepted by uniq.
+type uniqOptions struct {
+	count bool
+}
+
+func newUniqFlagSet(opts *uniqOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.count, "count", 'c', false, "prefix lines by the number of occurrences")
+	return flagSet
+}
+
+// Cut writes the fields selected by -f of each line of the files given as
+// arguments, or of standard input if there are none. Fields are separated by
+// the character given by -d, a tab by default. The field list is a comma
+// separated list of field numbers and ranges such as 2-4, 3- and -2. Lines
+// without a separator are written unchanged. Errors are returned joined,
+// each as *ArgError carrying the file the user attempted to read.
+// Possible errors: ErrFileNotFound, ErrIsDirectory, ErrInvalidFlag.
+func Cut(env *Env, args []string) error {
+	var opts cutOptions
+	flagSet := newCutFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+	if len([]rune(opts.delimiter)) != 1 {
+		return fmt.Errorf("%w: the delimiter must be a single character", ErrInvalidFlag)
+	}
+
+	ranges, err := parseFieldList(opts.fields)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+
+	var errs []error
+	for _, name := range inputNames(flagSet.Args()) {
+		content, err := readInput(env, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, line := range splitLines(content) {
+			if !strings.Contains(line, opts.delimiter) {
+				fmt.Fprintln(env.Stdout, line)
+				continue
+			}
+
+			var selected []string
+			for i, field := range strings.Split(line, opts.delimiter) {
+				if ranges.contains(i + 1) {
+					selected = append(selected, field)
+				}
+			}
+			fmt.Fprintln(env.Stdout, strings.Join(selected, opts.delimiter))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// cutOptions holds the flags accepted by cut.
+type cutOptions struct {
+	delimiter string
+	fields    string
+}
+
+func newCutFlagSet(opts *cutOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.StringVar(&opts.delimiter, "delimiter", 'd', "\t", "use the given character instead of tab as field delimiter")
+	flagSet.StringVar(&opts.fields, "fields", 'f', "", "select only these fields, e.g. 1,3-5")
+	return flagSet
+}
+
+// fieldRanges is a list of inclusive ranges of field numbers, counted from 1.
+type fieldRanges [][2]int
+
+// contains reports whether field n is in one of the ranges.
+func (r fieldRanges) contains(n int) bool {
+	for _, rng := range r {
+		if n >= rng[0] && n <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFieldList parses a field list such as 1,3-5,7-.
+func parseFieldList(list string) (fieldRanges, error) {
+	if list == "" {
+		return nil, errors.New("missing field list")
+	}
+
+	var ranges fieldRanges
+	for _, item := range strings.Split(list, ",") {
+		lo, hi, isRange := strings.Cut(item, "-")
+		if !isRange {
+			hi = lo
+		}
+
+		first, last := 1, int(^uint(0)>>1)
+		var err error
+		if lo != "" {
+			if first, err = strconv.Atoi(lo); err != nil || first < 1 {
+				return nil, fmt.Errorf("invalid field %q", item)
+			}
+		}
+		if hi != "" {
+			if last, err = strconv.Atoi(hi); err != nil || last < first {
+				return nil, fmt.Errorf("invalid field range %q", item)
+			}
+		}
+		if lo == "" && hi == "" {
+			return nil, fmt.Errorf("invalid field range %q", item)
+		}
+
+		ranges = append(ranges, [2]int{first, last})
+	}
+
+	return ranges, nil
+}
+
+// inputNames returns the files a text filter reads: args, or standard input
+// if there are none.
+func inputNames(args []string) []string {
+	if len(args) == 0 {
+		return []string{"-"}
+	}
+	return args
+}
+
+// displayName returns the name of the input name in headers and counts.
+func displayName(name string) string {
+	if name == "-" {
+		return "standard input"
+	}
+	return name
+}
+
+// readInput reads the file name, or standard input if name is "-". Errors
+// are returned as *ArgError carrying name.
+func readInput(env *Env, name string) (string, error) {
+	if name == "-" {
+		content, err := io.ReadAll(env.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("read stdin: %w", err)
+		}
+		return string(content), nil
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+	openPath := fsPath(resolvePath(currDir, name))
+
+	info, err := fs.Stat(env.FS, openPath)
+	if err != nil {
+		return "", &ArgError{Arg: name, Err: fmt.Errorf("stat file %q: %w", openPath, mapFSErr(err))}
+	}
+	if info.IsDir() {
+		return "", &ArgError{Arg: name, Err: ErrIsDirectory}
+	}
+
+	content, err := fs.ReadFile(env.FS, openPath)
+	if err != nil {
+		return "", &ArgError{Arg: name, Err: fmt.Errorf("read file %q: %w", openPath, mapFSErr(err))}
+	}
+
+	return string(content), nil
+}
+
+// splitLines splits content into lines without their line endings. A final
+// line without a newline is included.
+func splitLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,196 @@
+package termui
+
+import "testing"
+
+func TestTextUtilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "head",
+			line:       "head -n 2 test-repo.md",
+			wantOutput: "# test-repo\n\n",
+		},
+		{
+			name:       "head default lines",
+			line:       "head test-repo.md | wc -l",
+			wantOutput: "8\n",
+		},
+		{
+			name:       "head several files",
+			line:       "head -n1 app.js test-repo.md",
+			wantOutput: "==> app.js <==\nconsole.log('hello world');\n\n==> test-repo.md <==\n# test-repo\n",
+		},
+		{
+			name:       "head standard input",
+			line:       "cat test-repo.md | head -n 1",
+			wantOutput: "# test-repo\n",
+		},
+		{
+			name:       "head plus lines",
+			line:       "head -n +1 app.js",
+			wantOutput: "console.log('hello world');\n",
+		},
+		{
+			name:       "head negative lines",
+			line:       "head -n -1 app.js",
+			wantOutput: "head: invalid flag or option\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "tail",
+			line:       "tail -n 2 test-repo.md",
+			wantOutput: "**URL:** https://github.com/test/test-repo\n**Last Updated:** 2024-01-01T00:00:00Z\n",
+		},
+		{
+			name:       "tail without final newline",
+			line:       "tail -n 1 app.js",
+			wantOutput: "**URL:** https://github.com/example/app-js\n",
+		},
+		{
+			name:       "tail from line",
+			line:       "tail -n +2 app.js",
+			wantOutput: "\n**URL:** https://github.com/example/app-js\n",
+		},
+		{
+			name:       "tail from line past the end",
+			line:       "tail -n +5 app.js",
+			wantOutput: "",
+		},
+		{
+			name:       "tail negative lines",
+			line:       "tail -n -1 app.js",
+			wantOutput: "tail: invalid flag or option\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "tail more lines than the file",
+			line:       "tail -n 100 app.js | wc -l",
+			wantOutput: "3\n",
+		},
+		{
+			name:       "wc",
+			line:       "wc test-repo.md",
+			wantOutput: "  8  14 145 test-repo.md\n",
+		},
+		{
+			name:       "wc selected counts",
+			line:       "wc -lw app.js test-repo.md",
+			wantOutput: " 2  4 app.js\n 8 14 test-repo.md\n10 18 total\n",
+		},
+		{
+			name:       "wc standard input",
+			line:       "echo one two | wc -wc",
+			wantOutput: "2 8\n",
+		},
+		{
+			name:       "sort",
+			line:       "echo 'b\nc\na\nb' > f; sort f",
+			wantOutput: "a\nb\nb\nc\n",
+		},
+		{
+			name:       "sort several files",
+			line:       "echo 'b\na' > f; echo 'c' > g; sort g f",
+			wantOutput: "a\nb\nc\n",
+		},
+		{
+			name:       "sort reverse unique",
+			line:       "echo 'b\nc\na\nb' | sort -ru",
+			wantOutput: "c\nb\na\n",
+		},
+		{
+			name:       "sort numeric",
+			line:       "echo '10 ten\n9 nine\n-1 minus\nnone\n2.5 half' | sort -n",
+			wantOutput: "-1 minus\nnone\n2.5 half\n9 nine\n10 ten\n",
+		},
+		{
+			name:       "sort numeric ties by text",
+			line:       "echo '1 b\n1 a\n0' | sort -n",
+			wantOutput: "0\n1 a\n1 b\n",
+		},
+		{
+			name:       "sort numeric unique",
+			line:       "echo '1 b\n1 a\n01' | sort -nu",
+			wantOutput: "1 b\n",
+		},
+		{
+			name:       "uniq",
+			line:       "echo 'a\na\nb\na' | uniq",
+			wantOutput: "a\nb\na\n",
+		},
+		{
+			name:       "uniq count",
+			line:       "echo 'a\nb\nb\nc\nb' | sort | uniq -c",
+			wantOutput: "      1 a\n      3 b\n      1 c\n",
+		},
+		{
+			name:       "uniq too many files",
+			line:       "uniq app.js test-repo.md",
+			wantOutput: "uniq: too many arguments\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "cut",
+			line:       "echo 'a:b:c:d\nnone' | cut -d : -f 2",
+			wantOutput: "b\nnone\n",
+		},
+		{
+			name:       "cut field ranges",
+			line:       "echo 'a:b:c:d:e' | cut -d: -f -2,4-",
+			wantOutput: "a:b:d:e\n",
+		},
+		{
+			name:       "cut tab delimiter",
+			line:       "echo 'a\tb\tc' > f; cut -f 2-3 f",
+			wantOutput: "b\tc\n",
+		},
+		{
+			name:       "cut file",
+			line:       "grep '^\\*\\*' test-repo.md | cut -d ' ' -f 1",
+			wantOutput: "**Language:**\n**Stars:**\n**URL:**\n**Last\n",
+		},
+		{
+			name:       "cut missing field list",
+			line:       "cut -d : app.js",
+			wantOutput: "cut: invalid flag or option\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "cut invalid field list",
+			line:       "cut -f 0 app.js",
+			wantOutput: "cut: invalid flag or option\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "cut long delimiter",
+			line:       "cut -d ab -f 1 app.js",
+			wantOutput: "cut: invalid flag or option\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "missing file",
+			line:       "wc -l missing.txt app.js",
+			wantOutput: "2 app.js\n2 total\nwc: missing.txt: No such file or directory\n",
+			wantStatus: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package termui
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/zorcal/its-a-me-zorcal/pkg/posixflag"
+)
+
+// Tree writes the hierarchy under a directory, the current directory if no
+// path is given, drawn with box-drawing characters and followed by a count of
+// the directories and files shown. Hidden files are only shown with -a, -d
+// shows directories only and -L limits the depth of the hierarchy. Errors
+// are returned as *ArgError carrying the path the user attempted to access.
+// Possible errors: ErrFileNotFound, ErrNotDirectory, ErrTooManyArguments,
+// ErrInvalidFlag.
+func Tree(env *Env, args []string) error {
+	var opts treeOptions
+	flagSet := newTreeFlagSet(&opts)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+	if opts.level < 0 {
+		return fmt.Errorf("%w: negative level", ErrInvalidFlag)
+	}
+
+	remaining := flagSet.Args()
+	if len(remaining) > 1 {
+		return ErrTooManyArguments
+	}
+
+	pathArg := "."
+	if len(remaining) == 1 {
+		pathArg = remaining[0]
+	}
+
+	currDir := env.Sessions.GetCurrentDir(env.SessionID)
+	openPath := fsPath(resolvePath(currDir, pathArg))
+
+	info, err := fs.Stat(env.FS, openPath)
+	if err != nil {
+		return &ArgError{Arg: pathArg, Err: fmt.Errorf("stat path %q: %w", openPath, mapFSErr(err))}
+	}
+	if !info.IsDir() {
+		return &ArgError{Arg: pathArg, Err: ErrNotDirectory}
+	}
+
+	t := &treeWriter{env: env, opts: opts}
+	fmt.Fprintln(env.Stdout, pathArg)
+	if err := t.writeDir(openPath, "", 1); err != nil {
+		return &ArgError{Arg: pathArg, Err: err}
+	}
+
+	fmt.Fprintf(env.Stdout, "\n%s", plural(t.dirs, "directory", "directories"))
+	if !opts.dirsOnly {
+		fmt.Fprintf(env.Stdout, ", %s", plural(t.files, "file", "files"))
+	}
+	fmt.Fprintln(env.Stdout)
+
+	return nil
+}
+
+// treeOptions holds the flags accepted by tree.
+type treeOptions struct {
+	showAll  bool
+	dirsOnly bool
+	// level is the maximum depth shown, or 0 for no limit.
+	level int
+}
+
+func newTreeFlagSet(opts *treeOptions) *posixflag.FlagSet {
+	flagSet := posixflag.NewFlagSet()
+	flagSet.BoolVar(&opts.showAll, "all", 'a', false, "show hidden files")
+	flagSet.BoolVar(&opts.dirsOnly, "dirs-only", 'd', false, "list directories only")
+	flagSet.IntVar(&opts.level, "level", 'L', 0, "descend at most level directories deep, 0 for no limit")
+	return flagSet
+}
+
+// treeWriter writes the entries of a tree and counts them.
+type treeWriter struct {
+	env   *Env
+	opts  treeOptions
+	dirs  int
+	files int
+}
+
+// writeDir writes the entries of the directory at dirPath, depth levels below
+// the root, with each line starting with prefix.
+func (t *treeWriter) writeDir(dirPath, prefix string, depth int) error {
+	entries, err := fs.ReadDir(t.env.FS, dirPath)
+	if err != nil {
+		return fmt.Errorf("read directory %q: %w", dirPath, mapFSErr(err))
+	}
+
+	var shown []fs.DirEntry
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") && !t.opts.showAll {
+			continue
+		}
+		if !entry.IsDir() && t.opts.dirsOnly {
+			continue
+		}
+		shown = append(shown, entry)
+	}
+
+	for i, entry := range shown {
+		branch, indent := "├── ", "│   "
+		if i == len(shown)-1 {
+			branch, indent = "└── ", "    "
+		}
+		io.WriteString(t.env.Stdout, prefix+branch+entry.Name()+"\n")
+
+		if !entry.IsDir() {
+			t.files++
+			continue
+		}
+
+		t.dirs++
+		if t.opts.level > 0 && depth >= t.opts.level {
+			continue
+		}
+
+		childPath := entry.Name()
+		if dirPath != "." {
+			childPath = dirPath + "/" + entry.Name()
+		}
+		if err := t.writeDir(childPath, prefix+indent, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// plural formats n followed by singular or plural as n requires.
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
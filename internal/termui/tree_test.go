@@ -0,0 +1,107 @@
+package termui
+
+import "testing"
+
+func TestTree(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name: "current directory",
+			line: "tree",
+			wantOutput: ".\n" +
+				"├── app.js\n" +
+				"└── test-repo.md\n" +
+				"\n0 directories, 2 files\n",
+		},
+		{
+			name: "path",
+			line: "tree /home",
+			wantOutput: "/home\n" +
+				"├── guest\n" +
+				"│   └── welcome.txt\n" +
+				"└── zorcal\n" +
+				"    └── projects\n" +
+				"        ├── app.js\n" +
+				"        └── test-repo.md\n" +
+				"\n3 directories, 3 files\n",
+		},
+		{
+			name: "hidden files",
+			line: "tree -a /home/zorcal",
+			wantOutput: "/home/zorcal\n" +
+				"├── .secret.txt\n" +
+				"└── projects\n" +
+				"    ├── app.js\n" +
+				"    └── test-repo.md\n" +
+				"\n1 directory, 3 files\n",
+		},
+		{
+			name: "directories only",
+			line: "tree -d /home",
+			wantOutput: "/home\n" +
+				"├── guest\n" +
+				"└── zorcal\n" +
+				"    └── projects\n" +
+				"\n3 directories\n",
+		},
+		{
+			name: "level",
+			line: "tree -L 1 /home",
+			wantOutput: "/home\n" +
+				"├── guest\n" +
+				"└── zorcal\n" +
+				"\n2 directories, 0 files\n",
+		},
+		{
+			name: "session files",
+			line: "mkdir -p a/b && touch a/b/c.txt && tree -L1 a",
+			wantOutput: "a\n" +
+				"└── b\n" +
+				"\n1 directory, 0 files\n",
+		},
+		{
+			name:       "negative level",
+			line:       "tree -L -1",
+			wantOutput: "tree: invalid flag or option\n",
+			wantStatus: 2,
+		},
+		{
+			name:       "file",
+			line:       "tree app.js",
+			wantOutput: "tree: app.js: Not a directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "missing directory",
+			line:       "tree missing",
+			wantOutput: "tree: missing: No such file or directory\n",
+			wantStatus: 1,
+		},
+		{
+			name:       "too many arguments",
+			line:       "tree a b",
+			wantOutput: "tree: too many arguments\n",
+			wantStatus: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfs, sessMgr := setupTest()
+			sessionID := "session1"
+			sessMgr.SetCurrentDir(sessionID, "home/zorcal/projects")
+
+			env := &Env{FS: tfs, Sessions: sessMgr, SessionID: sessionID}
+			out, status := NewRegistry().Exec(env, tt.line)
+			if status != tt.wantStatus {
+				t.Errorf("Exec(env, %q) status = %d, want %d", tt.line, status, tt.wantStatus)
+			}
+			if got := out.String(); got != tt.wantOutput {
+				t.Errorf("Exec(env, %q) output = %q, want %q", tt.line, got, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package posixflag
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -27,6 +28,12 @@ type Flag struct {
 	DefValue string
 }
 
+// IsBool reports whether the flag is a boolean flag, which takes no value.
+func (f *Flag) IsBool() bool {
+	_, ok := f.Value.(*boolValue)
+	return ok
+}
+
 type Value interface {
 	String() string
 	Set(string) error
@@ -62,6 +69,21 @@ func (s *stringValue) Set(value string) error {
 	return nil
 }
 
+type intValue int
+
+func (i *intValue) String() string {
+	return strconv.Itoa(int(*i))
+}
+
+func (i *intValue) Set(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*i = intValue(v)
+	return nil
+}
+
 func NewFlagSet() *FlagSet {
 	return &FlagSet{
 		flags:       make(map[string]*Flag),
@@ -79,6 +101,11 @@ func (fs *FlagSet) StringVar(p *string, name string, short rune, value string, u
 	fs.Var((*stringValue)(p), name, short, usage)
 }
 
+func (fs *FlagSet) IntVar(p *int, name string, short rune, value int, usage string) {
+	*p = value
+	fs.Var((*intValue)(p), name, short, usage)
+}
+
 func (fs *FlagSet) Var(value Value, name string, short rune, usage string) {
 	flag := &Flag{
 		Name:     name,
@@ -97,7 +124,8 @@ func (fs *FlagSet) Parse(args []string) error {
 	fs.parsed = true
 	fs.args = []string{}
 
-	for i, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		if !strings.HasPrefix(arg, "-") || arg == "-" {
 			fs.args = append(fs.args, arg)
 			continue
@@ -188,6 +216,18 @@ func (fs *FlagSet) Lookup(name string) *Flag {
 	return fs.flags[name]
 }
 
+func (fs *FlagSet) VisitAll(fn func(*Flag)) {
+	names := make([]string, 0, len(fs.flags))
+	for name := range fs.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fn(fs.flags[name])
+	}
+}
+
 func (fs *FlagSet) Parsed() bool {
 	return fs.parsed
 }
@@ -2,6 +2,7 @@ package posixflag
 
 import (
 	"errors"
+	"slices"
 	"testing"
 )
 
@@ -144,6 +145,63 @@ func TestFlagSet_stringFlags_error(t *testing.T) {
 	}
 }
 
+func TestFlagSet_intFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{
+			name: "short flag with value",
+			args: []string{"-n", "5"},
+			want: 5,
+		},
+		{
+			name: "long flag with equals",
+			args: []string{"--lines=-3"},
+			want: -3,
+		},
+		{
+			name: "short flag with attached value",
+			args: []string{"-n20"},
+			want: 20,
+		},
+		{
+			name: "no flag",
+			args: []string{},
+			want: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			var lines int
+
+			fs.IntVar(&lines, "lines", 'n', 10, "number of lines")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if lines != tt.want {
+				t.Errorf("got lines = %v, want %v", lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagSet_intFlags_error(t *testing.T) {
+	fs := NewFlagSet()
+	var lines int
+
+	fs.IntVar(&lines, "lines", 'n', 10, "number of lines")
+
+	if err := fs.Parse([]string{"-n", "five"}); err == nil {
+		t.Error("got nil error, want non-nil")
+	}
+}
+
 func TestFlagSet_combinedFlags(t *testing.T) {
 	fs := NewFlagSet()
 	var verbose, debug, all bool
@@ -196,6 +254,51 @@ func TestFlagSet_args(t *testing.T) {
 	}
 }
 
+func TestFlagSet_args_flagValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "short flag with value",
+			args: []string{"arg1", "-f", "file.txt", "arg2"},
+		},
+		{
+			name: "long flag with value",
+			args: []string{"arg1", "--file", "file.txt", "arg2"},
+		},
+		{
+			name: "combined short flags with value",
+			args: []string{"arg1", "-vf", "file.txt", "arg2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			var verbose bool
+			var file string
+
+			fs.BoolVar(&verbose, "verbose", 'v', false, "verbose output")
+			fs.StringVar(&file, "file", 'f', "", "input file")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if file != "file.txt" {
+				t.Errorf("got file = %v, want file.txt", file)
+			}
+
+			args := fs.Args()
+			want := []string{"arg1", "arg2"}
+			if !slices.Equal(args, want) {
+				t.Errorf("got args = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
 func TestFlagSet_unknownFlag_error(t *testing.T) {
 	fs := NewFlagSet()
 	var verbose bool
@@ -279,3 +382,47 @@ func TestFlagSet_emptyArgs(t *testing.T) {
 		t.Errorf("got args = %v, want empty slice", args)
 	}
 }
+
+func TestFlagSet_visitAll(t *testing.T) {
+	fs := NewFlagSet()
+	var verbose, all bool
+	var file string
+
+	fs.BoolVar(&verbose, "verbose", 'v', false, "verbose output")
+	fs.StringVar(&file, "file", 'f', "default.txt", "input file")
+	fs.BoolVar(&all, "all", 'a', false, "show all")
+
+	var names []string
+	fs.VisitAll(func(f *Flag) {
+		names = append(names, f.Name)
+	})
+
+	want := []string{"all", "file", "verbose"}
+	if len(names) != len(want) {
+		t.Fatalf("got %d flags, want %d", len(names), len(want))
+	}
+
+	for i, name := range names {
+		if name != want[i] {
+			t.Errorf("names[%d]: got %v, want %v", i, name, want[i])
+		}
+	}
+}
+
+func TestFlag_isBool(t *testing.T) {
+	fs := NewFlagSet()
+	var verbose bool
+	var file string
+	var count int
+
+	fs.BoolVar(&verbose, "verbose", 'v', false, "verbose output")
+	fs.StringVar(&file, "file", 'f', "default.txt", "input file")
+	fs.IntVar(&count, "count", 'c', 1, "count")
+
+	want := map[string]bool{"verbose": true, "file": false, "count": false}
+	for name, wantBool := range want {
+		if got := fs.Lookup(name).IsBool(); got != wantBool {
+			t.Errorf("Lookup(%q).IsBool() = %v, want %v", name, got, wantBool)
+		}
+	}
+}
@@ -53,42 +53,82 @@ func (m *Manager[T]) GetOrCreateSession(sessionID string) *Session[T] {
 	return session
 }
 
-// CleanupOldSessions removes sessions older than maxAge.
-func (m *Manager[T]) CleanupOldSessions(maxAge time.Duration) {
+// GetSession returns an existing session, if there is one.
+func (m *Manager[T]) GetSession(sessionID string) (*Session[T], bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	return session, exists
+}
+
+// RemoveSession removes a session, if it exists.
+func (m *Manager[T]) RemoveSession(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, sessionID)
+}
+
+// CleanupOldSessions removes sessions older than maxAge and returns the IDs
+// of the removed sessions.
+func (m *Manager[T]) CleanupOldSessions(maxAge time.Duration) []string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	var removed []string
 	cutoff := time.Now().Add(-maxAge)
 	for id, session := range m.sessions {
 		if session.lastUsed.Before(cutoff) {
 			delete(m.sessions, id)
+			removed = append(removed, id)
 		}
 	}
+	return removed
 }
 
-// Session stores typed history entries for a user session.
+// Session stores typed history entries for a user session. Each entry added
+// is numbered with the next of a monotonically increasing sequence, starting
+// at 1, which lets callers ask for the entries added after one they have.
 type Session[T any] struct {
 	id           string
 	history      []T
 	historyLimit int
 	lastUsed     time.Time
-	mu           sync.RWMutex
+	// seq is the last sequence number handed out, and firstSeq the sequence
+	// number of history[0].
+	seq      int64
+	firstSeq int64
+	// clearedSeq is the sequence number of the last ClearHistory.
+	clearedSeq int64
+	// screenSeq is the last sequence number handed out before the screen
+	// was last cleared.
+	screenSeq int64
+	mu        sync.RWMutex
 }
 
 func (s *Session[T]) ID() string {
 	return s.id
 }
 
-// AddEntry adds an entry to the session history with automatic trimming.
-func (s *Session[T]) AddEntry(entry T) {
+// AddEntry adds an entry to the session history with automatic trimming,
+// and returns its sequence number.
+func (s *Session[T]) AddEntry(entry T) int64 {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.seq++
+	if len(s.history) == 0 {
+		s.firstSeq = s.seq
+	}
 	s.history = append(s.history, entry)
 
 	if len(s.history) > s.historyLimit {
 		s.history = s.history[1:]
+		s.firstSeq++
 	}
+
+	return s.seq
 }
 
 // History returns a copy of the session's history entries.
@@ -99,10 +139,56 @@ func (s *Session[T]) History() []T {
 	return slices.Clone(s.history)
 }
 
-// ClearHistory removes all entries from the session history.
+// HistorySince returns a copy of the entries added after the entry with
+// sequence number seq, along with the last sequence number handed out, to
+// ask for the next entries with. Entries removed by trimming are skipped.
+// If the history was cleared after seq, or seq is ahead of the session, e.g.
+// because it was handed out before a restart, the caller's entries up to seq
+// are stale: every entry is returned and reset is true.
+func (s *Session[T]) HistorySince(seq int64) (entries []T, last int64, reset bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if seq < s.clearedSeq || seq > s.seq {
+		return slices.Clone(s.history), s.seq, true
+	}
+
+	return s.entriesAfter(seq), s.seq, false
+}
+
+// entriesAfter returns a copy of the entries added after the entry with
+// sequence number seq. The caller must hold s.mu.
+func (s *Session[T]) entriesAfter(seq int64) []T {
+	skip := max(seq-s.firstSeq+1, 0)
+	return slices.Clone(s.history[min(skip, int64(len(s.history))):])
+}
+
+// ClearScreen marks the entries added so far as cleared from the screen of
+// the session. They stay in the history.
+func (s *Session[T]) ClearScreen() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.screenSeq = s.seq
+}
+
+// Screen returns a copy of the entries added since the screen was last
+// cleared.
+func (s *Session[T]) Screen() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.entriesAfter(s.screenSeq)
+}
+
+// ClearHistory removes all entries from the session history. The clear
+// takes a sequence number of its own, which tells the sequence numbers
+// handed out before it from those handed out after.
 func (s *Session[T]) ClearHistory() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.history = s.history[:0]
+	s.seq++
+	s.clearedSeq = s.seq
 }